@@ -18,6 +18,7 @@ type Config struct {
 	CredentialsFile   string
 	TokenFile         string
 	Scopes            []string
+	Subject           string // user a service account acts as through domain-wide delegation, if any
 }
 
 // GetClient returns an authenticated HTTP client.
@@ -35,7 +36,10 @@ func getServiceAccountClient(ctx context.Context, config Config) (*oauth2.Config
 		return nil, nil, fmt.Errorf("auth: failed to read service account file: %w", err)
 	}
 
-	creds, err := google.CredentialsFromJSON(ctx, data, config.Scopes...)
+	creds, err := google.CredentialsFromJSONWithParams(ctx, data, google.CredentialsParams{
+		Scopes:  config.Scopes,
+		Subject: config.Subject,
+	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("auth: failed to parse service account credentials: %w", err)
 	}
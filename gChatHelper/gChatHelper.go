@@ -0,0 +1,221 @@
+package gChatHelper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gnzdotmx/gworkspace-helper/auth"
+	"google.golang.org/api/chat/v1"
+	"google.golang.org/api/option"
+)
+
+// SendMessage posts a text message to a Chat space, given as "spaces/AAAA" or just its ID. Text
+// supports Chat formatting such as *bold* and <https://example.com|links>.
+//
+// With a service account the message is sent as the Chat app, which must be configured in the
+// Google Cloud project and added to the space; config.Scopes must include
+// https://www.googleapis.com/auth/chat.bot. With OAuth the message is sent as the user, with the
+// https://www.googleapis.com/auth/chat.messages.create scope.
+func SendMessage(ctx context.Context, config auth.Config, spaceID, text string) (*chat.Message, error) {
+	if text == "" {
+		return nil, fmt.Errorf("gChatHelper: message has no text")
+	}
+	return createMessage(ctx, config, spaceID, &chat.Message{Text: text})
+}
+
+// SendCard posts card messages to a Chat space. text is shown above the cards and in
+// notifications, and may be empty. Cards can only be sent as a Chat app, that is, with a service
+// account.
+func SendCard(ctx context.Context, config auth.Config, spaceID, text string, cards ...*Card) (*chat.Message, error) {
+	if len(cards) == 0 {
+		return nil, fmt.Errorf("gChatHelper: no cards to send")
+	}
+	return createMessage(ctx, config, spaceID, newMessage(text, cards))
+}
+
+// Card builds a card message. Cards are made of sections, each holding a list of widgets:
+//
+//	card := gChatHelper.NewCard("Weekly sync", "Notes are ready")
+//	card.AddSection("").
+//		AddDecoratedText("When", "Monday 10:00").
+//		AddButtons(gChatHelper.Button{Text: "Open notes", URL: docURL}, gChatHelper.Button{Text: "Join", URL: meetLink})
+type Card struct {
+	card *chat.GoogleAppsCardV1Card
+}
+
+// NewCard returns a card with a header showing title and subtitle. An empty title leaves the
+// card without a header.
+func NewCard(title, subtitle string) *Card {
+	card := &Card{card: &chat.GoogleAppsCardV1Card{}}
+	if title != "" {
+		card.card.Header = &chat.GoogleAppsCardV1CardHeader{Title: title, Subtitle: subtitle}
+	}
+	return card
+}
+
+// SetHeaderImage shows the image at imageURL, e.g., a logo, in the header of the card.
+func (c *Card) SetHeaderImage(imageURL string) *Card {
+	if c.card.Header == nil {
+		c.card.Header = &chat.GoogleAppsCardV1CardHeader{}
+	}
+	c.card.Header.ImageUrl = imageURL
+	c.card.Header.ImageType = "CIRCLE"
+	return c
+}
+
+// AddSection appends a section with an optional header to the card and returns it so widgets
+// can be added to it.
+func (c *Card) AddSection(header string) *Section {
+	section := &chat.GoogleAppsCardV1Section{Header: header}
+	c.card.Sections = append(c.card.Sections, section)
+	return &Section{section: section}
+}
+
+// Section is a section of a card.
+type Section struct {
+	section *chat.GoogleAppsCardV1Section
+}
+
+// Button is a button opening a URL, such as a generated document or a Meet link.
+type Button struct {
+	Text string
+	URL  string
+}
+
+// AddText appends a paragraph of text, which supports basic HTML such as <b> and <a href>.
+func (s *Section) AddText(text string) *Section {
+	return s.addWidget(&chat.GoogleAppsCardV1Widget{
+		TextParagraph: &chat.GoogleAppsCardV1TextParagraph{Text: text},
+	})
+}
+
+// AddDecoratedText appends text with a label above it, e.g., "Owner" and "jane@example.com".
+func (s *Section) AddDecoratedText(label, text string) *Section {
+	return s.addWidget(&chat.GoogleAppsCardV1Widget{
+		DecoratedText: &chat.GoogleAppsCardV1DecoratedText{TopLabel: label, Text: text, WrapText: true},
+	})
+}
+
+// AddLink appends decorated text that opens url when clicked, followed by an "Open" button.
+func (s *Section) AddLink(label, text, url string) *Section {
+	onClick := openLink(url)
+	return s.addWidget(&chat.GoogleAppsCardV1Widget{
+		DecoratedText: &chat.GoogleAppsCardV1DecoratedText{
+			TopLabel: label,
+			Text:     text,
+			WrapText: true,
+			OnClick:  onClick,
+			Button:   &chat.GoogleAppsCardV1Button{Text: "Open", OnClick: onClick},
+		},
+	})
+}
+
+// AddButtons appends a row of buttons.
+func (s *Section) AddButtons(buttons ...Button) *Section {
+	list := &chat.GoogleAppsCardV1ButtonList{}
+	for _, button := range buttons {
+		list.Buttons = append(list.Buttons, &chat.GoogleAppsCardV1Button{Text: button.Text, OnClick: openLink(button.URL)})
+	}
+	return s.addWidget(&chat.GoogleAppsCardV1Widget{ButtonList: list})
+}
+
+// AddDivider appends a horizontal line.
+func (s *Section) AddDivider() *Section {
+	return s.addWidget(&chat.GoogleAppsCardV1Widget{Divider: &chat.GoogleAppsCardV1Divider{}})
+}
+
+// addWidget appends a widget to the section.
+func (s *Section) addWidget(widget *chat.GoogleAppsCardV1Widget) *Section {
+	s.section.Widgets = append(s.section.Widgets, widget)
+	return s
+}
+
+// openLink returns the action opening url in a new tab.
+func openLink(url string) *chat.GoogleAppsCardV1OnClick {
+	return &chat.GoogleAppsCardV1OnClick{OpenLink: &chat.GoogleAppsCardV1OpenLink{Url: url}}
+}
+
+// newMessage returns a message with text and cards.
+func newMessage(text string, cards []*Card) *chat.Message {
+	message := &chat.Message{Text: text}
+	for i, card := range cards {
+		message.CardsV2 = append(message.CardsV2, &chat.CardWithId{
+			CardId: fmt.Sprintf("card%d", i+1),
+			Card:   card.card,
+		})
+	}
+	return message
+}
+
+// SendWebhookMessage posts a message with text and optional cards to the incoming webhook of a
+// space. No OAuth credentials are needed: the key and token in webhookURL authorize the request,
+// so keep the URL secret. Appending "&threadKey=<key>&messageReplyOption=REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD"
+// to the URL groups messages with the same key into a thread.
+func SendWebhookMessage(ctx context.Context, webhookURL, text string, cards ...*Card) (*chat.Message, error) {
+	if text == "" && len(cards) == 0 {
+		return nil, fmt.Errorf("gChatHelper: message has no text or cards")
+	}
+
+	body, err := json.Marshal(newMessage(text, cards))
+	if err != nil {
+		return nil, fmt.Errorf("gChatHelper: unable to encode message: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("gChatHelper: invalid webhook URL: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json; charset=UTF-8")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		// The error includes the URL, which holds the webhook credentials
+		return nil, fmt.Errorf("gChatHelper: unable to send webhook message: %w", errors.Unwrap(err))
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return nil, fmt.Errorf("gChatHelper: webhook returned %s: %s", response.Status, strings.TrimSpace(string(detail)))
+	}
+
+	var sent chat.Message
+	if err := json.NewDecoder(response.Body).Decode(&sent); err != nil {
+		return nil, fmt.Errorf("gChatHelper: unable to decode webhook response: %w", err)
+	}
+	return &sent, nil
+}
+
+// createMessage posts message to a Chat space.
+func createMessage(ctx context.Context, config auth.Config, spaceID string, message *chat.Message) (*chat.Message, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gChatHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	chatService, err := chat.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gChatHelper: unable to create chat service: %w", err)
+	}
+
+	sent, err := chatService.Spaces.Messages.Create(spaceName(spaceID), message).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gChatHelper: unable to send message to %s: %w", spaceID, err)
+	}
+	return sent, nil
+}
+
+// spaceName returns the resource name of a space given by name or ID.
+func spaceName(spaceID string) string {
+	if strings.HasPrefix(spaceID, "spaces/") {
+		return spaceID
+	}
+	return "spaces/" + spaceID
+}
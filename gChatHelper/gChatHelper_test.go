@@ -0,0 +1,82 @@
+package gChatHelper
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNewMessageJSON(t *testing.T) {
+	summary := NewCard("Weekly sync", "Notes are ready").SetHeaderImage("https://example.com/logo.png")
+	summary.AddSection("Details").
+		AddText("<b>Agenda</b>").
+		AddDecoratedText("When", "Monday 10:00").
+		AddLink("Notes", "Sync notes", "https://docs.example.com/notes").
+		AddDivider().
+		AddButtons(Button{Text: "Open notes", URL: "https://docs.example.com/notes"}, Button{Text: "Join", URL: "https://meet.example.com/abc"})
+	plain := NewCard("", "")
+	plain.AddSection("").AddText("No header")
+
+	body, err := json.Marshal(newMessage("hello", []*Card{summary, plain}))
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	want := `{
+		"text": "hello",
+		"cardsV2": [
+			{
+				"cardId": "card1",
+				"card": {
+					"header": {
+						"title": "Weekly sync",
+						"subtitle": "Notes are ready",
+						"imageUrl": "https://example.com/logo.png",
+						"imageType": "CIRCLE"
+					},
+					"sections": [{
+						"header": "Details",
+						"widgets": [
+							{"textParagraph": {"text": "<b>Agenda</b>"}},
+							{"decoratedText": {"topLabel": "When", "text": "Monday 10:00", "wrapText": true}},
+							{"decoratedText": {
+								"topLabel": "Notes",
+								"text": "Sync notes",
+								"wrapText": true,
+								"onClick": {"openLink": {"url": "https://docs.example.com/notes"}},
+								"button": {"text": "Open", "onClick": {"openLink": {"url": "https://docs.example.com/notes"}}}
+							}},
+							{"divider": {}},
+							{"buttonList": {"buttons": [
+								{"text": "Open notes", "onClick": {"openLink": {"url": "https://docs.example.com/notes"}}},
+								{"text": "Join", "onClick": {"openLink": {"url": "https://meet.example.com/abc"}}}
+							]}}
+						]
+					}]
+				}
+			},
+			{
+				"cardId": "card2",
+				"card": {"sections": [{"widgets": [{"textParagraph": {"text": "No header"}}]}]}
+			}
+		]
+	}`
+
+	var got, wantValue any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("json.Unmarshal(want): %v", err)
+	}
+	if !reflect.DeepEqual(got, wantValue) {
+		t.Fatalf("message JSON = %s, want %s", body, want)
+	}
+}
+
+func TestNewMessageTextOnly(t *testing.T) {
+	message := newMessage("hello", nil)
+	if message.Text != "hello" || message.CardsV2 != nil {
+		t.Fatalf("newMessage() = %+v, want text only", message)
+	}
+}
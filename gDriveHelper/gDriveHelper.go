@@ -2,10 +2,28 @@ package gDriveHelper
 
 import (
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gnzdotmx/gworkspace-helper/auth"
+	"github.com/gnzdotmx/gworkspace-helper/internal/channelstore"
+	"github.com/gnzdotmx/gworkspace-helper/internal/concurrency"
+	"github.com/google/uuid"
+	admin "google.golang.org/api/admin/directory/v1"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
@@ -167,3 +185,2479 @@ func DeleteFileOrFolder(ctx context.Context, config auth.Config, folderFileID st
 
 	return nil
 }
+
+// CreateShortcut creates a shortcut to the target file inside the given parent folder.
+func CreateShortcut(ctx context.Context, config auth.Config, targetID, parentFolderID, name string) (*drive.File, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	shortcut := &drive.File{
+		Name:     name,
+		MimeType: "application/vnd.google-apps.shortcut",
+		Parents:  []string{parentFolderID},
+		ShortcutDetails: &drive.FileShortcutDetails{
+			TargetId: targetID,
+		},
+	}
+
+	createdShortcut, err := driveService.Files.Create(shortcut).SupportsAllDrives(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create shortcut: %w", err)
+	}
+	return createdShortcut, nil
+}
+
+// ResolveShortcut returns the file a shortcut points to. If fileID is not a shortcut,
+// the file itself is returned.
+func ResolveShortcut(ctx context.Context, config auth.Config, fileID string) (*drive.File, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	file, err := driveService.Files.Get(fileID).Fields("id", "name", "mimeType", "shortcutDetails").SupportsAllDrives(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to retrieve file: %w", err)
+	}
+
+	if file.MimeType != "application/vnd.google-apps.shortcut" || file.ShortcutDetails == nil {
+		return file, nil
+	}
+
+	target, err := driveService.Files.Get(file.ShortcutDetails.TargetId).Fields("id", "name", "mimeType", "parents", "webViewLink").SupportsAllDrives(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to retrieve shortcut target: %w", err)
+	}
+	return target, nil
+}
+
+// DeleteOptions controls how DeleteFileOrFolderWithOptions removes a file or folder.
+type DeleteOptions struct {
+	// Permanent skips the trash and deletes the item irreversibly. By default the item is moved to the trash.
+	Permanent bool
+}
+
+// DeleteFileOrFolderWithOptions removes a file or folder, moving it to the trash unless opts.Permanent is set.
+func DeleteFileOrFolderWithOptions(ctx context.Context, config auth.Config, folderFileID string, opts DeleteOptions) error {
+	if opts.Permanent {
+		return DeleteFileOrFolder(ctx, config, folderFileID)
+	}
+	return TrashFile(ctx, config, folderFileID)
+}
+
+// TrashFile moves a file or folder to the trash.
+func TrashFile(ctx context.Context, config auth.Config, fileID string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	_, err = driveService.Files.Update(fileID, &drive.File{Trashed: true}).SupportsAllDrives(true).Do()
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to trash file: %w", err)
+	}
+
+	return nil
+}
+
+// UntrashFile restores a file or folder from the trash.
+func UntrashFile(ctx context.Context, config auth.Config, fileID string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	// Trashed is a bool, so it has to be forced into the request to send false
+	file := &drive.File{
+		Trashed:         false,
+		ForceSendFields: []string{"Trashed"},
+	}
+
+	_, err = driveService.Files.Update(fileID, file).SupportsAllDrives(true).Do()
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to untrash file: %w", err)
+	}
+
+	return nil
+}
+
+// EmptyTrash permanently deletes all trashed files. If driveID is empty, the user's
+// own trash is emptied; otherwise the trash of the given shared drive is emptied.
+func EmptyTrash(ctx context.Context, config auth.Config, driveID string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	call := driveService.Files.EmptyTrash()
+	if driveID != "" {
+		call = call.DriveId(driveID)
+	}
+
+	err = call.Do()
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to empty trash: %w", err)
+	}
+
+	return nil
+}
+
+// FileMetadataOptions holds the metadata fields to change with UpdateFileMetadata.
+// Nil or empty fields are left untouched.
+type FileMetadataOptions struct {
+	Description    *string
+	Starred        *bool
+	FolderColorRgb string            // e.g., "#4986e7"; only applies to folders
+	Properties     map[string]string // visible to all apps
+	AppProperties  map[string]string // private to the requesting app
+}
+
+// UpdateFileMetadata updates the description, starred flag, folder color and custom properties of a file.
+func UpdateFileMetadata(ctx context.Context, config auth.Config, fileID string, opts FileMetadataOptions) (*drive.File, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	file := &drive.File{
+		FolderColorRgb: opts.FolderColorRgb,
+		Properties:     opts.Properties,
+		AppProperties:  opts.AppProperties,
+	}
+	if opts.Description != nil {
+		file.Description = *opts.Description
+		file.ForceSendFields = append(file.ForceSendFields, "Description")
+	}
+	if opts.Starred != nil {
+		file.Starred = *opts.Starred
+		file.ForceSendFields = append(file.ForceSendFields, "Starred")
+	}
+
+	updatedFile, err := driveService.Files.Update(fileID, file).
+		Fields("id", "name", "description", "starred", "folderColorRgb", "properties", "appProperties").
+		SupportsAllDrives(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to update file metadata: %w", err)
+	}
+	return updatedFile, nil
+}
+
+// SearchFilesByAppProperties returns all non-trashed files whose appProperties contain every
+// key/value pair in props.
+func SearchFilesByAppProperties(ctx context.Context, config auth.Config, props map[string]string) ([]*drive.File, error) {
+	if len(props) == 0 {
+		return nil, fmt.Errorf("gDriveHelper: at least one app property is required")
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	// Sort the keys so the generated query is stable
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	clauses := []string{"trashed = false"}
+	for _, key := range keys {
+		clauses = append(clauses, fmt.Sprintf("appProperties has { key='%s' and value='%s' }", escapeQueryValue(key), escapeQueryValue(props[key])))
+	}
+
+	var files []*drive.File
+	err = driveService.Files.List().
+		Q(strings.Join(clauses, " and ")).
+		Fields("nextPageToken", "files(id,name,mimeType,parents,appProperties,webViewLink)").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Pages(ctx, func(page *drive.FileList) error {
+			files = append(files, page.Files...)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to search files by app properties: %w", err)
+	}
+	return files, nil
+}
+
+// escapeQueryValue escapes a value for use inside a single-quoted Drive query string.
+func escapeQueryValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return strings.ReplaceAll(value, "'", `\'`)
+}
+
+// ListRevisions lists the revisions of a file, oldest first.
+func ListRevisions(ctx context.Context, config auth.Config, fileID string) ([]*drive.Revision, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	var revisions []*drive.Revision
+	err = driveService.Revisions.List(fileID).
+		Fields("nextPageToken", "revisions(id,modifiedTime,keepForever,size,md5Checksum,mimeType,originalFilename,lastModifyingUser)").
+		Pages(ctx, func(page *drive.RevisionList) error {
+			revisions = append(revisions, page.Revisions...)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to list revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// DownloadRevision writes the content of a specific revision to w.
+// Only revisions of binary files can be downloaded; Google Docs editors files must be exported instead.
+func DownloadRevision(ctx context.Context, config auth.Config, fileID, revisionID string, w io.Writer) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	response, err := driveService.Revisions.Get(fileID, revisionID).Download()
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to download revision: %w", err)
+	}
+	defer response.Body.Close()
+
+	if _, err := io.Copy(w, response.Body); err != nil {
+		return fmt.Errorf("gDriveHelper: unable to read revision content: %w", err)
+	}
+	return nil
+}
+
+// SetRevisionKeepForever pins or unpins a revision so it is not purged automatically.
+func SetRevisionKeepForever(ctx context.Context, config auth.Config, fileID, revisionID string, keepForever bool) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	revision := &drive.Revision{
+		KeepForever:     keepForever,
+		ForceSendFields: []string{"KeepForever"},
+	}
+
+	_, err = driveService.Revisions.Update(fileID, revisionID, revision).Do()
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to update revision: %w", err)
+	}
+	return nil
+}
+
+// DeleteRevision permanently deletes a revision of a binary file.
+func DeleteRevision(ctx context.Context, config auth.Config, fileID, revisionID string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	err = driveService.Revisions.Delete(fileID, revisionID).Do()
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to delete revision: %w", err)
+	}
+	return nil
+}
+
+// PruneRevisions deletes all but the newest keepLatest revisions of a file, skipping pinned
+// (keepForever) revisions. It returns the IDs of the deleted revisions.
+func PruneRevisions(ctx context.Context, config auth.Config, fileID string, keepLatest int) ([]string, error) {
+	revisions, err := ListRevisions(ctx, config, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The head revision can never be deleted
+	if keepLatest < 1 {
+		keepLatest = 1
+	}
+
+	var deleted []string
+	for i := 0; i < len(revisions)-keepLatest; i++ {
+		if revisions[i].KeepForever {
+			continue
+		}
+		if err := DeleteRevision(ctx, config, fileID, revisions[i].Id); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, revisions[i].Id)
+	}
+	return deleted, nil
+}
+
+// ErrPublicSharingNotAllowed is returned when an "anyone" permission is requested without AllowPublic.
+var ErrPublicSharingNotAllowed = errors.New("gDriveHelper: public sharing requires AllowPublic to be set")
+
+// PermissionOptions describes a permission grant for AddPermission.
+type PermissionOptions struct {
+	Type               string // "user", "group", "domain" or "anyone"
+	Role               string // e.g., "writer", "commenter", "reader"
+	EmailAddress       string // required for "user" and "group"
+	Domain             string // required for "domain"
+	AllowFileDiscovery bool   // for "domain" and "anyone": false means "anyone with the link"
+	AllowPublic        bool   // explicit opt-in required for "anyone" grants
+	SendNotification   bool   // email the grantee; only applies to "user" and "group"
+}
+
+// AddPermission grants access to a file or folder for a user, group, domain or anyone with the link.
+func AddPermission(ctx context.Context, config auth.Config, fileID string, opts PermissionOptions) (*drive.Permission, error) {
+	permission, err := buildPermission(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	call := driveService.Permissions.Create(fileID, permission).SupportsAllDrives(true)
+	if opts.Type == "user" || opts.Type == "group" {
+		call = call.SendNotificationEmail(opts.SendNotification)
+	}
+
+	createdPermission, err := call.Do()
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to add permission: %w", err)
+	}
+	return createdPermission, nil
+}
+
+// buildPermission validates opts and converts them into a Drive permission.
+func buildPermission(opts PermissionOptions) (*drive.Permission, error) {
+	if opts.Role == "" {
+		return nil, fmt.Errorf("gDriveHelper: permission role is required")
+	}
+
+	permission := &drive.Permission{
+		Type: opts.Type,
+		Role: opts.Role,
+	}
+
+	switch opts.Type {
+	case "user", "group":
+		if opts.EmailAddress == "" {
+			return nil, fmt.Errorf("gDriveHelper: email address is required for %s permissions", opts.Type)
+		}
+		permission.EmailAddress = opts.EmailAddress
+	case "domain":
+		if opts.Domain == "" {
+			return nil, fmt.Errorf("gDriveHelper: domain is required for domain permissions")
+		}
+		permission.Domain = opts.Domain
+		permission.AllowFileDiscovery = opts.AllowFileDiscovery
+		permission.ForceSendFields = []string{"AllowFileDiscovery"}
+	case "anyone":
+		if !opts.AllowPublic {
+			return nil, ErrPublicSharingNotAllowed
+		}
+		permission.AllowFileDiscovery = opts.AllowFileDiscovery
+		permission.ForceSendFields = []string{"AllowFileDiscovery"}
+	default:
+		return nil, fmt.Errorf("gDriveHelper: unsupported permission type %q", opts.Type)
+	}
+
+	return permission, nil
+}
+
+// LinkSharingOptions holds the file-level settings that control how a shared link can be used.
+type LinkSharingOptions struct {
+	CopyRequiresWriterPermission bool // prevent readers and commenters from copying, printing or downloading
+	WritersCanShare              bool // allow writers to change permissions
+}
+
+// SetLinkSharingSettings updates the sharing restrictions of a file.
+func SetLinkSharingSettings(ctx context.Context, config auth.Config, fileID string, opts LinkSharingOptions) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	file := &drive.File{
+		CopyRequiresWriterPermission: opts.CopyRequiresWriterPermission,
+		WritersCanShare:              opts.WritersCanShare,
+		ForceSendFields:              []string{"CopyRequiresWriterPermission", "WritersCanShare"},
+	}
+
+	_, err = driveService.Files.Update(fileID, file).SupportsAllDrives(true).Do()
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to update link sharing settings: %w", err)
+	}
+	return nil
+}
+
+// AddGroupPermission grants a Google Group access to a file or folder.
+func AddGroupPermission(ctx context.Context, config auth.Config, fileID, groupEmail, role string) error {
+	_, err := AddPermission(ctx, config, fileID, PermissionOptions{
+		Type:         "group",
+		Role:         role,
+		EmailAddress: groupEmail,
+	})
+	return err
+}
+
+// RemoveGroupPermission removes a Google Group's permission from a file or folder.
+func RemoveGroupPermission(ctx context.Context, config auth.Config, fileID, groupEmail string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	permissionsList, err := driveService.Permissions.List(fileID).Fields("permissions(id,type,emailAddress)").SupportsAllDrives(true).Do()
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to list permissions: %w", err)
+	}
+
+	var permissionID string
+	for _, permission := range permissionsList.Permissions {
+		if permission.Type == "group" && strings.EqualFold(permission.EmailAddress, groupEmail) {
+			permissionID = permission.Id
+			break
+		}
+	}
+
+	if permissionID == "" {
+		return fmt.Errorf("gDriveHelper: no group permission found for email %s", groupEmail)
+	}
+
+	err = driveService.Permissions.Delete(fileID, permissionID).SupportsAllDrives(true).Do()
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to remove group permission: %w", err)
+	}
+
+	return nil
+}
+
+// ResolveGranteeType reports whether an email address belongs to a Google Group ("group")
+// or not ("user"), using the Admin SDK Directory API. It requires the
+// https://www.googleapis.com/auth/admin.directory.group.readonly scope.
+func ResolveGranteeType(ctx context.Context, config auth.Config, email string) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	adminService, err := admin.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gDriveHelper: unable to create directory service: %w", err)
+	}
+
+	_, err = adminService.Groups.Get(email).Fields("id").Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == 404 {
+			return "user", nil
+		}
+		return "", fmt.Errorf("gDriveHelper: unable to look up group: %w", err)
+	}
+	return "group", nil
+}
+
+// AddGranteePermission grants access to an email address, using a group permission when the
+// address belongs to a Google Group and a user permission otherwise.
+func AddGranteePermission(ctx context.Context, config auth.Config, fileID, email, role string) error {
+	granteeType, err := ResolveGranteeType(ctx, config, email)
+	if err != nil {
+		return err
+	}
+
+	_, err = AddPermission(ctx, config, fileID, PermissionOptions{
+		Type:         granteeType,
+		Role:         role,
+		EmailAddress: email,
+	})
+	return err
+}
+
+// PermissionInfo is a simplified view of a Drive permission.
+type PermissionInfo struct {
+	ID                 string
+	Email              string
+	Domain             string
+	Role               string
+	Type               string
+	AllowFileDiscovery bool
+	Expiration         time.Time // zero if the permission does not expire
+	Inherited          bool      // true if the permission comes from a parent (shared drives only)
+}
+
+// ListPermissions lists the permissions of a file or folder.
+func ListPermissions(ctx context.Context, config auth.Config, fileID string) ([]PermissionInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	return listPermissions(ctx, driveService, fileID)
+}
+
+// listPermissions lists and converts the permissions of a file using an existing Drive service.
+func listPermissions(ctx context.Context, driveService *drive.Service, fileID string) ([]PermissionInfo, error) {
+	var permissions []PermissionInfo
+	err := driveService.Permissions.List(fileID).
+		Fields("nextPageToken", "permissions(id,emailAddress,domain,role,type,allowFileDiscovery,expirationTime,permissionDetails)").
+		SupportsAllDrives(true).
+		Pages(ctx, func(page *drive.PermissionList) error {
+			for _, permission := range page.Permissions {
+				permissions = append(permissions, toPermissionInfo(permission))
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to list permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// toPermissionInfo converts a Drive permission into a PermissionInfo.
+func toPermissionInfo(permission *drive.Permission) PermissionInfo {
+	info := PermissionInfo{
+		ID:                 permission.Id,
+		Email:              permission.EmailAddress,
+		Domain:             permission.Domain,
+		Role:               permission.Role,
+		Type:               permission.Type,
+		AllowFileDiscovery: permission.AllowFileDiscovery,
+	}
+	if permission.ExpirationTime != "" {
+		if expiration, err := time.Parse(time.RFC3339, permission.ExpirationTime); err == nil {
+			info.Expiration = expiration
+		}
+	}
+	for _, detail := range permission.PermissionDetails {
+		if detail.Inherited {
+			info.Inherited = true
+			break
+		}
+	}
+	return info
+}
+
+// BulkPermissionOptions describes the permission changes applied by ApplyPermissionsToTree.
+type BulkPermissionOptions struct {
+	Grants            []PermissionOptions // permissions to add to every item
+	RevokeEmails      []string            // user or group emails whose permissions are removed from every item
+	IncludeRoot       bool                // also apply the changes to the root folder itself
+	Concurrency       int                 // number of parallel workers, defaults to 4
+	RequestsPerSecond float64             // maximum number of items processed per second, defaults to 5
+}
+
+// PermissionResult reports the outcome of a bulk permission change for a single item.
+type PermissionResult struct {
+	FileID string
+	Name   string
+	Err    error
+}
+
+// ApplyPermissionsToTree adds and removes permissions on every file and subfolder under rootFolderID.
+// Failures on individual items are reported in the returned results rather than aborting the run.
+func ApplyPermissionsToTree(ctx context.Context, config auth.Config, rootFolderID string, opts BulkPermissionOptions) ([]PermissionResult, error) {
+	permissions := make([]*drive.Permission, 0, len(opts.Grants))
+	for _, grant := range opts.Grants {
+		permission, err := buildPermission(grant)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	var items []*drive.File
+	if opts.IncludeRoot {
+		root, err := driveService.Files.Get(rootFolderID).Fields("id", "name").SupportsAllDrives(true).Do()
+		if err != nil {
+			return nil, fmt.Errorf("gDriveHelper: unable to retrieve root folder: %w", err)
+		}
+		items = append(items, root)
+	}
+	err = walkTree(ctx, driveService, rootFolderID, "id,name,mimeType", func(file *drive.File, parentID string) error {
+		items = append(items, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]PermissionResult, len(items))
+	concurrency.ForEach(ctx, len(items), opts.Concurrency, opts.RequestsPerSecond, func(i int) {
+		file := items[i]
+		results[i] = PermissionResult{FileID: file.Id, Name: file.Name}
+
+		for _, permission := range permissions {
+			_, err := driveService.Permissions.Create(file.Id, permission).SupportsAllDrives(true).SendNotificationEmail(false).Context(ctx).Do()
+			if err != nil {
+				results[i].Err = fmt.Errorf("gDriveHelper: unable to add permission: %w", err)
+				return
+			}
+		}
+
+		if len(opts.RevokeEmails) == 0 {
+			return
+		}
+		current, err := listPermissions(ctx, driveService, file.Id)
+		if err != nil {
+			results[i].Err = err
+			return
+		}
+		for _, email := range opts.RevokeEmails {
+			for _, permission := range current {
+				if permission.Inherited || !strings.EqualFold(permission.Email, email) {
+					continue
+				}
+				err := driveService.Permissions.Delete(file.Id, permission.ID).SupportsAllDrives(true).Context(ctx).Do()
+				if err != nil {
+					results[i].Err = fmt.Errorf("gDriveHelper: unable to remove permission: %w", err)
+					return
+				}
+			}
+		}
+	})
+
+	return results, ctx.Err()
+}
+
+// walkTree calls fn for every file and folder below folderID, descending into subfolders.
+// fields lists the file fields to retrieve and must include id and mimeType.
+func walkTree(ctx context.Context, driveService *drive.Service, folderID, fields string, fn func(file *drive.File, parentID string) error) error {
+	children, err := listChildren(ctx, driveService, folderID, fields)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if err := fn(child, folderID); err != nil {
+			return err
+		}
+		if child.MimeType == "application/vnd.google-apps.folder" {
+			if err := walkTree(ctx, driveService, child.Id, fields, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// listChildren lists the non-trashed direct children of a folder.
+func listChildren(ctx context.Context, driveService *drive.Service, folderID, fields string) ([]*drive.File, error) {
+	var children []*drive.File
+	err := driveService.Files.List().
+		Q(fmt.Sprintf("'%s' in parents and trashed = false", escapeQueryValue(folderID))).
+		Fields(googleapi.Field("nextPageToken, files("+fields+")")).
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Pages(ctx, func(page *drive.FileList) error {
+			children = append(children, page.Files...)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to list folder contents: %w", err)
+	}
+	return children, nil
+}
+
+// ChangeRecord is a simplified view of a Drive change.
+type ChangeRecord struct {
+	FileID  string
+	DriveID string
+	Removed bool // the file was deleted or access to it was lost
+	Time    time.Time
+	File    *drive.File // nil if Removed is true
+}
+
+// GetStartPageToken returns the token to pass to ListChangesSince to receive changes from now on.
+// If driveID is non-empty the token applies to that shared drive.
+func GetStartPageToken(ctx context.Context, config auth.Config, driveID string) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	call := driveService.Changes.GetStartPageToken().SupportsAllDrives(true)
+	if driveID != "" {
+		call = call.DriveId(driveID)
+	}
+
+	response, err := call.Do()
+	if err != nil {
+		return "", fmt.Errorf("gDriveHelper: unable to get start page token: %w", err)
+	}
+	return response.StartPageToken, nil
+}
+
+// ListChangesSince returns all changes recorded since pageToken together with the token to use
+// on the next call. If driveID is non-empty only changes of that shared drive are returned.
+func ListChangesSince(ctx context.Context, config auth.Config, pageToken, driveID string) ([]ChangeRecord, string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, "", fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, "", fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	var changes []ChangeRecord
+	for pageToken != "" {
+		call := driveService.Changes.List(pageToken).
+			Fields("nextPageToken", "newStartPageToken", "changes(fileId,driveId,removed,time,file(id,name,mimeType,parents,trashed,modifiedTime,md5Checksum))").
+			SupportsAllDrives(true).
+			IncludeItemsFromAllDrives(true).
+			Context(ctx)
+		if driveID != "" {
+			call = call.DriveId(driveID)
+		}
+
+		response, err := call.Do()
+		if err != nil {
+			return nil, "", fmt.Errorf("gDriveHelper: unable to list changes: %w", err)
+		}
+
+		for _, change := range response.Changes {
+			record := ChangeRecord{
+				FileID:  change.FileId,
+				DriveID: change.DriveId,
+				Removed: change.Removed,
+				File:    change.File,
+			}
+			if changeTime, err := time.Parse(time.RFC3339, change.Time); err == nil {
+				record.Time = changeTime
+			}
+			changes = append(changes, record)
+		}
+
+		if response.NewStartPageToken != "" {
+			return changes, response.NewStartPageToken, nil
+		}
+		pageToken = response.NextPageToken
+	}
+
+	return changes, pageToken, nil
+}
+
+// WatchChannel describes a Drive push notification channel.
+type WatchChannel struct {
+	ID         string    `json:"id"`
+	ResourceID string    `json:"resourceId"`
+	Address    string    `json:"address"`
+	Token      string    `json:"token,omitempty"`
+	FileID     string    `json:"fileId,omitempty"`    // set for file watches
+	PageToken  string    `json:"pageToken,omitempty"` // set for changes watches, kept when renewed
+	DriveID    string    `json:"driveId,omitempty"`   // set for shared drive changes watches
+	Expiration time.Time `json:"expiration"`
+}
+
+// ChannelStore persists watch channels so they can be renewed and stopped later.
+type ChannelStore interface {
+	Save(channel WatchChannel) error
+	Delete(channelID string) error
+	List() ([]WatchChannel, error)
+}
+
+// FileChannelStore is a ChannelStore backed by a JSON file.
+type FileChannelStore struct {
+	Path string
+	file channelstore.File[WatchChannel]
+}
+
+// Save adds or replaces a channel in the store.
+func (s *FileChannelStore) Save(channel WatchChannel) error {
+	if err := s.file.Save(s.Path, channel.ID, channel); err != nil {
+		return fmt.Errorf("gDriveHelper: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a channel from the store.
+func (s *FileChannelStore) Delete(channelID string) error {
+	if err := s.file.Delete(s.Path, channelID); err != nil {
+		return fmt.Errorf("gDriveHelper: %w", err)
+	}
+	return nil
+}
+
+// List returns all stored channels, soonest to expire first.
+func (s *FileChannelStore) List() ([]WatchChannel, error) {
+	channels, err := s.file.List(s.Path, func(channel WatchChannel) time.Time { return channel.Expiration })
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: %w", err)
+	}
+	return channels, nil
+}
+
+// WatchFileChannel registers a push notification channel that posts to webhookURL whenever the
+// file changes. The channel is saved to store when store is non-nil.
+// A zero ttl lets Drive pick its default expiration.
+func WatchFileChannel(ctx context.Context, config auth.Config, store ChannelStore, fileID, webhookURL, channelToken string, ttl time.Duration) (*WatchChannel, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	channel := WatchChannel{Address: webhookURL, Token: channelToken, FileID: fileID}
+	return registerChannel(driveService, store, channel, ttl)
+}
+
+// WatchChangesChannel registers a push notification channel that posts to webhookURL whenever
+// anything changes in the user's Drive, or in the shared drive driveID when it is non-empty.
+// The channel is saved to store when store is non-nil.
+func WatchChangesChannel(ctx context.Context, config auth.Config, store ChannelStore, driveID, webhookURL, channelToken string, ttl time.Duration) (*WatchChannel, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	channel := WatchChannel{Address: webhookURL, Token: channelToken, DriveID: driveID}
+	return registerChannel(driveService, store, channel, ttl)
+}
+
+// registerChannel creates a file or changes watch for channel under a fresh channel ID. A changes
+// watch starts from channel.PageToken, or from now on when it is empty.
+func registerChannel(driveService *drive.Service, store ChannelStore, channel WatchChannel, ttl time.Duration) (*WatchChannel, error) {
+	request := &drive.Channel{
+		Id:      uuid.NewString(),
+		Type:    "web_hook",
+		Address: channel.Address,
+		Token:   channel.Token,
+	}
+	if ttl > 0 {
+		request.Expiration = time.Now().Add(ttl).UnixMilli()
+	}
+
+	var response *drive.Channel
+	var err error
+	if channel.FileID != "" {
+		response, err = driveService.Files.Watch(channel.FileID, request).SupportsAllDrives(true).Do()
+	} else {
+		// A renewed channel keeps watching from its saved token so no changes are skipped
+		if channel.PageToken == "" {
+			startCall := driveService.Changes.GetStartPageToken().SupportsAllDrives(true)
+			if channel.DriveID != "" {
+				startCall = startCall.DriveId(channel.DriveID)
+			}
+			start, startErr := startCall.Do()
+			if startErr != nil {
+				return nil, fmt.Errorf("gDriveHelper: unable to get start page token: %w", startErr)
+			}
+			channel.PageToken = start.StartPageToken
+		}
+
+		watchCall := driveService.Changes.Watch(channel.PageToken, request).SupportsAllDrives(true).IncludeItemsFromAllDrives(true)
+		if channel.DriveID != "" {
+			watchCall = watchCall.DriveId(channel.DriveID)
+		}
+		response, err = watchCall.Do()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to register watch channel: %w", err)
+	}
+
+	channel.ID = response.Id
+	channel.ResourceID = response.ResourceId
+	channel.Expiration = time.UnixMilli(response.Expiration)
+
+	if store != nil {
+		if err := store.Save(channel); err != nil {
+			return nil, err
+		}
+	}
+	return &channel, nil
+}
+
+// StopWatchChannel stops a push notification channel and removes it from store when store is non-nil.
+func StopWatchChannel(ctx context.Context, config auth.Config, store ChannelStore, channel WatchChannel) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	return stopChannel(driveService, store, channel)
+}
+
+// stopChannel stops channel and removes it from store.
+func stopChannel(driveService *drive.Service, store ChannelStore, channel WatchChannel) error {
+	err := driveService.Channels.Stop(&drive.Channel{Id: channel.ID, ResourceId: channel.ResourceID}).Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		// An unknown channel has already expired, which is what we want
+		if !errors.As(err, &apiErr) || apiErr.Code != 404 {
+			return fmt.Errorf("gDriveHelper: unable to stop watch channel: %w", err)
+		}
+	}
+
+	if store != nil {
+		if err := store.Delete(channel.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenewWatchChannels replaces every stored channel that expires within renewBefore with a new
+// channel for the same resource and stops the old one. It returns the renewed channels.
+func RenewWatchChannels(ctx context.Context, config auth.Config, store ChannelStore, renewBefore, ttl time.Duration) ([]WatchChannel, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	channels, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	return channelstore.Renew(channels, time.Now().Add(renewBefore),
+		func(channel WatchChannel) time.Time { return channel.Expiration },
+		func(channel WatchChannel) (*WatchChannel, error) {
+			return registerChannel(driveService, store, channel, ttl)
+		},
+		func(channel WatchChannel) error { return stopChannel(driveService, store, channel) },
+	)
+}
+
+// KeepWatchChannelsAlive calls RenewWatchChannels every checkInterval until ctx is cancelled.
+// A non-positive checkInterval defaults to half of renewBefore, so every channel is checked at
+// least twice before it is due. Renewal errors are passed to onError, if set, and do not stop
+// the loop.
+func KeepWatchChannelsAlive(ctx context.Context, config auth.Config, store ChannelStore, checkInterval, renewBefore, ttl time.Duration, onError func(error)) {
+	if checkInterval <= 0 {
+		checkInterval = renewBefore / 2
+	}
+	if checkInterval <= 0 {
+		if onError != nil {
+			onError(fmt.Errorf("gDriveHelper: check interval or renewBefore must be positive"))
+		}
+		return
+	}
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := RenewWatchChannels(ctx, config, store, renewBefore, ttl); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListComments lists the comments on a file, including their replies.
+// Resolved and deleted comments are only included when includeResolved is true.
+func ListComments(ctx context.Context, config auth.Config, fileID string, includeResolved bool) ([]*drive.Comment, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	var comments []*drive.Comment
+	err = driveService.Comments.List(fileID).
+		Fields("nextPageToken", "comments(id,content,author(displayName,emailAddress),createdTime,modifiedTime,resolved,deleted,quotedFileContent,replies(id,content,author(displayName,emailAddress),createdTime,action,deleted))").
+		IncludeDeleted(includeResolved).
+		Pages(ctx, func(page *drive.CommentList) error {
+			for _, comment := range page.Comments {
+				if !includeResolved && (comment.Resolved || comment.Deleted) {
+					continue
+				}
+				comments = append(comments, comment)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to list comments: %w", err)
+	}
+	return comments, nil
+}
+
+// AddComment adds an unanchored comment to a file.
+func AddComment(ctx context.Context, config auth.Config, fileID, content string) (*drive.Comment, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	comment, err := driveService.Comments.Create(fileID, &drive.Comment{Content: content}).Fields("id", "content", "createdTime").Do()
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to add comment: %w", err)
+	}
+	return comment, nil
+}
+
+// ReplyToComment adds a reply to an existing comment.
+func ReplyToComment(ctx context.Context, config auth.Config, fileID, commentID, content string) (*drive.Reply, error) {
+	return createReply(ctx, config, fileID, commentID, &drive.Reply{Content: content})
+}
+
+// ResolveComment marks a comment as resolved, optionally with a closing message.
+func ResolveComment(ctx context.Context, config auth.Config, fileID, commentID, content string) error {
+	_, err := createReply(ctx, config, fileID, commentID, &drive.Reply{Content: content, Action: "resolve"})
+	return err
+}
+
+// createReply posts a reply to a comment.
+func createReply(ctx context.Context, config auth.Config, fileID, commentID string, reply *drive.Reply) (*drive.Reply, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	createdReply, err := driveService.Replies.Create(fileID, commentID, reply).Fields("id", "content", "action", "createdTime").Do()
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to reply to comment: %w", err)
+	}
+	return createdReply, nil
+}
+
+// DeleteComment deletes a comment from a file.
+func DeleteComment(ctx context.Context, config auth.Config, fileID, commentID string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	err = driveService.Comments.Delete(fileID, commentID).Do()
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to delete comment: %w", err)
+	}
+	return nil
+}
+
+// ChecksumMismatchError is returned when a local hash differs from the checksum reported by Drive.
+type ChecksumMismatchError struct {
+	FileID    string
+	Algorithm string // "md5" or "sha256"
+	Local     string
+	Remote    string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("gDriveHelper: %s checksum mismatch for file %s: local %s, drive %s", e.Algorithm, e.FileID, e.Local, e.Remote)
+}
+
+// UploadOptions controls how UploadFile creates the Drive file.
+type UploadOptions struct {
+	Name           string // defaults to the base name of the local file
+	MimeType       string // MIME type of the local file, detected by Drive when empty
+	VerifyChecksum bool   // compare the local hash with the checksum reported by Drive
+	ConvertToDoc   bool   // convert an image or PDF into a Google Doc using OCR
+	OCRLanguage    string // ISO 639-1 language hint for OCR, e.g., "en"
+}
+
+// UploadFile uploads a local file into the given folder.
+func UploadFile(ctx context.Context, config auth.Config, localPath, parentFolderID string, opts UploadOptions) (*drive.File, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to open local file: %w", err)
+	}
+	defer f.Close()
+
+	name := opts.Name
+	if name == "" {
+		name = filepath.Base(localPath)
+	}
+	file := &drive.File{
+		Name:     name,
+		MimeType: opts.MimeType,
+	}
+	if parentFolderID != "" {
+		file.Parents = []string{parentFolderID}
+	}
+
+	var mediaOptions []googleapi.MediaOption
+	if opts.ConvertToDoc {
+		// The target type goes on the metadata and the source type on the media
+		file.MimeType = "application/vnd.google-apps.document"
+		if opts.MimeType != "" {
+			mediaOptions = append(mediaOptions, googleapi.ContentType(opts.MimeType))
+		}
+	}
+
+	// Hash the content while it is being uploaded
+	md5Hash := md5.New()
+	sha256Hash := sha256.New()
+	media := io.TeeReader(f, io.MultiWriter(md5Hash, sha256Hash))
+
+	call := driveService.Files.Create(file).
+		Media(media, mediaOptions...).
+		Fields("id", "name", "mimeType", "parents", "size", "md5Checksum", "sha256Checksum", "webViewLink").
+		SupportsAllDrives(true).
+		Context(ctx)
+	if opts.OCRLanguage != "" {
+		call = call.OcrLanguage(opts.OCRLanguage)
+	}
+
+	uploadedFile, err := call.Do()
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to upload file: %w", err)
+	}
+
+	// Converted files have no checksum to compare against
+	if opts.VerifyChecksum && !opts.ConvertToDoc {
+		err := verifyChecksums(uploadedFile, hex.EncodeToString(md5Hash.Sum(nil)), hex.EncodeToString(sha256Hash.Sum(nil)))
+		if err != nil {
+			return uploadedFile, err
+		}
+	}
+	return uploadedFile, nil
+}
+
+// DownloadFile writes the content of a binary Drive file to w. When verifyChecksum is true the
+// downloaded content is compared with the checksum reported by Drive.
+func DownloadFile(ctx context.Context, config auth.Config, fileID string, w io.Writer, verifyChecksum bool) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	return downloadFile(ctx, driveService, fileID, w, verifyChecksum)
+}
+
+// downloadFile downloads a file using an existing Drive service.
+func downloadFile(ctx context.Context, driveService *drive.Service, fileID string, w io.Writer, verifyChecksum bool) error {
+	file, err := driveService.Files.Get(fileID).Fields("id", "md5Checksum", "sha256Checksum").SupportsAllDrives(true).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to retrieve file metadata: %w", err)
+	}
+
+	response, err := driveService.Files.Get(fileID).SupportsAllDrives(true).Context(ctx).Download()
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to download file: %w", err)
+	}
+	defer response.Body.Close()
+
+	md5Hash := md5.New()
+	sha256Hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, md5Hash, sha256Hash), response.Body); err != nil {
+		return fmt.Errorf("gDriveHelper: unable to read file content: %w", err)
+	}
+
+	if verifyChecksum {
+		return verifyChecksums(file, hex.EncodeToString(md5Hash.Sum(nil)), hex.EncodeToString(sha256Hash.Sum(nil)))
+	}
+	return nil
+}
+
+// verifyChecksums compares local hashes with the checksums Drive reports for file.
+func verifyChecksums(file *drive.File, localMD5, localSHA256 string) error {
+	if file.Sha256Checksum == "" && file.Md5Checksum == "" {
+		return fmt.Errorf("gDriveHelper: drive reports no checksum for file %s", file.Id)
+	}
+	if file.Sha256Checksum != "" && !strings.EqualFold(file.Sha256Checksum, localSHA256) {
+		return &ChecksumMismatchError{FileID: file.Id, Algorithm: "sha256", Local: localSHA256, Remote: file.Sha256Checksum}
+	}
+	if file.Md5Checksum != "" && !strings.EqualFold(file.Md5Checksum, localMD5) {
+		return &ChecksumMismatchError{FileID: file.Id, Algorithm: "md5", Local: localMD5, Remote: file.Md5Checksum}
+	}
+	return nil
+}
+
+// StorageQuota summarizes the storage usage of the authenticated user.
+type StorageQuota struct {
+	Limit             int64 // 0 when the storage is unlimited
+	Usage             int64 // total usage across all services
+	UsageInDrive      int64 // usage by files in Drive
+	UsageInDriveTrash int64 // usage by trashed files in Drive
+	UsageInOther      int64 // usage by other services such as Gmail and Photos
+	MaxUploadSize     int64
+}
+
+// Unlimited reports whether the account has no storage limit.
+func (q StorageQuota) Unlimited() bool {
+	return q.Limit == 0
+}
+
+// Available returns the remaining bytes, or -1 when the storage is unlimited.
+func (q StorageQuota) Available() int64 {
+	if q.Unlimited() {
+		return -1
+	}
+	return q.Limit - q.Usage
+}
+
+// GetStorageQuota returns the storage usage and limit of the authenticated user.
+func GetStorageQuota(ctx context.Context, config auth.Config) (*StorageQuota, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	about, err := driveService.About.Get().Fields("storageQuota", "maxUploadSize").Do()
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to retrieve storage quota: %w", err)
+	}
+
+	quota := &StorageQuota{MaxUploadSize: about.MaxUploadSize}
+	if about.StorageQuota != nil {
+		quota.Limit = about.StorageQuota.Limit
+		quota.Usage = about.StorageQuota.Usage
+		quota.UsageInDrive = about.StorageQuota.UsageInDrive
+		quota.UsageInDriveTrash = about.StorageQuota.UsageInDriveTrash
+		quota.UsageInOther = quota.Usage - quota.UsageInDrive
+	}
+	return quota, nil
+}
+
+// FolderSize holds the aggregated size of a folder and its subfolders.
+// Google Docs editors files do not count towards TotalBytes.
+type FolderSize struct {
+	FolderID    string
+	Name        string
+	TotalBytes  int64 // bytes of all files in this folder and below
+	FileCount   int   // number of files in this folder and below
+	FolderCount int   // number of subfolders in this folder and below
+	Subfolders  []*FolderSize
+}
+
+// GetFolderSize walks a folder tree and returns its total size with a per-subfolder breakdown.
+func GetFolderSize(ctx context.Context, config auth.Config, folderID string) (*FolderSize, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	root, err := driveService.Files.Get(folderID).Fields("id", "name").SupportsAllDrives(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to retrieve folder: %w", err)
+	}
+
+	return folderSize(ctx, driveService, root.Id, root.Name)
+}
+
+// folderSize computes the size of a folder tree. Each level of the tree is listed by a bounded
+// number of workers before descending, then the totals are added up from the deepest folders.
+func folderSize(ctx context.Context, driveService *drive.Service, folderID, name string) (*FolderSize, error) {
+	root := &FolderSize{FolderID: folderID, Name: name}
+
+	var folders []*FolderSize // every folder, parents before their subfolders
+	level := []*FolderSize{root}
+	for len(level) > 0 {
+		folders = append(folders, level...)
+
+		errs := make([]error, len(level))
+		concurrency.ForEach(ctx, len(level), 8, 20, func(i int) {
+			errs[i] = listFolderSize(ctx, driveService, level[i])
+		})
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var next []*FolderSize
+		for _, folder := range level {
+			next = append(next, folder.Subfolders...)
+		}
+		level = next
+	}
+
+	for i := len(folders) - 1; i >= 0; i-- {
+		for _, subfolder := range folders[i].Subfolders {
+			folders[i].TotalBytes += subfolder.TotalBytes
+			folders[i].FileCount += subfolder.FileCount
+			folders[i].FolderCount += subfolder.FolderCount + 1
+		}
+	}
+	return root, nil
+}
+
+// listFolderSize counts the files directly in folder and adds an entry for each subfolder.
+func listFolderSize(ctx context.Context, driveService *drive.Service, folder *FolderSize) error {
+	children, err := listChildren(ctx, driveService, folder.FolderID, "id,name,mimeType,size")
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if child.MimeType == "application/vnd.google-apps.folder" {
+			folder.Subfolders = append(folder.Subfolders, &FolderSize{FolderID: child.Id, Name: child.Name})
+			continue
+		}
+		folder.TotalBytes += child.Size
+		folder.FileCount++
+	}
+	return nil
+}
+
+// FileInfo is a simplified view of a Drive file.
+type FileInfo struct {
+	ID           string
+	Name         string
+	MimeType     string
+	OwnerEmails  []string
+	SharedBy     string // email of the user who shared the file, for files shared with the caller
+	Shared       bool
+	Size         int64
+	CreatedTime  time.Time
+	ModifiedTime time.Time
+	WebViewLink  string
+}
+
+// fileInfoFields lists the file fields needed by toFileInfo.
+const fileInfoFields = "id,name,mimeType,owners(emailAddress),sharingUser(emailAddress),shared,size,createdTime,modifiedTime,webViewLink"
+
+// ListSharedWithMe lists the files other users have shared with the authenticated user.
+func ListSharedWithMe(ctx context.Context, config auth.Config) ([]FileInfo, error) {
+	return queryFiles(ctx, config, "sharedWithMe = true and trashed = false")
+}
+
+// ListFilesOwnedBy lists the non-trashed files owned by the given email address.
+func ListFilesOwnedBy(ctx context.Context, config auth.Config, ownerEmail string) ([]FileInfo, error) {
+	return queryFiles(ctx, config, fmt.Sprintf("'%s' in owners and trashed = false", escapeQueryValue(ownerEmail)))
+}
+
+// ListSharedFilesOwnedBy lists the files owned by the given email address that are shared with others.
+func ListSharedFilesOwnedBy(ctx context.Context, config auth.Config, ownerEmail string) ([]FileInfo, error) {
+	files, err := ListFilesOwnedBy(ctx, config, ownerEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared []FileInfo
+	for _, file := range files {
+		if file.Shared {
+			shared = append(shared, file)
+		}
+	}
+	return shared, nil
+}
+
+// queryFiles lists all files matching a Drive search query.
+func queryFiles(ctx context.Context, config auth.Config, query string) ([]FileInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	var files []FileInfo
+	err = driveService.Files.List().
+		Q(query).
+		Fields(googleapi.Field("nextPageToken, files("+fileInfoFields+")")).
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Pages(ctx, func(page *drive.FileList) error {
+			for _, file := range page.Files {
+				files = append(files, toFileInfo(file))
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to list files: %w", err)
+	}
+	return files, nil
+}
+
+// toFileInfo converts a Drive file into a FileInfo.
+func toFileInfo(file *drive.File) FileInfo {
+	info := FileInfo{
+		ID:          file.Id,
+		Name:        file.Name,
+		MimeType:    file.MimeType,
+		Shared:      file.Shared,
+		Size:        file.Size,
+		WebViewLink: file.WebViewLink,
+	}
+	for _, owner := range file.Owners {
+		info.OwnerEmails = append(info.OwnerEmails, owner.EmailAddress)
+	}
+	if file.SharingUser != nil {
+		info.SharedBy = file.SharingUser.EmailAddress
+	}
+	if createdTime, err := time.Parse(time.RFC3339, file.CreatedTime); err == nil {
+		info.CreatedTime = createdTime
+	}
+	if modifiedTime, err := time.Parse(time.RFC3339, file.ModifiedTime); err == nil {
+		info.ModifiedTime = modifiedTime
+	}
+	return info
+}
+
+// UploadWithOCR uploads an image or PDF and converts it into a Google Doc using Drive's OCR.
+// It returns the ID of the new document.
+func UploadWithOCR(ctx context.Context, config auth.Config, localPath, parentFolderID, language string) (string, error) {
+	file, err := UploadFile(ctx, config, localPath, parentFolderID, UploadOptions{
+		Name:         strings.TrimSuffix(filepath.Base(localPath), filepath.Ext(localPath)),
+		ConvertToDoc: true,
+		OCRLanguage:  language,
+	})
+	if err != nil {
+		return "", err
+	}
+	return file.Id, nil
+}
+
+// CopyFolderOptions controls how CopyFolder copies a folder tree.
+type CopyFolderOptions struct {
+	Name                string // name of the new folder, defaults to the source folder name
+	PreservePermissions bool   // recreate the explicit permissions of every copied item
+
+	// AllowPublic allows PreservePermissions to recreate "anyone" grants. Without it they are
+	// reported as failures, so a copy never becomes public by accident.
+	AllowPublic bool
+}
+
+// PermissionCopyFailure describes a permission that could not be recreated on a copy.
+type PermissionCopyFailure struct {
+	SourceID   string
+	CopyID     string
+	Permission PermissionInfo
+	Err        error
+}
+
+// CopyFolderReport summarizes the result of CopyFolder.
+type CopyFolderReport struct {
+	FolderID           string // ID of the new root folder
+	FilesCopied        int
+	FoldersCopied      int
+	PermissionFailures []PermissionCopyFailure
+}
+
+// CopyFolder recursively copies a folder and its contents into destParentID.
+// Permission failures are collected in the report instead of aborting the copy.
+func CopyFolder(ctx context.Context, config auth.Config, sourceFolderID, destParentID string, opts CopyFolderOptions) (*CopyFolderReport, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	source, err := driveService.Files.Get(sourceFolderID).Fields("id", "name").SupportsAllDrives(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to retrieve source folder: %w", err)
+	}
+
+	copier := &folderCopier{
+		ctx:                 ctx,
+		driveService:        driveService,
+		preservePermissions: opts.PreservePermissions,
+		allowPublic:         opts.AllowPublic,
+		report:              &CopyFolderReport{},
+	}
+	if opts.PreservePermissions {
+		about, err := driveService.About.Get().Fields("user(emailAddress)").Do()
+		if err != nil {
+			return nil, fmt.Errorf("gDriveHelper: unable to retrieve current user: %w", err)
+		}
+		copier.callerEmail = about.User.EmailAddress
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = source.Name
+	}
+	folderID, err := copier.copyFolder(source.Id, name, destParentID)
+	if err != nil {
+		return copier.report, err
+	}
+	copier.report.FolderID = folderID
+	return copier.report, nil
+}
+
+// folderCopier holds the state shared while copying a folder tree.
+type folderCopier struct {
+	ctx                 context.Context
+	driveService        *drive.Service
+	preservePermissions bool
+	allowPublic         bool
+	callerEmail         string
+	report              *CopyFolderReport
+}
+
+// copyFolder creates a copy of sourceID named name inside parentID and copies its children.
+func (c *folderCopier) copyFolder(sourceID, name, parentID string) (string, error) {
+	folder := &drive.File{
+		Name:     name,
+		MimeType: "application/vnd.google-apps.folder",
+	}
+	if parentID != "" {
+		folder.Parents = []string{parentID}
+	}
+
+	createdFolder, err := c.driveService.Files.Create(folder).SupportsAllDrives(true).Context(c.ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("gDriveHelper: unable to create folder: %w", err)
+	}
+	c.report.FoldersCopied++
+	c.copyPermissions(sourceID, createdFolder.Id)
+
+	children, err := listChildren(c.ctx, c.driveService, sourceID, "id,name,mimeType")
+	if err != nil {
+		return createdFolder.Id, err
+	}
+
+	for _, child := range children {
+		if child.MimeType == "application/vnd.google-apps.folder" {
+			if _, err := c.copyFolder(child.Id, child.Name, createdFolder.Id); err != nil {
+				return createdFolder.Id, err
+			}
+			continue
+		}
+
+		copiedFile, err := c.driveService.Files.Copy(child.Id, &drive.File{
+			Name:    child.Name,
+			Parents: []string{createdFolder.Id},
+		}).SupportsAllDrives(true).Context(c.ctx).Do()
+		if err != nil {
+			return createdFolder.Id, fmt.Errorf("gDriveHelper: unable to copy file %s: %w", child.Id, err)
+		}
+		c.report.FilesCopied++
+		c.copyPermissions(child.Id, copiedFile.Id)
+	}
+
+	return createdFolder.Id, nil
+}
+
+// copyPermissions recreates the explicit permissions of sourceID on copyID.
+func (c *folderCopier) copyPermissions(sourceID, copyID string) {
+	if !c.preservePermissions {
+		return
+	}
+
+	permissions, err := listPermissions(c.ctx, c.driveService, sourceID)
+	if err != nil {
+		c.report.PermissionFailures = append(c.report.PermissionFailures, PermissionCopyFailure{SourceID: sourceID, CopyID: copyID, Err: err})
+		return
+	}
+
+	for _, permission := range permissions {
+		// The caller already owns the copy and inherited grants come with the parent
+		if permission.Inherited || strings.EqualFold(permission.Email, c.callerEmail) {
+			continue
+		}
+
+		if permission.Role == "owner" {
+			c.report.PermissionFailures = append(c.report.PermissionFailures, PermissionCopyFailure{
+				SourceID:   sourceID,
+				CopyID:     copyID,
+				Permission: permission,
+				Err:        fmt.Errorf("gDriveHelper: ownership cannot be copied"),
+			})
+			continue
+		}
+
+		// Going through buildPermission applies the same public sharing opt-in as AddPermission
+		grant, err := buildPermission(PermissionOptions{
+			Type:               permission.Type,
+			Role:               permission.Role,
+			EmailAddress:       permission.Email,
+			Domain:             permission.Domain,
+			AllowFileDiscovery: permission.AllowFileDiscovery,
+			AllowPublic:        c.allowPublic,
+		})
+		if err != nil {
+			c.report.PermissionFailures = append(c.report.PermissionFailures, PermissionCopyFailure{
+				SourceID:   sourceID,
+				CopyID:     copyID,
+				Permission: permission,
+				Err:        err,
+			})
+			continue
+		}
+
+		_, err = c.driveService.Permissions.Create(copyID, grant).SupportsAllDrives(true).SendNotificationEmail(false).Context(c.ctx).Do()
+		if err != nil {
+			c.report.PermissionFailures = append(c.report.PermissionFailures, PermissionCopyFailure{
+				SourceID:   sourceID,
+				CopyID:     copyID,
+				Permission: permission,
+				Err:        fmt.Errorf("gDriveHelper: unable to copy permission: %w", err),
+			})
+		}
+	}
+}
+
+// Kinds of findings reported by AuditPermissions.
+const (
+	AuditExternalShare     = "external_share"
+	AuditAnyoneWithLink    = "anyone_with_link"
+	AuditDiffersFromParent = "differs_from_parent"
+)
+
+// AuditOptions controls AuditPermissions.
+type AuditOptions struct {
+	// InternalDomains lists the domains considered internal, e.g., "example.com".
+	// Grants to users, groups or domains outside this list are reported as external shares.
+	InternalDomains []string
+}
+
+// AuditFinding is a single noteworthy permission found by AuditPermissions.
+type AuditFinding struct {
+	FileID     string         `json:"fileId"`
+	Name       string         `json:"name"`
+	Path       string         `json:"path"`
+	MimeType   string         `json:"mimeType"`
+	Kind       string         `json:"kind"`
+	Permission PermissionInfo `json:"permission"`
+}
+
+// AuditReport is the result of AuditPermissions.
+type AuditReport struct {
+	RootFolderID string         `json:"rootFolderId"`
+	ItemsScanned int            `json:"itemsScanned"`
+	Findings     []AuditFinding `json:"findings"`
+}
+
+// AuditPermissions walks a folder tree and reports external shares, anyone-with-link files and
+// permissions that are not present on the parent folder.
+func AuditPermissions(ctx context.Context, config auth.Config, rootFolderID string, opts AuditOptions) (*AuditReport, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	root, err := driveService.Files.Get(rootFolderID).Fields("id", "name", "mimeType").SupportsAllDrives(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to retrieve root folder: %w", err)
+	}
+
+	auditor := &permissionAuditor{
+		ctx:          ctx,
+		driveService: driveService,
+		opts:         opts,
+		report:       &AuditReport{RootFolderID: root.Id},
+	}
+	if err := auditor.audit(root, root.Name, nil); err != nil {
+		return nil, err
+	}
+	return auditor.report, nil
+}
+
+// permissionAuditor holds the state shared while auditing a folder tree.
+type permissionAuditor struct {
+	ctx          context.Context
+	driveService *drive.Service
+	opts         AuditOptions
+	report       *AuditReport
+}
+
+// audit checks the permissions of file and, for folders, of everything below it.
+// parentPermissions is nil for the root.
+func (a *permissionAuditor) audit(file *drive.File, path string, parentPermissions []PermissionInfo) error {
+	permissions, err := listPermissions(a.ctx, a.driveService, file.Id)
+	if err != nil {
+		return err
+	}
+	a.report.ItemsScanned++
+
+	for _, permission := range permissions {
+		finding := AuditFinding{FileID: file.Id, Name: file.Name, Path: path, MimeType: file.MimeType, Permission: permission}
+		if permission.Type == "anyone" {
+			finding.Kind = AuditAnyoneWithLink
+			a.report.Findings = append(a.report.Findings, finding)
+		} else if a.isExternal(permission) {
+			finding.Kind = AuditExternalShare
+			a.report.Findings = append(a.report.Findings, finding)
+		}
+		if parentPermissions != nil && !permission.Inherited && !containsGrant(parentPermissions, permission) {
+			finding.Kind = AuditDiffersFromParent
+			a.report.Findings = append(a.report.Findings, finding)
+		}
+	}
+
+	if file.MimeType != "application/vnd.google-apps.folder" {
+		return nil
+	}
+
+	children, err := listChildren(a.ctx, a.driveService, file.Id, "id,name,mimeType")
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := a.audit(child, path+"/"+child.Name, permissions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isExternal reports whether a permission grants access outside the internal domains.
+func (a *permissionAuditor) isExternal(permission PermissionInfo) bool {
+	domain := permission.Domain
+	if permission.Type == "user" || permission.Type == "group" {
+		at := strings.LastIndex(permission.Email, "@")
+		if at == -1 {
+			return false
+		}
+		domain = permission.Email[at+1:]
+	}
+	if domain == "" {
+		return false
+	}
+	for _, internal := range a.opts.InternalDomains {
+		if strings.EqualFold(domain, internal) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsGrant reports whether permissions contain a grant with the same grantee and role.
+func containsGrant(permissions []PermissionInfo, grant PermissionInfo) bool {
+	for _, permission := range permissions {
+		if permission.Type == grant.Type &&
+			permission.Role == grant.Role &&
+			strings.EqualFold(permission.Email, grant.Email) &&
+			strings.EqualFold(permission.Domain, grant.Domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *AuditReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r); err != nil {
+		return fmt.Errorf("gDriveHelper: unable to write audit report: %w", err)
+	}
+	return nil
+}
+
+// WriteCSV writes the findings of the report as CSV with a header row.
+func (r *AuditReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	records := [][]string{{"file_id", "name", "path", "mime_type", "kind", "type", "role", "email", "domain", "allow_file_discovery", "inherited"}}
+	for _, finding := range r.Findings {
+		records = append(records, []string{
+			finding.FileID,
+			finding.Name,
+			finding.Path,
+			finding.MimeType,
+			finding.Kind,
+			finding.Permission.Type,
+			finding.Permission.Role,
+			finding.Permission.Email,
+			finding.Permission.Domain,
+			strconv.FormatBool(finding.Permission.AllowFileDiscovery),
+			strconv.FormatBool(finding.Permission.Inherited),
+		})
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return fmt.Errorf("gDriveHelper: unable to write audit report: %w", err)
+	}
+	return nil
+}
+
+// FindOrphanedFiles lists the files owned by the authenticated user that have no parent folder.
+func FindOrphanedFiles(ctx context.Context, config auth.Config) ([]FileInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	return findOrphanedFiles(ctx, driveService)
+}
+
+// RefileOrphanedFiles moves every orphaned file owned by the authenticated user into folderID.
+// It returns the files that were moved.
+func RefileOrphanedFiles(ctx context.Context, config auth.Config, folderID string) ([]FileInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	orphans, err := findOrphanedFiles(ctx, driveService)
+	if err != nil {
+		return nil, err
+	}
+
+	var moved []FileInfo
+	for _, orphan := range orphans {
+		_, err := driveService.Files.Update(orphan.ID, &drive.File{}).AddParents(folderID).SupportsAllDrives(true).Context(ctx).Do()
+		if err != nil {
+			return moved, fmt.Errorf("gDriveHelper: unable to move orphaned file %s: %w", orphan.ID, err)
+		}
+		moved = append(moved, orphan)
+	}
+	return moved, nil
+}
+
+// findOrphanedFiles lists parentless files owned by the caller using an existing Drive service.
+func findOrphanedFiles(ctx context.Context, driveService *drive.Service) ([]FileInfo, error) {
+	var orphans []FileInfo
+	err := driveService.Files.List().
+		Q("'me' in owners and trashed = false").
+		Fields(googleapi.Field("nextPageToken, files(parents,"+fileInfoFields+")")).
+		Pages(ctx, func(page *drive.FileList) error {
+			for _, file := range page.Files {
+				if len(file.Parents) == 0 {
+					orphans = append(orphans, toFileInfo(file))
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to list files: %w", err)
+	}
+	return orphans, nil
+}
+
+// EmptyFolderOptions controls EmptyFolder.
+type EmptyFolderOptions struct {
+	Permanent   bool // delete irreversibly instead of moving to the trash
+	DryRun      bool // only report what would be removed
+	Concurrency int  // number of parallel workers, defaults to 4
+}
+
+// DeletionResult reports the outcome of removing a single item.
+type DeletionResult struct {
+	FileID string
+	Name   string
+	Err    error
+}
+
+// EmptyFolder removes all children of a folder without removing the folder itself.
+// Failures on individual items are reported in the returned results.
+func EmptyFolder(ctx context.Context, config auth.Config, folderID string, opts EmptyFolderOptions) ([]DeletionResult, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	children, err := listChildren(ctx, driveService, folderID, "id,name,mimeType")
+	if err != nil {
+		return nil, err
+	}
+
+	return removeFiles(ctx, driveService, children, opts.Permanent, opts.DryRun, opts.Concurrency), ctx.Err()
+}
+
+// removeFiles trashes or deletes files concurrently. In dry-run mode nothing is removed.
+func removeFiles(ctx context.Context, driveService *drive.Service, files []*drive.File, permanent, dryRun bool, workers int) []DeletionResult {
+	results := make([]DeletionResult, len(files))
+	for i, file := range files {
+		results[i] = DeletionResult{FileID: file.Id, Name: file.Name}
+	}
+	if dryRun {
+		return results
+	}
+
+	concurrency.ForEach(ctx, len(files), workers, 10, func(i int) {
+		var err error
+		if permanent {
+			err = driveService.Files.Delete(files[i].Id).SupportsAllDrives(true).Context(ctx).Do()
+		} else {
+			_, err = driveService.Files.Update(files[i].Id, &drive.File{Trashed: true}).SupportsAllDrives(true).Context(ctx).Do()
+		}
+		if err != nil {
+			results[i].Err = fmt.Errorf("gDriveHelper: unable to remove file: %w", err)
+		}
+	})
+	return results
+}
+
+// ConflictPolicy decides what happens when an item with the same name already exists in a folder.
+type ConflictPolicy int
+
+const (
+	// ConflictFail returns ErrNameConflict when a sibling with the same name exists.
+	ConflictFail ConflictPolicy = iota
+	// ConflictOverwrite moves same-name siblings to the trash before continuing. Only siblings
+	// of the same MIME type are replaced, so a file never replaces a folder or a different kind
+	// of file; when one of another type holds the name, ErrNameConflict is returned and nothing
+	// is trashed.
+	ConflictOverwrite
+	// ConflictAutoSuffix appends " (2)", " (3)", ... to the name until it is unique.
+	ConflictAutoSuffix
+)
+
+// ErrNameConflict is returned by ConflictFail when a sibling with the same name exists, and by
+// ConflictOverwrite when that sibling has another MIME type.
+var ErrNameConflict = errors.New("gDriveHelper: an item with the same name already exists in the folder")
+
+// CreateFolderInParent creates a folder inside parentID, applying policy when a sibling has the same name.
+func CreateFolderInParent(ctx context.Context, config auth.Config, parentID, name string, policy ConflictPolicy) (*drive.File, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	name, err = resolveNameConflict(ctx, driveService, parentID, name, "", "application/vnd.google-apps.folder", false, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	folder := &drive.File{
+		Name:     name,
+		MimeType: "application/vnd.google-apps.folder",
+		Parents:  []string{parentID},
+	}
+
+	createdFolder, err := driveService.Files.Create(folder).SupportsAllDrives(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create folder: %w", err)
+	}
+	return createdFolder, nil
+}
+
+// RenameWithPolicy renames a file or folder, applying policy when a sibling already has newName.
+// It returns the name that was finally applied.
+func RenameWithPolicy(ctx context.Context, config auth.Config, fileID, newName string, policy ConflictPolicy) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	file, err := driveService.Files.Get(fileID).Fields("id", "mimeType", "parents").SupportsAllDrives(true).Do()
+	if err != nil {
+		return "", fmt.Errorf("gDriveHelper: unable to retrieve file: %w", err)
+	}
+
+	isFolder := file.MimeType == "application/vnd.google-apps.folder"
+	for _, parentID := range file.Parents {
+		newName, err = resolveNameConflict(ctx, driveService, parentID, newName, fileID, file.MimeType, !isFolder, policy)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	_, err = driveService.Files.Update(fileID, &drive.File{Name: newName}).SupportsAllDrives(true).Do()
+	if err != nil {
+		return "", fmt.Errorf("gDriveHelper: unable to rename file: %w", err)
+	}
+	return newName, nil
+}
+
+// resolveNameConflict applies policy to name within parentID and returns the name to use.
+// excludeID is ignored when looking for siblings; mimeType is the type of the named item, the only
+// type ConflictOverwrite trashes; keepExtension places the suffix before the file extension.
+func resolveNameConflict(ctx context.Context, driveService *drive.Service, parentID, name, excludeID, mimeType string, keepExtension bool, policy ConflictPolicy) (string, error) {
+	base, _ := splitExtension(name, keepExtension)
+	siblings, err := listSiblings(ctx, driveService, parentID, base, excludeID)
+	if err != nil {
+		return "", err
+	}
+
+	name, overwritten, err := resolveName(siblings, name, mimeType, keepExtension, policy)
+	if err != nil {
+		return "", err
+	}
+	for _, sibling := range overwritten {
+		_, err := driveService.Files.Update(sibling.Id, &drive.File{Trashed: true}).SupportsAllDrives(true).Context(ctx).Do()
+		if err != nil {
+			return "", fmt.Errorf("gDriveHelper: unable to trash conflicting item: %w", err)
+		}
+	}
+	return name, nil
+}
+
+// listSiblings lists the items of parentID, other than excludeID, whose name contains base.
+func listSiblings(ctx context.Context, driveService *drive.Service, parentID, base, excludeID string) ([]*drive.File, error) {
+	var siblings []*drive.File
+	err := driveService.Files.List().
+		Q(fmt.Sprintf("'%s' in parents and name contains '%s' and trashed = false", escapeQueryValue(parentID), escapeQueryValue(base))).
+		Fields("nextPageToken", "files(id,name,mimeType)").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Pages(ctx, func(page *drive.FileList) error {
+			for _, file := range page.Files {
+				if file.Id != excludeID {
+					siblings = append(siblings, file)
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to list siblings: %w", err)
+	}
+	return siblings, nil
+}
+
+// resolveName applies policy to name among siblings. It returns the name to use and, for
+// ConflictOverwrite, the siblings to trash first.
+func resolveName(siblings []*drive.File, name, mimeType string, keepExtension bool, policy ConflictPolicy) (string, []*drive.File, error) {
+	taken := map[string]bool{}
+	for _, sibling := range siblings {
+		taken[sibling.Name] = true
+	}
+	if !taken[name] {
+		return name, nil, nil
+	}
+
+	switch policy {
+	case ConflictOverwrite:
+		var overwritten []*drive.File
+		for _, sibling := range siblings {
+			if sibling.Name != name {
+				continue
+			}
+			// Trashing the other items would still leave this one sharing the name
+			if sibling.MimeType != mimeType {
+				return "", nil, ErrNameConflict
+			}
+			overwritten = append(overwritten, sibling)
+		}
+		return name, overwritten, nil
+	case ConflictAutoSuffix:
+		base, ext := splitExtension(name, keepExtension)
+		for n := 2; ; n++ {
+			candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+			if !taken[candidate] {
+				return candidate, nil, nil
+			}
+		}
+	default:
+		return "", nil, ErrNameConflict
+	}
+}
+
+// splitExtension splits name into its base and its file extension when keepExtension is set.
+func splitExtension(name string, keepExtension bool) (string, string) {
+	if !keepExtension {
+		return name, ""
+	}
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext), ext
+}
+
+// RetentionOptions controls CleanupFolderByAge.
+type RetentionOptions struct {
+	MaxAge          time.Duration // files older than this are removed, must be positive
+	UseModifiedTime bool          // compare against modifiedTime instead of createdTime
+	Exclude         []string      // filepath.Match patterns on file names that are never removed, e.g., "*.keep"
+	Permanent       bool          // delete irreversibly instead of moving to the trash
+	DryRun          bool          // only report what would be removed
+	Concurrency     int           // number of parallel workers, defaults to 4
+}
+
+// RetentionSummary reports the outcome of CleanupFolderByAge.
+type RetentionSummary struct {
+	Removed    []DeletionResult // files removed, or that would be removed in dry-run mode
+	Excluded   int              // expired files kept because they matched an exclusion pattern
+	TotalBytes int64            // size of the removed files
+}
+
+// CleanupFolderByAge removes the files directly inside a folder that are older than opts.MaxAge,
+// which must be positive. Subfolders are never removed.
+func CleanupFolderByAge(ctx context.Context, config auth.Config, folderID string, opts RetentionOptions) (*RetentionSummary, error) {
+	// A zero MaxAge would put the cutoff at now and remove every file of the folder
+	if opts.MaxAge <= 0 {
+		return nil, fmt.Errorf("gDriveHelper: retention MaxAge must be positive, got %s", opts.MaxAge)
+	}
+	for _, pattern := range opts.Exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("gDriveHelper: invalid exclusion pattern %q: %w", pattern, err)
+		}
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	timeField := "createdTime"
+	if opts.UseModifiedTime {
+		timeField = "modifiedTime"
+	}
+	cutoff := time.Now().Add(-opts.MaxAge).UTC().Format(time.RFC3339)
+	query := fmt.Sprintf("'%s' in parents and trashed = false and mimeType != 'application/vnd.google-apps.folder' and %s < '%s'",
+		escapeQueryValue(folderID), timeField, cutoff)
+
+	summary := &RetentionSummary{}
+	var expired []*drive.File
+	err = driveService.Files.List().
+		Q(query).
+		Fields("nextPageToken", "files(id,name,size)").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Pages(ctx, func(page *drive.FileList) error {
+			for _, file := range page.Files {
+				if matchesAny(opts.Exclude, file.Name) {
+					summary.Excluded++
+					continue
+				}
+				expired = append(expired, file)
+				summary.TotalBytes += file.Size
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("gDriveHelper: unable to list expired files: %w", err)
+	}
+
+	summary.Removed = removeFiles(ctx, driveService, expired, opts.Permanent, opts.DryRun, opts.Concurrency)
+	return summary, ctx.Err()
+}
+
+// matchesAny reports whether name matches any of the filepath.Match patterns.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ShareableLinkOptions controls GetShareableLink.
+type ShareableLinkOptions struct {
+	Role        string // "reader" or "commenter", defaults to "reader"
+	Domain      string // restrict the link to this domain; when empty the link works for anyone
+	AllowPublic bool   // explicit opt-in required when Domain is empty
+}
+
+// GetShareableLink makes sure a link-sharing permission matching opts exists on the file and
+// returns the file's webViewLink.
+func GetShareableLink(ctx context.Context, config auth.Config, fileID string, opts ShareableLinkOptions) (string, error) {
+	role := opts.Role
+	if role == "" {
+		role = "reader"
+	}
+	if role != "reader" && role != "commenter" {
+		return "", fmt.Errorf("gDriveHelper: unsupported link role %q", role)
+	}
+
+	permissionOptions := PermissionOptions{Type: "anyone", Role: role, AllowPublic: opts.AllowPublic}
+	if opts.Domain != "" {
+		permissionOptions = PermissionOptions{Type: "domain", Role: role, Domain: opts.Domain}
+	}
+	permission, err := buildPermission(permissionOptions)
+	if err != nil {
+		return "", err
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	existing, err := listPermissions(ctx, driveService, fileID)
+	if err != nil {
+		return "", err
+	}
+
+	found := false
+	for _, current := range existing {
+		if current.Type == permission.Type && current.Role == permission.Role && strings.EqualFold(current.Domain, permission.Domain) {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		_, err = driveService.Permissions.Create(fileID, permission).SupportsAllDrives(true).Do()
+		if err != nil {
+			return "", fmt.Errorf("gDriveHelper: unable to add link permission: %w", err)
+		}
+	}
+
+	file, err := driveService.Files.Get(fileID).Fields("webViewLink").SupportsAllDrives(true).Do()
+	if err != nil {
+		return "", fmt.Errorf("gDriveHelper: unable to retrieve file link: %w", err)
+	}
+	return file.WebViewLink, nil
+}
+
+// WatchFile polls a file every interval and calls callback with the latest metadata whenever its
+// modifiedTime or md5Checksum changes. It blocks until ctx is cancelled or callback returns an error.
+// For push-based notifications see WatchFileChannel.
+func WatchFile(ctx context.Context, config auth.Config, fileID string, interval time.Duration, callback func(file *drive.File) error) error {
+	if interval <= 0 {
+		return fmt.Errorf("gDriveHelper: watch interval must be positive, got %s", interval)
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gDriveHelper: unable to create drive service: %w", err)
+	}
+
+	getFile := func() (*drive.File, error) {
+		file, err := driveService.Files.Get(fileID).
+			Fields("id", "name", "mimeType", "modifiedTime", "md5Checksum", "version", "lastModifyingUser(displayName,emailAddress)").
+			SupportsAllDrives(true).
+			Context(ctx).
+			Do()
+		if err != nil {
+			return nil, fmt.Errorf("gDriveHelper: unable to retrieve file: %w", err)
+		}
+		return file, nil
+	}
+
+	last, err := getFile()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := getFile()
+		if err != nil {
+			return err
+		}
+		if current.ModifiedTime == last.ModifiedTime && current.Md5Checksum == last.Md5Checksum {
+			continue
+		}
+
+		last = current
+		if err := callback(current); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package gDriveHelper
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gnzdotmx/gworkspace-helper/auth"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestCleanupFolderByAgeRequiresMaxAge(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RetentionOptions
+	}{
+		{name: "zero options", opts: RetentionOptions{}},
+		{name: "zero MaxAge with permanent deletion", opts: RetentionOptions{Permanent: true}},
+		{name: "negative MaxAge", opts: RetentionOptions{MaxAge: -time.Hour}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The empty config would fail to authenticate, so only the guard can produce this error
+			_, err := CleanupFolderByAge(context.Background(), auth.Config{}, "folder", tt.opts)
+			if err == nil || !strings.Contains(err.Error(), "MaxAge must be positive") {
+				t.Fatalf("CleanupFolderByAge error = %v, want a MaxAge error", err)
+			}
+		})
+	}
+}
+
+func TestResolveName(t *testing.T) {
+	const folder = "application/vnd.google-apps.folder"
+	siblings := []*drive.File{
+		{Id: "1", Name: "report.pdf", MimeType: "application/pdf"},
+		{Id: "2", Name: "report (2).pdf", MimeType: "application/pdf"},
+		{Id: "3", Name: "Archive", MimeType: folder},
+		{Id: "4", Name: "notes", MimeType: "text/plain"},
+		{Id: "5", Name: "notes", MimeType: "text/plain"},
+	}
+
+	tests := []struct {
+		name          string
+		item          string
+		mimeType      string
+		keepExtension bool
+		policy        ConflictPolicy
+		wantName      string
+		wantTrashed   []string
+		wantErr       error
+	}{
+		{name: "free name", item: "summary.pdf", mimeType: "application/pdf", policy: ConflictFail, wantName: "summary.pdf"},
+		{name: "fail on conflict", item: "report.pdf", mimeType: "application/pdf", policy: ConflictFail, wantErr: ErrNameConflict},
+		{name: "suffix before the extension", item: "report.pdf", mimeType: "application/pdf", keepExtension: true, policy: ConflictAutoSuffix, wantName: "report (3).pdf"},
+		{name: "suffix after a folder name", item: "Archive", mimeType: folder, policy: ConflictAutoSuffix, wantName: "Archive (2)"},
+		{name: "overwrite every same-type sibling", item: "notes", mimeType: "text/plain", policy: ConflictOverwrite, wantName: "notes", wantTrashed: []string{"4", "5"}},
+		{name: "overwrite a folder with a folder", item: "Archive", mimeType: folder, policy: ConflictOverwrite, wantName: "Archive", wantTrashed: []string{"3"}},
+		{name: "never overwrite a folder with a file", item: "Archive", mimeType: "text/plain", policy: ConflictOverwrite, wantErr: ErrNameConflict},
+		{name: "never overwrite another type", item: "report.pdf", mimeType: "application/vnd.google-apps.document", policy: ConflictOverwrite, wantErr: ErrNameConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, trashed, err := resolveName(siblings, tt.item, tt.mimeType, tt.keepExtension, tt.policy)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resolveName error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			var trashedIDs []string
+			for _, file := range trashed {
+				trashedIDs = append(trashedIDs, file.Id)
+			}
+			if name != tt.wantName || !reflect.DeepEqual(trashedIDs, tt.wantTrashed) {
+				t.Fatalf("resolveName = %q trashing %v, want %q trashing %v", name, trashedIDs, tt.wantName, tt.wantTrashed)
+			}
+		})
+	}
+}
+
+func TestEscapeQueryValue(t *testing.T) {
+	tests := map[string]string{
+		"report":         "report",
+		"Bob's file":     `Bob\'s file`,
+		`C:\temp`:        `C:\\temp`,
+		`it\'s`:          `it\\\'s`,
+		"' or name != '": `\' or name != \'`,
+	}
+	for value, want := range tests {
+		if got := escapeQueryValue(value); got != want {
+			t.Errorf("escapeQueryValue(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestBuildPermission(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    PermissionOptions
+		want    *drive.Permission
+		wantErr error
+	}{
+		{
+			name: "user",
+			opts: PermissionOptions{Type: "user", Role: "writer", EmailAddress: "jane@example.com"},
+			want: &drive.Permission{Type: "user", Role: "writer", EmailAddress: "jane@example.com"},
+		},
+		{
+			name: "domain",
+			opts: PermissionOptions{Type: "domain", Role: "reader", Domain: "example.com"},
+			want: &drive.Permission{Type: "domain", Role: "reader", Domain: "example.com", ForceSendFields: []string{"AllowFileDiscovery"}},
+		},
+		{
+			name: "anyone with opt-in",
+			opts: PermissionOptions{Type: "anyone", Role: "reader", AllowPublic: true, AllowFileDiscovery: true},
+			want: &drive.Permission{Type: "anyone", Role: "reader", AllowFileDiscovery: true, ForceSendFields: []string{"AllowFileDiscovery"}},
+		},
+		{name: "anyone without opt-in", opts: PermissionOptions{Type: "anyone", Role: "reader"}, wantErr: ErrPublicSharingNotAllowed},
+		{name: "missing role", opts: PermissionOptions{Type: "user", EmailAddress: "jane@example.com"}},
+		{name: "user without email", opts: PermissionOptions{Type: "group", Role: "reader"}},
+		{name: "domain without domain", opts: PermissionOptions{Type: "domain", Role: "reader"}},
+		{name: "unsupported type", opts: PermissionOptions{Type: "robot", Role: "reader"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildPermission(tt.opts)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("buildPermission() = %+v, want an error", got)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("buildPermission() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildPermission(): %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("buildPermission() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesAny(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		file     string
+		want     bool
+	}{
+		{name: "no patterns", patterns: nil, file: "a.log", want: false},
+		{name: "suffix", patterns: []string{"*.tmp", "*.log"}, file: "server.log", want: true},
+		{name: "no match", patterns: []string{"*.tmp"}, file: "server.log", want: false},
+		{name: "case-sensitive", patterns: []string{"*.LOG"}, file: "server.log", want: false},
+		{name: "character class", patterns: []string{"backup-[0-9]*"}, file: "backup-2024.zip", want: true},
+		{name: "malformed pattern is skipped", patterns: []string{"[", "*.zip"}, file: "backup.zip", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesAny(tt.patterns, tt.file); got != tt.want {
+				t.Fatalf("matchesAny(%q, %q) = %v, want %v", tt.patterns, tt.file, got, tt.want)
+			}
+		})
+	}
+}
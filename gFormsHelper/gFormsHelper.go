@@ -0,0 +1,1078 @@
+package gFormsHelper
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gnzdotmx/gworkspace-helper/auth"
+	"github.com/gnzdotmx/gworkspace-helper/gSheetsHelper"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/forms/v1"
+	"google.golang.org/api/option"
+	"sigs.k8s.io/yaml"
+)
+
+// Question types.
+const (
+	QuestionText       = "text"      // short answer
+	QuestionParagraph  = "paragraph" // long answer
+	QuestionChoice     = "choice"    // radio buttons, a single option
+	QuestionCheckboxes = "checkboxes"
+	QuestionDropdown   = "dropdown"
+	QuestionScale      = "scale"
+	QuestionDate       = "date"
+)
+
+// Question is a question item to add to a form. The tags define its representation in a FormSpec.
+type Question struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type"` // one of the Question constants
+	Required    bool   `json:"required,omitempty"`
+
+	// Choice, checkboxes and dropdown questions
+	Options []string `json:"options,omitempty"`
+	Other   bool     `json:"other,omitempty"`   // add an "Other" option with a free text answer, not supported by dropdowns
+	Shuffle bool     `json:"shuffle,omitempty"` // show the options in random order
+
+	// Scale questions, from 1 to 5 by default
+	Low       int64  `json:"low,omitempty"`
+	High      int64  `json:"high,omitempty"`
+	LowLabel  string `json:"lowLabel,omitempty"`
+	HighLabel string `json:"highLabel,omitempty"`
+
+	// Date questions
+	IncludeTime bool `json:"includeTime,omitempty"`
+	ExcludeYear bool `json:"excludeYear,omitempty"`
+
+	// Grading turns the form into a quiz and makes the question graded.
+	Grading *Grading `json:"grading,omitempty"`
+}
+
+// Grading holds the point value and answer key of a quiz question.
+type Grading struct {
+	Points         int64    `json:"points"`
+	CorrectAnswers []string `json:"correctAnswers,omitempty"` // formatted like Answer.Values; checkbox answers must match all of them
+	Feedback       string   `json:"feedback,omitempty"`       // shown for every answer, not available for choice questions with correct answers
+	FeedbackRight  string   `json:"feedbackRight,omitempty"`  // choice questions only
+	FeedbackWrong  string   `json:"feedbackWrong,omitempty"`  // choice questions only
+}
+
+// QuestionInfo describes a question of a form.
+type QuestionInfo struct {
+	ItemID     string
+	QuestionID string // identifies the answers to this question in responses
+	Title      string
+	Type       string // one of the Question constants, or empty for other kinds of questions
+}
+
+// FormInfo holds the metadata of a form.
+type FormInfo struct {
+	ID            string
+	Title         string
+	Description   string
+	ResponderURL  string // share this URL to collect responses
+	EditURL       string
+	LinkedSheetID string // spreadsheet collecting the responses, if any
+	Questions     []QuestionInfo
+}
+
+// CreateForm creates a form with the given title, description and questions, in that order.
+func CreateForm(ctx context.Context, config auth.Config, title, description string, questions []Question) (*FormInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	requests, err := questionRequests(questions, 0)
+	if err != nil {
+		return nil, err
+	}
+	if description != "" {
+		requests = append([]*forms.Request{{
+			UpdateFormInfo: &forms.UpdateFormInfoRequest{
+				Info:       &forms.Info{Description: description},
+				UpdateMask: "description",
+			},
+		}}, requests...)
+	}
+
+	// Only the title can be set on creation, everything else is added with a batch update
+	form, err := formsService.Forms.Create(&forms.Form{
+		Info: &forms.Info{Title: title, DocumentTitle: title},
+	}).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to create form: %w", err)
+	}
+	if len(requests) == 0 {
+		return toFormInfo(form), nil
+	}
+
+	response, err := formsService.Forms.BatchUpdate(form.FormId, &forms.BatchUpdateFormRequest{
+		Requests:              requests,
+		IncludeFormInResponse: true,
+	}).Do()
+	if err != nil {
+		// Do not leave a half-built form behind
+		if driveService, driveErr := drive.NewService(ctx, option.WithHTTPClient(client)); driveErr == nil {
+			driveService.Files.Delete(form.FormId).SupportsAllDrives(true).Do()
+		}
+		return nil, fmt.Errorf("gFormsHelper: unable to add questions to form: %w", err)
+	}
+	return toFormInfo(response.Form), nil
+}
+
+// AddQuestions appends questions to the end of a form.
+func AddQuestions(ctx context.Context, config auth.Config, formID string, questions []Question) (*FormInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	form, err := formsService.Forms.Get(formID).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to retrieve form: %w", err)
+	}
+
+	requests, err := questionRequests(questions, len(form.Items))
+	if err != nil {
+		return nil, err
+	}
+	if len(requests) == 0 {
+		return toFormInfo(form), nil
+	}
+
+	response, err := formsService.Forms.BatchUpdate(formID, &forms.BatchUpdateFormRequest{
+		Requests:              requests,
+		IncludeFormInResponse: true,
+		// Fail instead of misplacing the questions if the form changed in the meantime
+		WriteControl: &forms.WriteControl{RequiredRevisionId: form.RevisionId},
+	}).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to add questions to form: %w", err)
+	}
+	return toFormInfo(response.Form), nil
+}
+
+// GetFormInfo returns the metadata and questions of a form.
+func GetFormInfo(ctx context.Context, config auth.Config, formID string) (*FormInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	form, err := formsService.Forms.Get(formID).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to retrieve form: %w", err)
+	}
+	return toFormInfo(form), nil
+}
+
+// questionRequests returns the requests creating questions at consecutive positions from index,
+// preceded by a request enabling quiz mode if any question is graded.
+func questionRequests(questions []Question, index int) ([]*forms.Request, error) {
+	var requests []*forms.Request
+	for _, question := range questions {
+		if question.Grading != nil {
+			requests = append(requests, quizModeRequest(true))
+			break
+		}
+	}
+	for i, question := range questions {
+		item, err := newQuestionItem(question)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, &forms.Request{
+			CreateItem: &forms.CreateItemRequest{
+				Item:     item,
+				Location: &forms.Location{Index: int64(index + i), ForceSendFields: []string{"Index"}},
+			},
+		})
+	}
+	return requests, nil
+}
+
+// newQuestionItem converts a Question into a form item.
+func newQuestionItem(question Question) (*forms.Item, error) {
+	if question.Title == "" {
+		return nil, fmt.Errorf("gFormsHelper: question has no title")
+	}
+
+	q := &forms.Question{Required: question.Required}
+	switch question.Type {
+	case QuestionText, QuestionParagraph:
+		q.TextQuestion = &forms.TextQuestion{Paragraph: question.Type == QuestionParagraph}
+	case QuestionChoice, QuestionCheckboxes, QuestionDropdown:
+		if len(question.Options) == 0 {
+			return nil, fmt.Errorf("gFormsHelper: question %q has no options", question.Title)
+		}
+		choiceType := map[string]string{
+			QuestionChoice:     "RADIO",
+			QuestionCheckboxes: "CHECKBOX",
+			QuestionDropdown:   "DROP_DOWN",
+		}[question.Type]
+		if question.Other && choiceType == "DROP_DOWN" {
+			return nil, fmt.Errorf("gFormsHelper: dropdown question %q cannot have an Other option", question.Title)
+		}
+
+		choice := &forms.ChoiceQuestion{Type: choiceType, Shuffle: question.Shuffle}
+		for _, option := range question.Options {
+			choice.Options = append(choice.Options, &forms.Option{Value: option})
+		}
+		if question.Other {
+			choice.Options = append(choice.Options, &forms.Option{IsOther: true})
+		}
+		q.ChoiceQuestion = choice
+	case QuestionScale:
+		low, high := question.Low, question.High
+		if low == 0 && high == 0 {
+			low, high = 1, 5
+		}
+		// Forms only accepts scales starting at 0 or 1 and ending between 2 and 10
+		if (low != 0 && low != 1) || high < 2 || high > 10 {
+			return nil, fmt.Errorf("gFormsHelper: scale of question %q must go from 0 or 1 to 2-10", question.Title)
+		}
+		q.ScaleQuestion = &forms.ScaleQuestion{
+			Low:             low,
+			High:            high,
+			LowLabel:        question.LowLabel,
+			HighLabel:       question.HighLabel,
+			ForceSendFields: []string{"Low"},
+		}
+	case QuestionDate:
+		q.DateQuestion = &forms.DateQuestion{
+			IncludeTime: question.IncludeTime,
+			IncludeYear: !question.ExcludeYear,
+		}
+	default:
+		return nil, fmt.Errorf("gFormsHelper: unsupported question type %q", question.Type)
+	}
+
+	if question.Grading != nil {
+		q.Grading = toGrading(*question.Grading)
+	}
+
+	return &forms.Item{
+		Title:        question.Title,
+		Description:  question.Description,
+		QuestionItem: &forms.QuestionItem{Question: q},
+	}, nil
+}
+
+// toGrading converts a Grading into its form representation.
+func toGrading(grading Grading) *forms.Grading {
+	result := &forms.Grading{
+		PointValue:      grading.Points,
+		CorrectAnswers:  &forms.CorrectAnswers{},
+		ForceSendFields: []string{"PointValue"},
+	}
+	for _, answer := range grading.CorrectAnswers {
+		result.CorrectAnswers.Answers = append(result.CorrectAnswers.Answers, &forms.CorrectAnswer{Value: answer})
+	}
+	if grading.Feedback != "" {
+		result.GeneralFeedback = &forms.Feedback{Text: grading.Feedback}
+	}
+	if grading.FeedbackRight != "" {
+		result.WhenRight = &forms.Feedback{Text: grading.FeedbackRight}
+	}
+	if grading.FeedbackWrong != "" {
+		result.WhenWrong = &forms.Feedback{Text: grading.FeedbackWrong}
+	}
+	return result
+}
+
+// quizModeRequest returns the request turning quiz mode on or off.
+func quizModeRequest(enabled bool) *forms.Request {
+	return &forms.Request{
+		UpdateSettings: &forms.UpdateSettingsRequest{
+			Settings: &forms.FormSettings{
+				QuizSettings: &forms.QuizSettings{IsQuiz: enabled, ForceSendFields: []string{"IsQuiz"}},
+			},
+			UpdateMask: "quizSettings.isQuiz",
+		},
+	}
+}
+
+// SetQuizMode turns a form into a quiz, or back into a regular form. Turning quiz mode off
+// deletes the grading of every question.
+func SetQuizMode(ctx context.Context, config auth.Config, formID string, enabled bool) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	_, err = formsService.Forms.BatchUpdate(formID, &forms.BatchUpdateFormRequest{
+		Requests: []*forms.Request{quizModeRequest(enabled)},
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("gFormsHelper: unable to update quiz mode: %w", err)
+	}
+	return nil
+}
+
+// SetQuestionGrading sets the point value and answer key of a question, identified by its
+// QuestionInfo.ItemID, turning the form into a quiz if needed.
+func SetQuestionGrading(ctx context.Context, config auth.Config, formID, itemID string, grading Grading) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	form, err := formsService.Forms.Get(formID).Do()
+	if err != nil {
+		return fmt.Errorf("gFormsHelper: unable to retrieve form: %w", err)
+	}
+
+	for index, item := range form.Items {
+		if item.ItemId != itemID {
+			continue
+		}
+		if item.QuestionItem == nil || item.QuestionItem.Question == nil {
+			return fmt.Errorf("gFormsHelper: item %s is not a question", itemID)
+		}
+
+		item.QuestionItem.Question.Grading = toGrading(grading)
+		_, err = formsService.Forms.BatchUpdate(formID, &forms.BatchUpdateFormRequest{
+			Requests: []*forms.Request{
+				quizModeRequest(true),
+				{
+					UpdateItem: &forms.UpdateItemRequest{
+						Item:       item,
+						Location:   &forms.Location{Index: int64(index), ForceSendFields: []string{"Index"}},
+						UpdateMask: "questionItem.question.grading",
+					},
+				},
+			},
+			WriteControl: &forms.WriteControl{RequiredRevisionId: form.RevisionId},
+		}).Do()
+		if err != nil {
+			return fmt.Errorf("gFormsHelper: unable to set grading of item %s: %w", itemID, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("gFormsHelper: item %s not found in form %s", itemID, formID)
+}
+
+// toFormInfo converts a form into a FormInfo.
+func toFormInfo(form *forms.Form) *FormInfo {
+	info := &FormInfo{
+		ID:            form.FormId,
+		ResponderURL:  form.ResponderUri,
+		EditURL:       fmt.Sprintf("https://docs.google.com/forms/d/%s/edit", form.FormId),
+		LinkedSheetID: form.LinkedSheetId,
+	}
+	if form.Info != nil {
+		info.Title = form.Info.Title
+		info.Description = form.Info.Description
+	}
+
+	for _, item := range form.Items {
+		if item.QuestionItem == nil || item.QuestionItem.Question == nil {
+			continue
+		}
+		question := item.QuestionItem.Question
+		info.Questions = append(info.Questions, QuestionInfo{
+			ItemID:     item.ItemId,
+			QuestionID: question.QuestionId,
+			Title:      item.Title,
+			Type:       questionType(question),
+		})
+	}
+	return info
+}
+
+// questionType returns the Question constant matching a form question.
+func questionType(question *forms.Question) string {
+	switch {
+	case question.TextQuestion != nil && question.TextQuestion.Paragraph:
+		return QuestionParagraph
+	case question.TextQuestion != nil:
+		return QuestionText
+	case question.ChoiceQuestion != nil:
+		switch question.ChoiceQuestion.Type {
+		case "CHECKBOX":
+			return QuestionCheckboxes
+		case "DROP_DOWN":
+			return QuestionDropdown
+		}
+		return QuestionChoice
+	case question.ScaleQuestion != nil:
+		return QuestionScale
+	case question.DateQuestion != nil:
+		return QuestionDate
+	}
+	return ""
+}
+
+// UploadedFile is a file uploaded in answer to a file upload question.
+type UploadedFile struct {
+	FileID   string
+	Name     string
+	MimeType string
+}
+
+// Answer holds the answer to a question. Checkbox questions have a value per selected option,
+// other questions have at most one.
+type Answer struct {
+	QuestionID string
+	Title      string
+	Values     []string
+	Files      []UploadedFile // file upload questions
+	Grade      *Grade         // set for graded quiz questions
+}
+
+// Grade is the grading of an answer to a quiz question.
+type Grade struct {
+	Score    float64
+	Correct  bool
+	Feedback string
+}
+
+// Text returns the values of the answer, or the names of the uploaded files, separated by
+// commas.
+func (a Answer) Text() string {
+	if len(a.Files) > 0 {
+		var names []string
+		for _, file := range a.Files {
+			names = append(names, file.Name)
+		}
+		return strings.Join(names, ", ")
+	}
+	return strings.Join(a.Values, ", ")
+}
+
+// Response is a submitted response to a form.
+type Response struct {
+	ID              string
+	RespondentEmail string // only set when the form collects emails
+	Created         time.Time
+	Submitted       time.Time // last submission, later than Created if the response was edited
+
+	TotalScore float64 // set for graded quiz responses
+
+	// Answers are keyed by question title. Questions sharing a title get a numeric suffix,
+	// e.g., "Comments (2)", and the rows of grid questions are keyed as "Question [Row]".
+	Answers map[string]Answer
+}
+
+// ListResponses lists the responses of a form submitted after since, oldest first. A zero since
+// returns every response; to fetch responses incrementally, pass the Submitted time of the last
+// response processed.
+func ListResponses(ctx context.Context, config auth.Config, formID string, since time.Time) ([]Response, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	_, responses, err := fetchResponses(ctx, formsService, formID, since)
+	return responses, err
+}
+
+// ExportResponsesCSV writes every response of a form to w as CSV, with a column per question in
+// form order after the submission time and respondent email columns.
+func ExportResponsesCSV(ctx context.Context, config auth.Config, formID string, w io.Writer) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	form, responses, err := fetchResponses(ctx, formsService, formID, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	for _, row := range responseTable(form, responses) {
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("gFormsHelper: unable to write CSV: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("gFormsHelper: unable to write CSV: %w", err)
+	}
+	return nil
+}
+
+// ExportResponsesToSheet writes every response of a form into a tab of a spreadsheet, replacing
+// its content, with the same columns as ExportResponsesCSV. An empty sheetName selects the first
+// tab, and an empty spreadsheetID creates a new spreadsheet named after the form. It returns the
+// ID of the spreadsheet.
+func ExportResponsesToSheet(ctx context.Context, config auth.Config, formID, spreadsheetID, sheetName string) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	form, responses, err := fetchResponses(ctx, formsService, formID, time.Time{})
+	if err != nil {
+		return "", err
+	}
+
+	var spreadsheet *gSheetsHelper.SpreadsheetInfo
+	if spreadsheetID == "" {
+		title := "Form responses"
+		if form.Info != nil && form.Info.Title != "" {
+			title = form.Info.Title + " (Responses)"
+		}
+		spreadsheet, err = gSheetsHelper.CreateSpreadsheet(ctx, config, title, "")
+	} else if sheetName == "" {
+		spreadsheet, err = gSheetsHelper.GetSpreadsheetInfo(ctx, config, spreadsheetID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("gFormsHelper: unable to prepare spreadsheet: %w", err)
+	}
+	if spreadsheet != nil {
+		spreadsheetID = spreadsheet.ID
+		if sheetName == "" && len(spreadsheet.Sheets) > 0 {
+			sheetName = spreadsheet.Sheets[0].Title
+		}
+	}
+
+	var values [][]interface{}
+	for _, row := range responseTable(form, responses) {
+		cells := make([]interface{}, len(row))
+		for i, cell := range row {
+			cells[i] = cell
+		}
+		values = append(values, cells)
+	}
+
+	// Raw input keeps answers such as "=1+1" or "05-19" from being interpreted
+	sheetRange := "'" + strings.ReplaceAll(sheetName, "'", "''") + "'"
+	if _, err := gSheetsHelper.WriteRange(ctx, config, spreadsheetID, sheetRange, values, gSheetsHelper.InputRaw); err != nil {
+		return spreadsheetID, fmt.Errorf("gFormsHelper: unable to export responses: %w", err)
+	}
+	return spreadsheetID, nil
+}
+
+// formQuestion is a question of a form, with the title its answers are keyed by.
+type formQuestion struct {
+	ID    string
+	Title string
+}
+
+// formQuestions returns the questions of a form in form order, including the rows of grid
+// questions, with unique titles.
+func formQuestions(form *forms.Form) []formQuestion {
+	var questions []formQuestion
+	seen := map[string]int{}
+	add := func(id, title string) {
+		seen[title]++
+		if seen[title] > 1 {
+			title = fmt.Sprintf("%s (%d)", title, seen[title])
+		}
+		questions = append(questions, formQuestion{ID: id, Title: title})
+	}
+
+	for _, item := range form.Items {
+		switch {
+		case item.QuestionItem != nil && item.QuestionItem.Question != nil:
+			add(item.QuestionItem.Question.QuestionId, item.Title)
+		case item.QuestionGroupItem != nil:
+			for _, question := range item.QuestionGroupItem.Questions {
+				title := item.Title
+				if question.RowQuestion != nil {
+					title = fmt.Sprintf("%s [%s]", item.Title, question.RowQuestion.Title)
+				}
+				add(question.QuestionId, title)
+			}
+		}
+	}
+	return questions
+}
+
+// fetchResponses retrieves a form and its responses submitted after since, oldest first.
+func fetchResponses(ctx context.Context, formsService *forms.Service, formID string, since time.Time) (*forms.Form, []Response, error) {
+	form, err := formsService.Forms.Get(formID).Do()
+	if err != nil {
+		return nil, nil, fmt.Errorf("gFormsHelper: unable to retrieve form: %w", err)
+	}
+
+	titles := map[string]string{}
+	for _, question := range formQuestions(form) {
+		titles[question.ID] = question.Title
+	}
+
+	var responses []Response
+	call := formsService.Forms.Responses.List(formID)
+	if !since.IsZero() {
+		call = call.Filter("timestamp > " + since.UTC().Format(time.RFC3339Nano))
+	}
+	err = call.Pages(ctx, func(page *forms.ListFormResponsesResponse) error {
+		for _, formResponse := range page.Responses {
+			responses = append(responses, toResponse(formResponse, titles))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("gFormsHelper: unable to list responses: %w", err)
+	}
+
+	sort.SliceStable(responses, func(i, j int) bool {
+		return responses[i].Submitted.Before(responses[j].Submitted)
+	})
+	return form, responses, nil
+}
+
+// toResponse converts a form response, keying its answers by the titles of their questions.
+func toResponse(formResponse *forms.FormResponse, titles map[string]string) Response {
+	response := Response{
+		ID:              formResponse.ResponseId,
+		RespondentEmail: formResponse.RespondentEmail,
+		TotalScore:      formResponse.TotalScore,
+		Answers:         map[string]Answer{},
+	}
+	if created, err := time.Parse(time.RFC3339, formResponse.CreateTime); err == nil {
+		response.Created = created
+	}
+	if submitted, err := time.Parse(time.RFC3339, formResponse.LastSubmittedTime); err == nil {
+		response.Submitted = submitted
+	}
+
+	for questionID, formAnswer := range formResponse.Answers {
+		title, ok := titles[questionID]
+		if !ok {
+			// The question was deleted after the response was submitted
+			title = questionID
+		}
+
+		answer := Answer{QuestionID: questionID, Title: title}
+		if formAnswer.TextAnswers != nil {
+			for _, text := range formAnswer.TextAnswers.Answers {
+				answer.Values = append(answer.Values, text.Value)
+			}
+		}
+		if formAnswer.FileUploadAnswers != nil {
+			for _, file := range formAnswer.FileUploadAnswers.Answers {
+				answer.Files = append(answer.Files, UploadedFile{FileID: file.FileId, Name: file.FileName, MimeType: file.MimeType})
+			}
+		}
+		if grade := formAnswer.Grade; grade != nil {
+			answer.Grade = &Grade{Score: grade.Score, Correct: grade.Correct}
+			if grade.Feedback != nil {
+				answer.Grade.Feedback = grade.Feedback.Text
+			}
+		}
+		response.Answers[title] = answer
+	}
+	return response
+}
+
+// responseTable lays out responses as rows, with a header row.
+func responseTable(form *forms.Form, responses []Response) [][]string {
+	questions := formQuestions(form)
+	header := []string{"Submitted", "Email"}
+	for _, question := range questions {
+		header = append(header, question.Title)
+	}
+
+	rows := [][]string{header}
+	for _, response := range responses {
+		row := []string{response.Submitted.Format(time.RFC3339), response.RespondentEmail}
+		for _, question := range questions {
+			row = append(row, response.Answers[question.Title].Text())
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// QuizResult is the grade of a quiz response.
+type QuizResult struct {
+	ResponseID      string
+	RespondentEmail string
+	Submitted       time.Time
+	Score           float64
+	MaxScore        int64 // sum of the point values of the graded questions
+	Correct         int   // number of questions answered correctly
+}
+
+// ListQuizResults returns the grade of every response of a quiz, oldest first. Questions that
+// need manual grading count towards the score once they are graded in Forms.
+func ListQuizResults(ctx context.Context, config auth.Config, formID string) ([]QuizResult, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	form, responses, err := fetchResponses(ctx, formsService, formID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if form.Settings == nil || form.Settings.QuizSettings == nil || !form.Settings.QuizSettings.IsQuiz {
+		return nil, fmt.Errorf("gFormsHelper: form %s is not a quiz", formID)
+	}
+
+	var maxScore int64
+	for _, item := range form.Items {
+		if item.QuestionItem != nil && item.QuestionItem.Question != nil && item.QuestionItem.Question.Grading != nil {
+			maxScore += item.QuestionItem.Question.Grading.PointValue
+		}
+	}
+
+	var results []QuizResult
+	for _, response := range responses {
+		result := QuizResult{
+			ResponseID:      response.ID,
+			RespondentEmail: response.RespondentEmail,
+			Submitted:       response.Submitted,
+			Score:           response.TotalScore,
+			MaxScore:        maxScore,
+		}
+		for _, answer := range response.Answers {
+			if answer.Grade != nil && answer.Grade.Correct {
+				result.Correct++
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// FormWatch describes a push notification watch on a form.
+type FormWatch struct {
+	ID         string
+	FormID     string
+	EventType  string // "RESPONSES" or "SCHEMA"
+	Topic      string
+	State      string // "ACTIVE" or "SUSPENDED"
+	ErrorType  string // why a suspended watch stopped delivering notifications
+	Expiration time.Time
+}
+
+// WatchFormResponses publishes a notification to a Cloud Pub/Sub topic, e.g.,
+// "projects/my-project/topics/forms", whenever a response is submitted to the form. The topic must
+// grant forms-notifications@system.gserviceaccount.com the publisher role and belong to the
+// project of the credentials. Watches expire after 7 days unless renewed with RenewFormWatch.
+func WatchFormResponses(ctx context.Context, config auth.Config, formID, topic string) (*FormWatch, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	watch, err := formsService.Forms.Watches.Create(formID, &forms.CreateWatchRequest{
+		Watch: &forms.Watch{
+			EventType: "RESPONSES",
+			Target:    &forms.WatchTarget{Topic: &forms.CloudPubsubTopic{TopicName: topic}},
+		},
+	}).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to watch form responses: %w", err)
+	}
+	return toFormWatch(formID, watch), nil
+}
+
+// ListFormWatches lists the watches created on a form with the current credentials.
+func ListFormWatches(ctx context.Context, config auth.Config, formID string) ([]FormWatch, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	response, err := formsService.Forms.Watches.List(formID).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to list form watches: %w", err)
+	}
+
+	var watches []FormWatch
+	for _, watch := range response.Watches {
+		watches = append(watches, *toFormWatch(formID, watch))
+	}
+	return watches, nil
+}
+
+// RenewFormWatch extends a watch for another 7 days and reactivates it if it was suspended.
+func RenewFormWatch(ctx context.Context, config auth.Config, formID, watchID string) (*FormWatch, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	watch, err := formsService.Forms.Watches.Renew(formID, watchID, &forms.RenewWatchRequest{}).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to renew form watch %s: %w", watchID, err)
+	}
+	return toFormWatch(formID, watch), nil
+}
+
+// StopFormWatch deletes a watch.
+func StopFormWatch(ctx context.Context, config auth.Config, formID, watchID string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	if _, err := formsService.Forms.Watches.Delete(formID, watchID).Do(); err != nil {
+		return fmt.Errorf("gFormsHelper: unable to stop form watch %s: %w", watchID, err)
+	}
+	return nil
+}
+
+// FormNotification identifies the form and watch a Pub/Sub message was published for.
+type FormNotification struct {
+	FormID    string
+	WatchID   string
+	EventType string
+}
+
+// ParseFormNotification reads the attributes of a Pub/Sub message published for a form watch.
+// The message carries no response data; use ListResponses with the Submitted time of the last
+// response processed to fetch the new responses.
+func ParseFormNotification(attributes map[string]string) (FormNotification, error) {
+	notification := FormNotification{
+		FormID:    attributes["formId"],
+		WatchID:   attributes["watchId"],
+		EventType: attributes["eventType"],
+	}
+	if notification.FormID == "" {
+		return notification, fmt.Errorf("gFormsHelper: message is not a form notification")
+	}
+	return notification, nil
+}
+
+// toFormWatch converts a form watch into a FormWatch.
+func toFormWatch(formID string, watch *forms.Watch) *FormWatch {
+	info := &FormWatch{
+		ID:        watch.Id,
+		FormID:    formID,
+		EventType: watch.EventType,
+		State:     watch.State,
+		ErrorType: watch.ErrorType,
+	}
+	if watch.Target != nil && watch.Target.Topic != nil {
+		info.Topic = watch.Target.Topic.TopicName
+	}
+	if expiration, err := time.Parse(time.RFC3339, watch.ExpireTime); err == nil {
+		info.Expiration = expiration
+	}
+	return info
+}
+
+// FormSpec declares a whole form, so it can be kept in version control and created in one call.
+// Specs are YAML or JSON documents, read with ParseFormSpec and written with MarshalFormSpec or
+// MarshalFormSpecYAML. Both formats use the json field names.
+type FormSpec struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Questions   []Question `json:"questions"`
+}
+
+// ParseFormSpec decodes a YAML or JSON form spec. Unknown fields are rejected, so that a
+// misspelled setting is not silently ignored.
+func ParseFormSpec(data []byte) (*FormSpec, error) {
+	// YAML is a superset of JSON, so both are converted to JSON and decoded the same way
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to parse form spec: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var spec FormSpec
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to parse form spec: %w", err)
+	}
+	if spec.Title == "" {
+		return nil, fmt.Errorf("gFormsHelper: form spec has no title")
+	}
+	return &spec, nil
+}
+
+// MarshalFormSpec encodes spec as indented JSON that ParseFormSpec reads back, e.g., to save
+// the result of DumpFormSpec.
+func MarshalFormSpec(spec FormSpec) ([]byte, error) {
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to encode form spec: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// MarshalFormSpecYAML encodes spec as YAML that ParseFormSpec reads back.
+func MarshalFormSpecYAML(spec FormSpec) ([]byte, error) {
+	data, err := yaml.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("gFormsHelper: unable to encode form spec: %w", err)
+	}
+	return data, nil
+}
+
+// CreateFormFromSpec creates the form declared by spec.
+func CreateFormFromSpec(ctx context.Context, config auth.Config, spec FormSpec) (*FormInfo, error) {
+	return CreateForm(ctx, config, spec.Title, spec.Description, spec.Questions)
+}
+
+// DumpFormSpec returns the spec of an existing form, the reverse of CreateFormFromSpec. Items a
+// spec cannot represent, such as images, section breaks, grids, and file upload or time
+// questions, are left out and their titles returned as skipped.
+func DumpFormSpec(ctx context.Context, config auth.Config, formID string) (*FormSpec, []string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("gFormsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	formsService, err := forms.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, nil, fmt.Errorf("gFormsHelper: unable to create forms service: %w", err)
+	}
+
+	form, err := formsService.Forms.Get(formID).Do()
+	if err != nil {
+		return nil, nil, fmt.Errorf("gFormsHelper: unable to retrieve form: %w", err)
+	}
+
+	spec := &FormSpec{}
+	if form.Info != nil {
+		spec.Title = form.Info.Title
+		spec.Description = form.Info.Description
+	}
+
+	var skipped []string
+	for _, item := range form.Items {
+		if item.QuestionItem == nil || item.QuestionItem.Question == nil || questionType(item.QuestionItem.Question) == "" {
+			skipped = append(skipped, item.Title)
+			continue
+		}
+		spec.Questions = append(spec.Questions, toQuestion(item))
+	}
+	return spec, skipped, nil
+}
+
+// toQuestion converts a question item into a Question, the reverse of newQuestionItem.
+func toQuestion(item *forms.Item) Question {
+	q := item.QuestionItem.Question
+	question := Question{
+		Title:       item.Title,
+		Description: item.Description,
+		Type:        questionType(q),
+		Required:    q.Required,
+	}
+
+	switch {
+	case q.ChoiceQuestion != nil:
+		question.Shuffle = q.ChoiceQuestion.Shuffle
+		for _, option := range q.ChoiceQuestion.Options {
+			if option.IsOther {
+				question.Other = true
+				continue
+			}
+			question.Options = append(question.Options, option.Value)
+		}
+	case q.ScaleQuestion != nil:
+		question.Low = q.ScaleQuestion.Low
+		question.High = q.ScaleQuestion.High
+		question.LowLabel = q.ScaleQuestion.LowLabel
+		question.HighLabel = q.ScaleQuestion.HighLabel
+	case q.DateQuestion != nil:
+		question.IncludeTime = q.DateQuestion.IncludeTime
+		question.ExcludeYear = !q.DateQuestion.IncludeYear
+	}
+
+	if grading := q.Grading; grading != nil {
+		question.Grading = &Grading{Points: grading.PointValue}
+		if grading.CorrectAnswers != nil {
+			for _, answer := range grading.CorrectAnswers.Answers {
+				question.Grading.CorrectAnswers = append(question.Grading.CorrectAnswers, answer.Value)
+			}
+		}
+		if grading.GeneralFeedback != nil {
+			question.Grading.Feedback = grading.GeneralFeedback.Text
+		}
+		if grading.WhenRight != nil {
+			question.Grading.FeedbackRight = grading.WhenRight.Text
+		}
+		if grading.WhenWrong != nil {
+			question.Grading.FeedbackWrong = grading.WhenWrong.Text
+		}
+	}
+	return question
+}
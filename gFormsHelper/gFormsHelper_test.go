@@ -0,0 +1,90 @@
+package gFormsHelper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormSpecRoundTrip(t *testing.T) {
+	spec := FormSpec{
+		Title:       "Feedback",
+		Description: "Tell us how it went",
+		Questions: []Question{
+			{Title: "Name", Type: QuestionText, Required: true},
+			{Title: "Rating", Type: QuestionScale, Low: 1, High: 5, LowLabel: "Bad", HighLabel: "Great"},
+			{Title: "Topics", Type: QuestionCheckboxes, Options: []string{"Talks", "Food"}, Other: true},
+			{Title: "Capital", Type: QuestionChoice, Options: []string{"Paris", "Lyon"}, Grading: &Grading{Points: 2, CorrectAnswers: []string{"Paris"}}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		marshal func(FormSpec) ([]byte, error)
+	}{
+		{name: "JSON", marshal: MarshalFormSpec},
+		{name: "YAML", marshal: MarshalFormSpecYAML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.marshal(spec)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			parsed, err := ParseFormSpec(data)
+			if err != nil {
+				t.Fatalf("ParseFormSpec: %v", err)
+			}
+			if !reflect.DeepEqual(*parsed, spec) {
+				t.Fatalf("round trip = %+v, want %+v", *parsed, spec)
+			}
+		})
+	}
+}
+
+func TestParseFormSpecYAML(t *testing.T) {
+	data := `
+title: Feedback
+questions:
+  - title: Name
+    type: text
+    required: true
+  - title: Rating
+    type: scale
+    low: 1
+    high: 5
+`
+	want := FormSpec{
+		Title: "Feedback",
+		Questions: []Question{
+			{Title: "Name", Type: QuestionText, Required: true},
+			{Title: "Rating", Type: QuestionScale, Low: 1, High: 5},
+		},
+	}
+	spec, err := ParseFormSpec([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseFormSpec: %v", err)
+	}
+	if !reflect.DeepEqual(*spec, want) {
+		t.Fatalf("ParseFormSpec = %+v, want %+v", *spec, want)
+	}
+}
+
+func TestParseFormSpecErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "unknown JSON field", data: `{"title": "Form", "questions": [{"title": "Q", "type": "text", "requird": true}]}`},
+		{name: "unknown YAML field", data: "title: Form\nquestions:\n  - title: Q\n    type: text\n    requird: true\n"},
+		{name: "missing title", data: `{"questions": []}`},
+		{name: "malformed YAML", data: "title: [Form\n"},
+		{name: "wrong value type", data: "title: Form\nquestions:\n  - title: Q\n    low: one\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseFormSpec([]byte(tt.data)); err == nil {
+				t.Fatalf("ParseFormSpec(%s) succeeded, want an error", tt.data)
+			}
+		})
+	}
+}
@@ -1,18 +1,117 @@
 package gMeetHelper
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"html"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gnzdotmx/gworkspace-helper/auth"
+	"github.com/gnzdotmx/gworkspace-helper/gDriveHelper"
+	"github.com/gnzdotmx/gworkspace-helper/gdocsHelper"
+	"github.com/gnzdotmx/gworkspace-helper/internal/channelstore"
+	"github.com/gnzdotmx/gworkspace-helper/internal/concurrency"
+	"github.com/google/uuid"
 	"google.golang.org/api/calendar/v3"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/meet/v2"
 	"google.golang.org/api/option"
 )
 
+// TimeZoneJST is the Japan Standard Time zone, the only time zone supported by earlier versions.
+const TimeZoneJST = "Asia/Tokyo"
+
+// Values for the sendUpdates arguments and fields, controlling which guests are emailed about a
+// change. An empty value leaves the choice to the Calendar API, which does not notify guests.
+const (
+	SendUpdatesAll          = "all"
+	SendUpdatesExternalOnly = "externalOnly" // only guests outside the organizer's domain
+	SendUpdatesNone         = "none"
+)
+
+// checkSendUpdates returns an error if sendUpdates is not empty or one of the SendUpdates values.
+func checkSendUpdates(sendUpdates string) error {
+	switch sendUpdates {
+	case "", SendUpdatesAll, SendUpdatesExternalOnly, SendUpdatesNone:
+		return nil
+	}
+	return fmt.Errorf("gMeetHelper: invalid sendUpdates value %q", sendUpdates)
+}
+
+// EventOptions holds optional settings for CreateCalendarEvent.
+type EventOptions struct {
+	// CalendarID is the calendar to create the event in, defaults to "primary".
+	CalendarID string
+
+	// TimeZone is an IANA time zone name such as "Europe/Madrid". When empty, the time zone of the
+	// calendar is used.
+	TimeZone string
+
+	// Reminders overrides the calendar's default reminders when set.
+	Reminders *ReminderSettings
+
+	// AllDay creates an all-day event covering every date from startTime through endTime
+	// (inclusive, in TimeZone). The time of day of both arguments is ignored.
+	AllDay bool
+
+	// SendUpdates controls which guests are emailed about the new event, see SendUpdatesAll.
+	SendUpdates string
+}
+
+// Reminder is a notification sent before an event starts.
+type Reminder struct {
+	Method        string // "popup" or "email"
+	MinutesBefore int64
+}
+
+// ReminderSettings controls the reminders of an event.
+type ReminderSettings struct {
+	UseDefault bool       // use the calendar's default reminders; must be false when Overrides is set
+	Overrides  []Reminder // event-specific reminders, at most 5
+}
+
+// toEventReminders converts reminder settings into the Calendar API representation.
+func toEventReminders(settings *ReminderSettings) (*calendar.EventReminders, error) {
+	if settings.UseDefault && len(settings.Overrides) > 0 {
+		return nil, fmt.Errorf("gMeetHelper: reminder overrides cannot be combined with default reminders")
+	}
+
+	reminders := &calendar.EventReminders{
+		UseDefault:      settings.UseDefault,
+		ForceSendFields: []string{"UseDefault"},
+	}
+	for _, reminder := range settings.Overrides {
+		if reminder.Method != "popup" && reminder.Method != "email" {
+			return nil, fmt.Errorf("gMeetHelper: unsupported reminder method %q", reminder.Method)
+		}
+		reminders.Overrides = append(reminders.Overrides, &calendar.EventReminder{
+			Method:          reminder.Method,
+			Minutes:         reminder.MinutesBefore,
+			ForceSendFields: []string{"Minutes"},
+		})
+	}
+	if len(reminders.Overrides) == 0 {
+		// Send an empty list so that no reminders are used at all
+		reminders.ForceSendFields = append(reminders.ForceSendFields, "Overrides")
+	}
+	return reminders, nil
+}
+
 // CreateCalendarEvent creates a new event in Google Calendar.
-func CreateCalendarEvent(ctx context.Context, config auth.Config, summary, location, description string, startTime, endTime time.Time) (*calendar.Event, error) {
+func CreateCalendarEvent(ctx context.Context, config auth.Config, summary, location, description string, startTime, endTime time.Time, opts EventOptions) (*calendar.Event, error) {
 	conf, token, err := auth.GetClient(ctx, config)
 	if err != nil {
 		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
@@ -24,31 +123,66 @@ func CreateCalendarEvent(ctx context.Context, config auth.Config, summary, locat
 		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
 	}
 
-	// Load the Japanese timezone
-	jst, err := time.LoadLocation("Asia/Tokyo")
+	timeZone, err := resolveTimeZone(calendarService, opts.CalendarID, opts.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := newEvent(summary, location, description, startTime, endTime, timeZone, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	call := calendarService.Events.Insert(calendarOrPrimary(opts.CalendarID), event).ConferenceDataVersion(1)
+	if opts.SendUpdates != "" {
+		call = call.SendUpdates(opts.SendUpdates)
+	}
+
+	createdEvent, err := call.Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar event: %w", err)
+	}
+	return createdEvent, nil
+}
+
+// resolveTimeZone returns timeZone, or the time zone of the calendar when it is empty.
+func resolveTimeZone(calendarService *calendar.Service, calendarID, timeZone string) (string, error) {
+	if timeZone != "" {
+		return timeZone, nil
+	}
+	cal, err := calendarService.Calendars.Get(calendarOrPrimary(calendarID)).Do()
 	if err != nil {
-		return nil, fmt.Errorf("gMeetHelper: unable to load Japanese timezone: %w", err)
+		return "", fmt.Errorf("gMeetHelper: unable to retrieve calendar time zone: %w", err)
+	}
+	return cal.TimeZone, nil
+}
+
+// newEvent builds an event with a Google Meet conference request, ready to be inserted.
+func newEvent(summary, location, description string, startTime, endTime time.Time, timeZone string, opts EventOptions) (*calendar.Event, error) {
+	if err := checkSendUpdates(opts.SendUpdates); err != nil {
+		return nil, err
 	}
 
-	// Convert startTime and endTime to Japanese timezone
-	startTimeJST := startTime.In(jst)
-	endTimeJST := endTime.In(jst)
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to load timezone %s: %w", timeZone, err)
+	}
 
 	event := &calendar.Event{
 		Summary:     summary,
 		Location:    location,
 		Description: description,
 		Start: &calendar.EventDateTime{
-			DateTime: startTimeJST.Format(time.RFC3339),
-			TimeZone: "Asia/Tokyo",
+			DateTime: startTime.In(loc).Format(time.RFC3339),
+			TimeZone: timeZone,
 		},
 		End: &calendar.EventDateTime{
-			DateTime: endTimeJST.Format(time.RFC3339),
-			TimeZone: "Asia/Tokyo",
+			DateTime: endTime.In(loc).Format(time.RFC3339),
+			TimeZone: timeZone,
 		},
 		ConferenceData: &calendar.ConferenceData{
 			CreateRequest: &calendar.CreateConferenceRequest{
-				RequestId: "unique-request-id", // Must be unique for each request
+				RequestId: uuid.NewString(), // Must be unique for each request
 				ConferenceSolutionKey: &calendar.ConferenceSolutionKey{
 					Type: "hangoutsMeet",
 				},
@@ -56,15 +190,167 @@ func CreateCalendarEvent(ctx context.Context, config auth.Config, summary, locat
 		},
 	}
 
-	createdEvent, err := calendarService.Events.Insert("primary", event).ConferenceDataVersion(1).Do()
+	if opts.AllDay {
+		startDate := startTime.In(loc)
+		endDate := endTime.In(loc)
+		if endDate.Format("2006-01-02") < startDate.Format("2006-01-02") {
+			return nil, fmt.Errorf("gMeetHelper: all-day event ends before it starts")
+		}
+		// The end date of an all-day event is exclusive
+		event.Start = &calendar.EventDateTime{Date: startDate.Format("2006-01-02"), TimeZone: timeZone}
+		event.End = &calendar.EventDateTime{Date: endDate.AddDate(0, 0, 1).Format("2006-01-02"), TimeZone: timeZone}
+	}
+
+	if opts.Reminders != nil {
+		event.Reminders, err = toEventReminders(opts.Reminders)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return event, nil
+}
+
+// calendarOrPrimary returns calendarID, or "primary" when it is empty.
+func calendarOrPrimary(calendarID string) string {
+	if calendarID == "" {
+		return "primary"
+	}
+	return calendarID
+}
+
+// ConferenceInfo holds the Google Meet details of an event.
+type ConferenceInfo struct {
+	MeetLink      string
+	ConferenceID  string
+	Status        string // "success", "pending" or "failure" while the conference is being created
+	DialIns       []DialIn
+	MoreDialInURL string // page listing dial-in numbers for other countries
+}
+
+// DialIn is a phone number that can be used to join a conference.
+type DialIn struct {
+	Number     string // e.g., "+1 234-567-8900"
+	URI        string // tel: URI
+	PIN        string
+	RegionCode string
+}
+
+// GetConferenceInfo extracts the Google Meet link and conference ID from an event, such as the one
+// returned by CreateCalendarEvent.
+func GetConferenceInfo(event *calendar.Event) ConferenceInfo {
+	info := ConferenceInfo{MeetLink: event.HangoutLink}
+	if event.ConferenceData == nil {
+		return info
+	}
+
+	info.ConferenceID = event.ConferenceData.ConferenceId
+	if event.ConferenceData.CreateRequest != nil && event.ConferenceData.CreateRequest.Status != nil {
+		info.Status = event.ConferenceData.CreateRequest.Status.StatusCode
+	}
+	for _, entryPoint := range event.ConferenceData.EntryPoints {
+		switch entryPoint.EntryPointType {
+		case "video":
+			if info.MeetLink == "" {
+				info.MeetLink = entryPoint.Uri
+			}
+		case "phone":
+			info.DialIns = append(info.DialIns, DialIn{
+				Number:     entryPoint.Label,
+				URI:        entryPoint.Uri,
+				PIN:        entryPoint.Pin,
+				RegionCode: entryPoint.RegionCode,
+			})
+		case "more":
+			info.MoreDialInURL = entryPoint.Uri
+		}
+	}
+	return info
+}
+
+// GetEventConferenceInfo retrieves an event and returns its Google Meet link, conference ID and
+// phone dial-in details.
+func GetEventConferenceInfo(ctx context.Context, config auth.Config, calendarID, eventID string) (*ConferenceInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
 	if err != nil {
-		return nil, fmt.Errorf("gMeetHelper: unable to create calendar event: %w", err)
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
 	}
-	return createdEvent, nil
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	event, err := calendarService.Events.Get(calendarOrPrimary(calendarID), eventID).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to retrieve event: %w", err)
+	}
+	if event.ConferenceData == nil && event.HangoutLink == "" {
+		return nil, fmt.Errorf("gMeetHelper: event %s has no conference", eventID)
+	}
+
+	info := GetConferenceInfo(event)
+	return &info, nil
+}
+
+// AddAttendeesToEvent adds attendees to an existing event. An empty calendarID means "primary".
+// Attendees that are already invited are left unchanged. sendUpdates controls which guests are
+// emailed, see SendUpdatesAll.
+func AddAttendeesToEvent(ctx context.Context, config auth.Config, calendarID, eventID string, attendees []string, sendUpdates string) error {
+	return updateAttendees(ctx, config, calendarID, eventID, sendUpdates, func(current []*calendar.EventAttendee) []*calendar.EventAttendee {
+		return mergeAttendees(current, attendees, false)
+	})
+}
+
+// AddOptionalAttendeesToEvent adds attendees marked as optional to an existing event.
+// Attendees that are already invited are marked as optional.
+func AddOptionalAttendeesToEvent(ctx context.Context, config auth.Config, calendarID, eventID string, attendees []string, sendUpdates string) error {
+	return updateAttendees(ctx, config, calendarID, eventID, sendUpdates, func(current []*calendar.EventAttendee) []*calendar.EventAttendee {
+		return mergeAttendees(current, attendees, true)
+	})
 }
 
-// AddAttendeesToEvent adds attendees to an existing event.
-func AddAttendeesToEvent(ctx context.Context, config auth.Config, eventID string, attendees []string) error {
+// SetAttendeeOptional marks an invited attendee as optional or required.
+func SetAttendeeOptional(ctx context.Context, config auth.Config, calendarID, eventID, email string, optional bool, sendUpdates string) error {
+	found := false
+	err := updateAttendees(ctx, config, calendarID, eventID, sendUpdates, func(current []*calendar.EventAttendee) []*calendar.EventAttendee {
+		for _, attendee := range current {
+			if strings.EqualFold(attendee.Email, email) {
+				attendee.Optional = optional
+				attendee.ForceSendFields = append(attendee.ForceSendFields, "Optional")
+				found = true
+			}
+		}
+		return current
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("gMeetHelper: %s is not an attendee of event %s", email, eventID)
+	}
+	return nil
+}
+
+// RemoveAttendeesFromEvent removes attendees from an existing event by email.
+func RemoveAttendeesFromEvent(ctx context.Context, config auth.Config, calendarID, eventID string, emails []string, sendUpdates string) error {
+	return updateAttendees(ctx, config, calendarID, eventID, sendUpdates, func(current []*calendar.EventAttendee) []*calendar.EventAttendee {
+		remaining := make([]*calendar.EventAttendee, 0, len(current))
+		for _, attendee := range current {
+			if !containsEmail(emails, attendee.Email) {
+				remaining = append(remaining, attendee)
+			}
+		}
+		return remaining
+	})
+}
+
+// updateAttendees retrieves an event, replaces its attendees with the result of update and saves it.
+func updateAttendees(ctx context.Context, config auth.Config, calendarID, eventID, sendUpdates string, update func([]*calendar.EventAttendee) []*calendar.EventAttendee) error {
+	if err := checkSendUpdates(sendUpdates); err != nil {
+		return err
+	}
+
 	conf, token, err := auth.GetClient(ctx, config)
 	if err != nil {
 		return fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
@@ -76,24 +362,60 @@ func AddAttendeesToEvent(ctx context.Context, config auth.Config, eventID string
 		return fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
 	}
 
-	event, err := calendarService.Events.Get("primary", eventID).Do()
+	event, err := calendarService.Events.Get(calendarOrPrimary(calendarID), eventID).Do()
 	if err != nil {
 		return fmt.Errorf("gMeetHelper: unable to retrieve event: %w", err)
 	}
 
-	for _, email := range attendees {
-		event.Attendees = append(event.Attendees, &calendar.EventAttendee{Email: email})
+	event.Attendees = update(event.Attendees)
+	// An empty list has to be sent explicitly to remove the last attendee
+	event.ForceSendFields = append(event.ForceSendFields, "Attendees")
+
+	call := calendarService.Events.Update(calendarOrPrimary(calendarID), event.Id, event)
+	if sendUpdates != "" {
+		call = call.SendUpdates(sendUpdates)
 	}
 
-	_, err = calendarService.Events.Update("primary", event.Id, event).Do()
+	_, err = call.Do()
 	if err != nil {
-		return fmt.Errorf("gMeetHelper: unable to add attendees to event: %w", err)
+		return fmt.Errorf("gMeetHelper: unable to update event attendees: %w", err)
 	}
 	return nil
 }
 
-// AttachFileToEvent attaches a file to an event.
-func AttachFileToEvent(ctx context.Context, config auth.Config, eventID, fileID string) error {
+// mergeAttendees adds the emails that are not yet in current. With optional set, both new and
+// existing matching attendees are marked as optional.
+func mergeAttendees(current []*calendar.EventAttendee, emails []string, optional bool) []*calendar.EventAttendee {
+	for _, email := range emails {
+		var existing *calendar.EventAttendee
+		for _, attendee := range current {
+			if strings.EqualFold(attendee.Email, email) {
+				existing = attendee
+				break
+			}
+		}
+
+		if existing == nil {
+			current = append(current, &calendar.EventAttendee{Email: email, Optional: optional})
+		} else if optional {
+			existing.Optional = true
+		}
+	}
+	return current
+}
+
+// containsEmail reports whether emails contains email, ignoring case.
+func containsEmail(emails []string, email string) bool {
+	for _, candidate := range emails {
+		if strings.EqualFold(candidate, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// AttachFileToEvent attaches a file to an event. An empty calendarID means "primary".
+func AttachFileToEvent(ctx context.Context, config auth.Config, calendarID, eventID, fileID string) error {
 	// Authenticate and create the client
 	conf, token, err := auth.GetClient(ctx, config)
 	if err != nil {
@@ -120,7 +442,7 @@ func AttachFileToEvent(ctx context.Context, config auth.Config, eventID, fileID
 	}
 
 	// Retrieve the event
-	event, err := calendarService.Events.Get("primary", eventID).Do()
+	event, err := calendarService.Events.Get(calendarOrPrimary(calendarID), eventID).Do()
 	if err != nil {
 		return fmt.Errorf("gMeetHelper: unable to retrieve event: %w", err)
 	}
@@ -142,10 +464,2813 @@ func AttachFileToEvent(ctx context.Context, config auth.Config, eventID, fileID
 	event.Attachments = append(event.Attachments, attachment)
 
 	// Update the event with supportsAttachments set to true
-	_, err = calendarService.Events.Update("primary", event.Id, event).SupportsAttachments(true).Do()
+	_, err = calendarService.Events.Update(calendarOrPrimary(calendarID), event.Id, event).SupportsAttachments(true).Do()
 	if err != nil {
 		return fmt.Errorf("gMeetHelper: unable to attach file to event: %w", err)
 	}
 
 	return nil
 }
+
+// AttachmentInfo describes a file attached to an event.
+type AttachmentInfo struct {
+	FileID   string
+	Title    string
+	MimeType string
+	FileURL  string
+}
+
+// ListEventAttachments returns the files attached to an event.
+func ListEventAttachments(ctx context.Context, config auth.Config, calendarID, eventID string) ([]AttachmentInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create Calendar service: %w", err)
+	}
+
+	event, err := calendarService.Events.Get(calendarOrPrimary(calendarID), eventID).Fields("attachments").Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to retrieve event: %w", err)
+	}
+
+	attachments := make([]AttachmentInfo, 0, len(event.Attachments))
+	for _, attachment := range event.Attachments {
+		attachments = append(attachments, AttachmentInfo{
+			FileID:   attachment.FileId,
+			Title:    attachment.Title,
+			MimeType: attachment.MimeType,
+			FileURL:  attachment.FileUrl,
+		})
+	}
+	return attachments, nil
+}
+
+// RemoveEventAttachment detaches a Drive file from an event. It is not an error if the file is
+// not attached.
+func RemoveEventAttachment(ctx context.Context, config auth.Config, calendarID, eventID, fileID string) error {
+	return updateAttachments(ctx, config, calendarID, eventID, nil, func(current []*calendar.EventAttachment, _ map[string]*calendar.EventAttachment) []*calendar.EventAttachment {
+		var kept []*calendar.EventAttachment
+		for _, attachment := range current {
+			if attachment.FileId != fileID {
+				kept = append(kept, attachment)
+			}
+		}
+		return kept
+	})
+}
+
+// SetEventAttachments replaces the attachments of an event with the given Drive files, in order.
+// Files already attached are kept as they are and duplicates in fileIDs are ignored, so running
+// it again with the same list leaves the event unchanged. An empty list removes all attachments.
+func SetEventAttachments(ctx context.Context, config auth.Config, calendarID, eventID string, fileIDs []string) error {
+	return updateAttachments(ctx, config, calendarID, eventID, fileIDs, func(current []*calendar.EventAttachment, lookedUp map[string]*calendar.EventAttachment) []*calendar.EventAttachment {
+		existing := make(map[string]*calendar.EventAttachment)
+		for _, attachment := range current {
+			existing[attachment.FileId] = attachment
+		}
+
+		var attachments []*calendar.EventAttachment
+		seen := make(map[string]bool)
+		for _, fileID := range fileIDs {
+			if seen[fileID] {
+				continue
+			}
+			seen[fileID] = true
+			if attachment, ok := existing[fileID]; ok {
+				attachments = append(attachments, attachment)
+			} else {
+				attachments = append(attachments, lookedUp[fileID])
+			}
+		}
+		return attachments
+	})
+}
+
+// updateAttachments applies update to the attachments of an event and saves the result. Drive
+// metadata is looked up for every file in fileIDs that is not attached yet and passed to update.
+func updateAttachments(ctx context.Context, config auth.Config, calendarID, eventID string, fileIDs []string, update func([]*calendar.EventAttachment, map[string]*calendar.EventAttachment) []*calendar.EventAttachment) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to create Calendar service: %w", err)
+	}
+
+	event, err := calendarService.Events.Get(calendarOrPrimary(calendarID), eventID).Fields("attachments").Do()
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to retrieve event: %w", err)
+	}
+
+	attached := make(map[string]bool)
+	for _, attachment := range event.Attachments {
+		attached[attachment.FileId] = true
+	}
+
+	lookedUp := make(map[string]*calendar.EventAttachment)
+	var driveService *drive.Service
+	for _, fileID := range fileIDs {
+		if attached[fileID] || lookedUp[fileID] != nil {
+			continue
+		}
+		if driveService == nil {
+			driveService, err = drive.NewService(ctx, option.WithHTTPClient(client))
+			if err != nil {
+				return fmt.Errorf("gMeetHelper: unable to create Drive service: %w", err)
+			}
+		}
+		file, err := driveService.Files.Get(fileID).Fields("webViewLink", "name", "mimeType").SupportsAllDrives(true).Do()
+		if err != nil {
+			return fmt.Errorf("gMeetHelper: unable to retrieve file metadata for %s: %w", fileID, err)
+		}
+		lookedUp[fileID] = &calendar.EventAttachment{
+			FileId:   fileID,
+			FileUrl:  file.WebViewLink,
+			Title:    file.Name,
+			MimeType: file.MimeType,
+		}
+	}
+
+	patch := &calendar.Event{
+		Attachments:     update(event.Attachments, lookedUp),
+		ForceSendFields: []string{"Attachments"}, // send an empty list to remove every attachment
+	}
+	if len(patch.Attachments) > 25 {
+		return fmt.Errorf("gMeetHelper: an event can have at most 25 attachments, got %d", len(patch.Attachments))
+	}
+
+	_, err = calendarService.Events.Patch(calendarOrPrimary(calendarID), eventID, patch).SupportsAttachments(true).Do()
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to update event attachments: %w", err)
+	}
+	return nil
+}
+
+// EventUpdate holds the fields to change with UpdateCalendarEvent. Nil fields are left untouched.
+type EventUpdate struct {
+	CalendarID  string // calendar that holds the event, defaults to "primary"
+	Summary     *string
+	Description *string
+	Location    *string
+	StartTime   *time.Time
+	EndTime     *time.Time
+	TimeZone    string // time zone for StartTime and EndTime, keeps the event's time zone when empty
+	SendUpdates string // which guests to email, see SendUpdatesAll
+	Reminders   *ReminderSettings
+}
+
+// UpdateCalendarEvent patches an existing event, changing only the fields set in update. Giving an
+// all-day event a StartTime and an EndTime turns it into a timed event.
+func UpdateCalendarEvent(ctx context.Context, config auth.Config, eventID string, update EventUpdate) (*calendar.Event, error) {
+	if err := checkSendUpdates(update.SendUpdates); err != nil {
+		return nil, err
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	patch := &calendar.Event{}
+	if update.Summary != nil {
+		patch.Summary = *update.Summary
+		patch.ForceSendFields = append(patch.ForceSendFields, "Summary")
+	}
+	if update.Description != nil {
+		patch.Description = *update.Description
+		patch.ForceSendFields = append(patch.ForceSendFields, "Description")
+	}
+	if update.Location != nil {
+		patch.Location = *update.Location
+		patch.ForceSendFields = append(patch.ForceSendFields, "Location")
+	}
+
+	if update.StartTime != nil || update.EndTime != nil {
+		event, err := calendarService.Events.Get(calendarOrPrimary(update.CalendarID), eventID).Fields("start", "end").Do()
+		if err != nil {
+			return nil, fmt.Errorf("gMeetHelper: unable to retrieve event: %w", err)
+		}
+
+		// Keep the event's own time zones unless told otherwise, so a recurring event still
+		// follows their daylight saving rules
+		startZone, endZone := update.TimeZone, update.TimeZone
+		if update.TimeZone == "" && event.Start != nil {
+			startZone = event.Start.TimeZone
+		}
+		if update.TimeZone == "" && event.End != nil {
+			endZone = event.End.TimeZone
+		}
+
+		// An all-day event becomes a timed event, which needs both bounds and its dates cleared
+		allDay := event.Start != nil && event.Start.Date != ""
+		if allDay && (update.StartTime == nil || update.EndTime == nil) {
+			return nil, fmt.Errorf("gMeetHelper: both StartTime and EndTime are needed to schedule an all-day event at a time")
+		}
+
+		if update.StartTime != nil {
+			patch.Start, err = patchEventTime(*update.StartTime, startZone, allDay)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if update.EndTime != nil {
+			patch.End, err = patchEventTime(*update.EndTime, endZone, allDay)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if update.Reminders != nil {
+		patch.Reminders, err = toEventReminders(update.Reminders)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	call := calendarService.Events.Patch(calendarOrPrimary(update.CalendarID), eventID, patch)
+	if update.SendUpdates != "" {
+		call = call.SendUpdates(update.SendUpdates)
+	}
+
+	updatedEvent, err := call.Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to update calendar event: %w", err)
+	}
+	return updatedEvent, nil
+}
+
+// patchEventTime returns t as an event start or end in timeZone. Without a time zone the time is
+// sent with its UTC offset only. clearDate removes the date of an all-day event.
+func patchEventTime(t time.Time, timeZone string, clearDate bool) (*calendar.EventDateTime, error) {
+	eventTime := &calendar.EventDateTime{DateTime: t.Format(time.RFC3339)}
+	if timeZone != "" {
+		loc, err := time.LoadLocation(timeZone)
+		if err != nil {
+			return nil, fmt.Errorf("gMeetHelper: unable to load timezone %s: %w", timeZone, err)
+		}
+		eventTime = &calendar.EventDateTime{DateTime: t.In(loc).Format(time.RFC3339), TimeZone: timeZone}
+	}
+	if clearDate {
+		eventTime.NullFields = []string{"Date"}
+	}
+	return eventTime, nil
+}
+
+// DeleteCalendarEvent deletes (cancels) an event. sendUpdates is one of "all", "externalOnly" or "none".
+// Passing the ID of a single instance of a recurring event cancels only that instance.
+func DeleteCalendarEvent(ctx context.Context, config auth.Config, calendarID, eventID, sendUpdates string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	if err := checkSendUpdates(sendUpdates); err != nil {
+		return err
+	}
+
+	call := calendarService.Events.Delete(calendarOrPrimary(calendarID), eventID)
+	if sendUpdates != "" {
+		call = call.SendUpdates(sendUpdates)
+	}
+
+	err = call.Do()
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to delete calendar event: %w", err)
+	}
+	return nil
+}
+
+// CancelEventInstance cancels the single instance of a recurring event that was originally
+// scheduled to start at originalStart.
+func CancelEventInstance(ctx context.Context, config auth.Config, calendarID, recurringEventID string, originalStart time.Time, sendUpdates string) error {
+	if err := checkSendUpdates(sendUpdates); err != nil {
+		return err
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	instances, err := calendarService.Events.Instances(calendarOrPrimary(calendarID), recurringEventID).
+		OriginalStart(originalStart.Format(time.RFC3339)).
+		Do()
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to retrieve event instances: %w", err)
+	}
+	if len(instances.Items) == 0 {
+		return fmt.Errorf("gMeetHelper: no instance of event %s starts at %s", recurringEventID, originalStart.Format(time.RFC3339))
+	}
+
+	call := calendarService.Events.Delete(calendarOrPrimary(calendarID), instances.Items[0].Id)
+	if sendUpdates != "" {
+		call = call.SendUpdates(sendUpdates)
+	}
+
+	err = call.Do()
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to cancel event instance: %w", err)
+	}
+	return nil
+}
+
+// DuplicateEvent creates a copy of an event starting at newStart, keeping its duration, details,
+// attendees, reminders and attachments. Recurrence is not copied. If the original has a Google
+// Meet conference, the copy gets a new one of its own.
+func DuplicateEvent(ctx context.Context, config auth.Config, calendarID, eventID string, newStart time.Time) (*calendar.Event, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	original, err := calendarService.Events.Get(calendarOrPrimary(calendarID), eventID).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to retrieve event: %w", err)
+	}
+
+	duplicate := &calendar.Event{
+		Summary:                 original.Summary,
+		Description:             original.Description,
+		Location:                original.Location,
+		ColorId:                 original.ColorId,
+		Transparency:            original.Transparency,
+		Visibility:              original.Visibility,
+		Reminders:               original.Reminders,
+		Attachments:             original.Attachments,
+		GuestsCanInviteOthers:   original.GuestsCanInviteOthers,
+		GuestsCanModify:         original.GuestsCanModify,
+		GuestsCanSeeOtherGuests: original.GuestsCanSeeOtherGuests,
+	}
+	for _, attendee := range original.Attendees {
+		duplicate.Attendees = append(duplicate.Attendees, &calendar.EventAttendee{
+			Email:    attendee.Email,
+			Optional: attendee.Optional,
+			Resource: attendee.Resource,
+		})
+	}
+
+	start, allDay := parseEventDateTime(original.Start)
+	end, _ := parseEventDateTime(original.End)
+	if allDay {
+		days := int(end.Sub(start).Hours()+12) / 24 // tolerate DST shifts
+		duplicate.Start = &calendar.EventDateTime{Date: newStart.Format("2006-01-02"), TimeZone: original.Start.TimeZone}
+		duplicate.End = &calendar.EventDateTime{Date: newStart.AddDate(0, 0, days).Format("2006-01-02"), TimeZone: original.End.TimeZone}
+	} else {
+		duplicate.Start = &calendar.EventDateTime{DateTime: newStart.Format(time.RFC3339), TimeZone: original.Start.TimeZone}
+		duplicate.End = &calendar.EventDateTime{DateTime: newStart.Add(end.Sub(start)).Format(time.RFC3339), TimeZone: original.End.TimeZone}
+	}
+
+	if original.ConferenceData != nil && original.ConferenceData.ConferenceSolution != nil &&
+		original.ConferenceData.ConferenceSolution.Key != nil &&
+		original.ConferenceData.ConferenceSolution.Key.Type == "hangoutsMeet" {
+		duplicate.ConferenceData = &calendar.ConferenceData{
+			CreateRequest: &calendar.CreateConferenceRequest{
+				RequestId:             uuid.NewString(),
+				ConferenceSolutionKey: &calendar.ConferenceSolutionKey{Type: "hangoutsMeet"},
+			},
+		}
+	}
+
+	created, err := calendarService.Events.Insert(calendarOrPrimary(calendarID), duplicate).
+		ConferenceDataVersion(1).SupportsAttachments(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to duplicate event: %w", err)
+	}
+	return created, nil
+}
+
+// EventTemplate is a preset for a kind of meeting that is scheduled over and over, such as a
+// weekly 1:1 or an incident review. Summary and Description may contain {{name}} placeholders
+// that are filled in by CreateEventFromTemplate.
+type EventTemplate struct {
+	Summary     string
+	Description string
+	Location    string
+	Duration    time.Duration
+	Attendees   []string
+	Optional    []string // attendees invited as optional
+
+	// Conference adds a Google Meet conference to every event created from the template.
+	Conference bool
+
+	// Options holds the calendar, time zone and reminders of the events. AllDay is not supported.
+	Options EventOptions
+}
+
+// CreateEventFromTemplate creates an event from tmpl starting at startTime. Every {{name}} in
+// the template's summary and description is replaced with vars[name].
+func CreateEventFromTemplate(ctx context.Context, config auth.Config, tmpl EventTemplate, startTime time.Time, vars map[string]string) (*calendar.Event, error) {
+	if tmpl.Duration <= 0 {
+		return nil, fmt.Errorf("gMeetHelper: template duration must be positive")
+	}
+	if tmpl.Options.AllDay {
+		return nil, fmt.Errorf("gMeetHelper: all-day templates are not supported")
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	timeZone, err := resolveTimeZone(calendarService, tmpl.Options.CalendarID, tmpl.Options.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := expandTemplate(tmpl.Summary, vars)
+	description := expandTemplate(tmpl.Description, vars)
+	event, err := newEvent(summary, tmpl.Location, description, startTime, startTime.Add(tmpl.Duration), timeZone, tmpl.Options)
+	if err != nil {
+		return nil, err
+	}
+	if !tmpl.Conference {
+		event.ConferenceData = nil
+	}
+	event.Attendees = mergeAttendees(nil, tmpl.Attendees, false)
+	event.Attendees = mergeAttendees(event.Attendees, tmpl.Optional, true)
+
+	call := calendarService.Events.Insert(calendarOrPrimary(tmpl.Options.CalendarID), event).ConferenceDataVersion(1)
+	if tmpl.Options.SendUpdates != "" {
+		call = call.SendUpdates(tmpl.Options.SendUpdates)
+	}
+
+	created, err := call.Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar event: %w", err)
+	}
+	return created, nil
+}
+
+// expandTemplate replaces every {{name}} in text with vars[name].
+func expandTemplate(text string, vars map[string]string) string {
+	for name, value := range vars {
+		text = strings.ReplaceAll(text, "{{"+name+"}}", value)
+	}
+	return text
+}
+
+// EventInfo is a simplified view of a calendar event.
+type EventInfo struct {
+	ID               string
+	Summary          string
+	Description      string
+	Location         string
+	Start            time.Time
+	End              time.Time
+	AllDay           bool
+	Status           string // "confirmed", "tentative" or "cancelled"
+	Transparency     string // "opaque" (busy) or "transparent" (free)
+	Organizer        string
+	Attendees        []string
+	HTMLLink         string
+	MeetLink         string
+	RecurringEventID string
+}
+
+// ListEventsOptions holds optional filters for ListEvents.
+type ListEventsOptions struct {
+	Query        string // free text search in summary, description, location and attendees
+	SingleEvents bool   // expand recurring events into their instances
+	OrderBy      string // "startTime" (requires SingleEvents) or "updated"
+	PageSize     int64  // events fetched per request, defaults to the API default
+	ShowDeleted  bool   // include cancelled events
+}
+
+// EventIterator iterates over the events returned by ListEvents, fetching pages on demand.
+type EventIterator struct {
+	ctx       context.Context
+	call      *calendar.EventsListCall
+	buffer    []EventInfo
+	pageToken string
+	done      bool
+}
+
+// Next returns the next event, or iterator.Done when there are no more events.
+func (it *EventIterator) Next() (EventInfo, error) {
+	for len(it.buffer) == 0 {
+		if it.done {
+			return EventInfo{}, iterator.Done
+		}
+
+		events, err := it.call.PageToken(it.pageToken).Context(it.ctx).Do()
+		if err != nil {
+			return EventInfo{}, fmt.Errorf("gMeetHelper: unable to list events: %w", err)
+		}
+		for _, event := range events.Items {
+			it.buffer = append(it.buffer, toEventInfo(event))
+		}
+		it.pageToken = events.NextPageToken
+		it.done = it.pageToken == ""
+	}
+
+	event := it.buffer[0]
+	it.buffer = it.buffer[1:]
+	return event, nil
+}
+
+// All drains the iterator and returns the remaining events.
+func (it *EventIterator) All() ([]EventInfo, error) {
+	var events []EventInfo
+	for {
+		event, err := it.Next()
+		if err == iterator.Done {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, event)
+	}
+}
+
+// ListEvents lists the events of a calendar between timeMin and timeMax. A zero time leaves
+// that bound open.
+func ListEvents(ctx context.Context, config auth.Config, calendarID string, timeMin, timeMax time.Time, opts ListEventsOptions) (*EventIterator, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	if opts.OrderBy == "startTime" && !opts.SingleEvents {
+		return nil, fmt.Errorf("gMeetHelper: ordering by startTime requires SingleEvents")
+	}
+
+	call := calendarService.Events.List(calendarOrPrimary(calendarID)).SingleEvents(opts.SingleEvents).ShowDeleted(opts.ShowDeleted)
+	if !timeMin.IsZero() {
+		call = call.TimeMin(timeMin.Format(time.RFC3339))
+	}
+	if !timeMax.IsZero() {
+		call = call.TimeMax(timeMax.Format(time.RFC3339))
+	}
+	if opts.Query != "" {
+		call = call.Q(opts.Query)
+	}
+	if opts.OrderBy != "" {
+		call = call.OrderBy(opts.OrderBy)
+	}
+	if opts.PageSize > 0 {
+		call = call.MaxResults(opts.PageSize)
+	}
+
+	return &EventIterator{ctx: ctx, call: call}, nil
+}
+
+// toEventInfo converts a calendar event into an EventInfo.
+func toEventInfo(event *calendar.Event) EventInfo {
+	info := EventInfo{
+		ID:               event.Id,
+		Summary:          event.Summary,
+		Description:      event.Description,
+		Location:         event.Location,
+		Status:           event.Status,
+		Transparency:     event.Transparency,
+		HTMLLink:         event.HtmlLink,
+		MeetLink:         GetConferenceInfo(event).MeetLink,
+		RecurringEventID: event.RecurringEventId,
+	}
+	if event.Organizer != nil {
+		info.Organizer = event.Organizer.Email
+	}
+	for _, attendee := range event.Attendees {
+		info.Attendees = append(info.Attendees, attendee.Email)
+	}
+	info.Start, info.AllDay = parseEventDateTime(event.Start)
+	info.End, _ = parseEventDateTime(event.End)
+	return info
+}
+
+// parseEventDateTime converts an EventDateTime into a time.Time, reporting whether it is an all-day date.
+func parseEventDateTime(eventTime *calendar.EventDateTime) (time.Time, bool) {
+	if eventTime == nil {
+		return time.Time{}, false
+	}
+	if eventTime.DateTime != "" {
+		t, _ := time.Parse(time.RFC3339, eventTime.DateTime)
+		return t, false
+	}
+
+	loc := time.UTC
+	if eventTime.TimeZone != "" {
+		if l, err := time.LoadLocation(eventTime.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	t, _ := time.ParseInLocation("2006-01-02", eventTime.Date, loc)
+	return t, true
+}
+
+// CalendarInfo is a simplified view of an entry in the user's calendar list.
+type CalendarInfo struct {
+	ID          string
+	Summary     string
+	Description string
+	TimeZone    string
+	AccessRole  string // "owner", "writer", "reader" or "freeBusyReader"
+	Primary     bool
+}
+
+// CreateCalendar creates a secondary calendar owned by the authenticated user.
+// An empty timeZone uses the user's default.
+func CreateCalendar(ctx context.Context, config auth.Config, summary, description, timeZone string) (*calendar.Calendar, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	cal := &calendar.Calendar{
+		Summary:     summary,
+		Description: description,
+		TimeZone:    timeZone,
+	}
+
+	createdCalendar, err := calendarService.Calendars.Insert(cal).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar: %w", err)
+	}
+	return createdCalendar, nil
+}
+
+// ListCalendars lists the calendars in the authenticated user's calendar list.
+func ListCalendars(ctx context.Context, config auth.Config) ([]CalendarInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	var calendars []CalendarInfo
+	err = calendarService.CalendarList.List().Pages(ctx, func(page *calendar.CalendarList) error {
+		for _, entry := range page.Items {
+			calendars = append(calendars, CalendarInfo{
+				ID:          entry.Id,
+				Summary:     entry.Summary,
+				Description: entry.Description,
+				TimeZone:    entry.TimeZone,
+				AccessRole:  entry.AccessRole,
+				Primary:     entry.Primary,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to list calendars: %w", err)
+	}
+	return calendars, nil
+}
+
+// UpdateCalendar changes the summary, description and time zone of a calendar.
+// Empty arguments are left untouched.
+func UpdateCalendar(ctx context.Context, config auth.Config, calendarID, summary, description, timeZone string) (*calendar.Calendar, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	cal := &calendar.Calendar{
+		Summary:     summary,
+		Description: description,
+		TimeZone:    timeZone,
+	}
+
+	updatedCalendar, err := calendarService.Calendars.Patch(calendarID, cal).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to update calendar: %w", err)
+	}
+	return updatedCalendar, nil
+}
+
+// DeleteCalendar permanently deletes a secondary calendar and all its events.
+func DeleteCalendar(ctx context.Context, config auth.Config, calendarID string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	err = calendarService.Calendars.Delete(calendarID).Do()
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to delete calendar: %w", err)
+	}
+	return nil
+}
+
+// SubscribeCalendar adds an existing calendar to the authenticated user's calendar list.
+func SubscribeCalendar(ctx context.Context, config auth.Config, calendarID string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	_, err = calendarService.CalendarList.Insert(&calendar.CalendarListEntry{Id: calendarID}).Do()
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to subscribe to calendar: %w", err)
+	}
+	return nil
+}
+
+// UnsubscribeCalendar removes a calendar from the authenticated user's calendar list without deleting it.
+func UnsubscribeCalendar(ctx context.Context, config auth.Config, calendarID string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	err = calendarService.CalendarList.Delete(calendarID).Do()
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to unsubscribe from calendar: %w", err)
+	}
+	return nil
+}
+
+// CalendarSettings holds the Calendar preferences of the authenticated user.
+type CalendarSettings struct {
+	TimeZone           string // IANA time zone name, e.g., "Asia/Tokyo"
+	Location           *time.Location
+	WeekStart          time.Weekday
+	Locale             string // e.g., "en" or "ja"
+	Format24HourTime   bool
+	DefaultEventLength time.Duration
+	HideWeekends       bool
+}
+
+// GetCalendarSettings returns the Calendar settings of the authenticated user, so that times can be
+// expressed in the user's own time zone instead of the machine's.
+func GetCalendarSettings(ctx context.Context, config auth.Config) (*CalendarSettings, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	values := make(map[string]string)
+	err = calendarService.Settings.List().Pages(ctx, func(page *calendar.Settings) error {
+		for _, setting := range page.Items {
+			values[setting.Id] = setting.Value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to retrieve calendar settings: %w", err)
+	}
+
+	settings := &CalendarSettings{
+		TimeZone:         values["timezone"],
+		Location:         time.Local,
+		Locale:           values["locale"],
+		Format24HourTime: values["format24HourTime"] == "true",
+		HideWeekends:     values["hideWeekends"] == "true",
+	}
+	if settings.TimeZone != "" {
+		settings.Location, err = time.LoadLocation(settings.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("gMeetHelper: unable to load timezone %s: %w", settings.TimeZone, err)
+		}
+	}
+	// weekStart is "0" for Sunday, "1" for Monday and "6" for Saturday
+	if weekStart, err := strconv.Atoi(values["weekStart"]); err == nil && weekStart >= 0 && weekStart <= 6 {
+		settings.WeekStart = time.Weekday(weekStart)
+	}
+	if minutes, err := strconv.Atoi(values["defaultEventLength"]); err == nil {
+		settings.DefaultEventLength = time.Duration(minutes) * time.Minute
+	}
+	return settings, nil
+}
+
+// WorkingHours returns working hours from start to end in the user's time zone, for use with
+// FindMeetingSlot. Weekends are excluded.
+func (s *CalendarSettings) WorkingHours(start, end time.Duration) WorkingHours {
+	return WorkingHours{Start: start, End: end, Location: s.Location}
+}
+
+// Interval is a span of time between Start and End.
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// FreeBusy holds the busy intervals of one attendee. Err is set when the attendee's availability
+// could not be read, e.g., because their calendar is not shared.
+type FreeBusy struct {
+	Busy []Interval
+	Err  error
+}
+
+// GetFreeBusy returns the busy intervals of each email address between timeMin and timeMax.
+func GetFreeBusy(ctx context.Context, config auth.Config, emails []string, timeMin, timeMax time.Time) (map[string]FreeBusy, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	request := &calendar.FreeBusyRequest{
+		TimeMin: timeMin.Format(time.RFC3339),
+		TimeMax: timeMax.Format(time.RFC3339),
+	}
+	for _, email := range emails {
+		request.Items = append(request.Items, &calendar.FreeBusyRequestItem{Id: email})
+	}
+
+	response, err := calendarService.Freebusy.Query(request).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to query free/busy information: %w", err)
+	}
+
+	result := make(map[string]FreeBusy, len(emails))
+	for _, email := range emails {
+		cal, ok := response.Calendars[email]
+		if !ok {
+			result[email] = FreeBusy{Err: fmt.Errorf("gMeetHelper: no free/busy information returned for %s", email)}
+			continue
+		}
+
+		var freeBusy FreeBusy
+		if len(cal.Errors) > 0 {
+			reasons := make([]string, 0, len(cal.Errors))
+			for _, e := range cal.Errors {
+				reasons = append(reasons, e.Reason)
+			}
+			freeBusy.Err = fmt.Errorf("gMeetHelper: unable to read free/busy information for %s: %s", email, strings.Join(reasons, ", "))
+		}
+		for _, period := range cal.Busy {
+			start, err := time.Parse(time.RFC3339, period.Start)
+			if err != nil {
+				continue
+			}
+			end, err := time.Parse(time.RFC3339, period.End)
+			if err != nil {
+				continue
+			}
+			freeBusy.Busy = append(freeBusy.Busy, Interval{Start: start, End: end})
+		}
+		result[email] = freeBusy
+	}
+	return result, nil
+}
+
+// WorkingHours restricts meeting slots to a daily time range.
+type WorkingHours struct {
+	Start    time.Duration  // offset from midnight, e.g., 9 * time.Hour
+	End      time.Duration  // offset from midnight, e.g., 18 * time.Hour
+	Location *time.Location // time zone of the working hours, defaults to time.Local
+	Weekdays []time.Weekday // working days, defaults to Monday through Friday
+}
+
+// slotStep is the granularity of the candidate start times returned by FindMeetingSlot.
+const slotStep = 15 * time.Minute
+
+// FindMeetingSlot returns the start times within window, aligned to 15 minutes, at which every
+// attendee is free for the whole duration during working hours.
+func FindMeetingSlot(ctx context.Context, config auth.Config, attendees []string, duration time.Duration, window Interval, workingHours WorkingHours) ([]time.Time, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("gMeetHelper: meeting duration must be positive")
+	}
+
+	freeBusy, err := GetFreeBusy(ctx, config, attendees, window.Start, window.End)
+	if err != nil {
+		return nil, err
+	}
+
+	var busy []Interval
+	var errs []error
+	for _, email := range attendees {
+		if freeBusy[email].Err != nil {
+			errs = append(errs, freeBusy[email].Err)
+		}
+		busy = append(busy, freeBusy[email].Busy...)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return findFreeSlots(busy, duration, window, workingHours), nil
+}
+
+// findFreeSlots returns the candidate start times that do not overlap any busy interval.
+func findFreeSlots(busy []Interval, duration time.Duration, window Interval, workingHours WorkingHours) []time.Time {
+	loc := workingHours.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	weekdays := workingHours.Weekdays
+	if len(weekdays) == 0 {
+		weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	}
+	isWorkday := map[time.Weekday]bool{}
+	for _, day := range weekdays {
+		isWorkday[day] = true
+	}
+
+	sort.Slice(busy, func(i, j int) bool { return busy[i].Start.Before(busy[j].Start) })
+
+	var slots []time.Time
+	windowStart := window.Start.In(loc)
+	for day := time.Date(windowStart.Year(), windowStart.Month(), windowStart.Day(), 0, 0, 0, 0, loc); day.Before(window.End); day = day.AddDate(0, 0, 1) {
+		if !isWorkday[day.Weekday()] {
+			continue
+		}
+
+		// Build the bounds from the wall clock, as adding durations to midnight is off by the
+		// daylight saving shift on the days it changes
+		dayStart := wallClock(day, workingHours.Start)
+		dayEnd := wallClock(day, workingHours.End)
+		if dayStart.Before(window.Start) {
+			dayStart = window.Start
+		}
+		if dayEnd.After(window.End) {
+			dayEnd = window.End
+		}
+
+		// Align the first candidate to the slot grid
+		candidate := dayStart.Truncate(slotStep)
+		if candidate.Before(dayStart) {
+			candidate = candidate.Add(slotStep)
+		}
+
+		for ; !candidate.Add(duration).After(dayEnd); candidate = candidate.Add(slotStep) {
+			if isFree(busy, candidate, candidate.Add(duration)) {
+				slots = append(slots, candidate)
+			}
+		}
+	}
+	return slots
+}
+
+// wallClock returns the time of day on the date of day, in its location, that is offset past
+// midnight on a clock on the wall.
+func wallClock(day time.Time, offset time.Duration) time.Time {
+	hour, minute, second := int(offset/time.Hour), int(offset%time.Hour/time.Minute), int(offset%time.Minute/time.Second)
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, second, 0, day.Location())
+}
+
+// isFree reports whether [start, end) does not overlap any of the sorted busy intervals.
+func isFree(busy []Interval, start, end time.Time) bool {
+	for _, interval := range busy {
+		if !interval.Start.Before(end) {
+			break
+		}
+		if interval.End.After(start) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckConflicts returns the events of a calendar that overlap [start, end), in start order.
+// Events marked as free (transparent) and cancelled events are not conflicts.
+func CheckConflicts(ctx context.Context, config auth.Config, calendarID string, start, end time.Time) ([]EventInfo, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("gMeetHelper: conflict check end must be after start")
+	}
+
+	it, err := ListEvents(ctx, config, calendarID, start, end, ListEventsOptions{SingleEvents: true, OrderBy: "startTime"})
+	if err != nil {
+		return nil, err
+	}
+	events, err := it.All()
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []EventInfo
+	for _, event := range events {
+		if event.Transparency == "transparent" || event.Status == "cancelled" {
+			continue
+		}
+		// timeMin and timeMax already select overlapping events; this guards against events
+		// that merely touch the range
+		if event.Start.Before(end) && event.End.After(start) {
+			conflicts = append(conflicts, event)
+		}
+	}
+	return conflicts, nil
+}
+
+// RespondToEvent sets the authenticated user's response ("accepted", "declined" or "tentative")
+// on an event they were invited to, optionally with a comment.
+func RespondToEvent(ctx context.Context, config auth.Config, calendarID, eventID, responseStatus, comment string) error {
+	switch responseStatus {
+	case "accepted", "declined", "tentative":
+	default:
+		return fmt.Errorf("gMeetHelper: unsupported response status %q", responseStatus)
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	event, err := calendarService.Events.Get(calendarOrPrimary(calendarID), eventID).Do()
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to retrieve event: %w", err)
+	}
+
+	found := false
+	for _, attendee := range event.Attendees {
+		if attendee.Self {
+			attendee.ResponseStatus = responseStatus
+			attendee.Comment = comment
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("gMeetHelper: authenticated user is not an attendee of event %s", eventID)
+	}
+
+	patch := &calendar.Event{Attendees: event.Attendees}
+	_, err = calendarService.Events.Patch(calendarOrPrimary(calendarID), eventID, patch).Do()
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to respond to event: %w", err)
+	}
+	return nil
+}
+
+// Auto-decline modes for out-of-office and focus time events.
+const (
+	DeclineNone                          = "declineNone"
+	DeclineAllConflictingInvitations     = "declineAllConflictingInvitations"
+	DeclineOnlyNewConflictingInvitations = "declineOnlyNewConflictingInvitations"
+)
+
+// AutoDeclineSettings controls how invitations that conflict with a special event are handled.
+type AutoDeclineSettings struct {
+	Mode    string // one of the Decline* constants, defaults to DeclineNone
+	Message string // response sent with declined invitations
+}
+
+// CreateOutOfOfficeEvent creates an out-of-office event on the authenticated user's primary calendar.
+func CreateOutOfOfficeEvent(ctx context.Context, config auth.Config, summary string, startTime, endTime time.Time, autoDecline AutoDeclineSettings) (*calendar.Event, error) {
+	event := &calendar.Event{
+		EventType:    "outOfOffice",
+		Summary:      summary,
+		Transparency: "opaque",
+		OutOfOfficeProperties: &calendar.EventOutOfOfficeProperties{
+			AutoDeclineMode: autoDeclineModeOrNone(autoDecline.Mode),
+			DeclineMessage:  autoDecline.Message,
+		},
+	}
+	return insertSpecialEvent(ctx, config, event, startTime, endTime)
+}
+
+// CreateFocusTimeEvent creates a focus time event on the authenticated user's primary calendar.
+// chatStatus is "doNotDisturb" or "available".
+func CreateFocusTimeEvent(ctx context.Context, config auth.Config, summary string, startTime, endTime time.Time, autoDecline AutoDeclineSettings, chatStatus string) (*calendar.Event, error) {
+	event := &calendar.Event{
+		EventType:    "focusTime",
+		Summary:      summary,
+		Transparency: "opaque",
+		FocusTimeProperties: &calendar.EventFocusTimeProperties{
+			AutoDeclineMode: autoDeclineModeOrNone(autoDecline.Mode),
+			DeclineMessage:  autoDecline.Message,
+			ChatStatus:      chatStatus,
+		},
+	}
+	return insertSpecialEvent(ctx, config, event, startTime, endTime)
+}
+
+// autoDeclineModeOrNone returns mode, or DeclineNone when it is empty.
+func autoDeclineModeOrNone(mode string) string {
+	if mode == "" {
+		return DeclineNone
+	}
+	return mode
+}
+
+// insertSpecialEvent inserts an out-of-office or focus time event, which are only allowed on the
+// primary calendar and must be timed events.
+func insertSpecialEvent(ctx context.Context, config auth.Config, event *calendar.Event, startTime, endTime time.Time) (*calendar.Event, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	event.Start = &calendar.EventDateTime{DateTime: startTime.Format(time.RFC3339)}
+	event.End = &calendar.EventDateTime{DateTime: endTime.Format(time.RFC3339)}
+
+	createdEvent, err := calendarService.Events.Insert("primary", event).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create %s event: %w", event.EventType, err)
+	}
+	return createdEvent, nil
+}
+
+// ConferenceRecordFilter narrows the conference records returned by ListConferenceRecords.
+// Empty fields are ignored.
+type ConferenceRecordFilter struct {
+	SpaceName   string // e.g., "spaces/jQCFfuBOdN5z"
+	MeetingCode string // e.g., "abc-mnop-xyz"
+	StartAfter  time.Time
+	StartBefore time.Time
+}
+
+// ConferenceRecordInfo is a simplified view of a Meet conference record.
+type ConferenceRecordInfo struct {
+	Name             string // e.g., "conferenceRecords/abc-123"
+	SpaceName        string
+	StartTime        time.Time
+	EndTime          time.Time // zero while the conference is ongoing
+	ExpireTime       time.Time
+	ParticipantCount int // only set when requested
+}
+
+// ListConferenceRecords lists the Meet conference records matching filter, newest first.
+// When countParticipants is true, the number of participants of each conference is fetched too.
+func ListConferenceRecords(ctx context.Context, config auth.Config, filter ConferenceRecordFilter, countParticipants bool) ([]ConferenceRecordInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	meetService, err := meet.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create meet service: %w", err)
+	}
+
+	var clauses []string
+	if filter.SpaceName != "" {
+		clauses = append(clauses, fmt.Sprintf("space.name = %q", filter.SpaceName))
+	}
+	if filter.MeetingCode != "" {
+		clauses = append(clauses, fmt.Sprintf("space.meeting_code = %q", filter.MeetingCode))
+	}
+	if !filter.StartAfter.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("start_time >= %q", filter.StartAfter.UTC().Format(time.RFC3339)))
+	}
+	if !filter.StartBefore.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("start_time <= %q", filter.StartBefore.UTC().Format(time.RFC3339)))
+	}
+
+	call := meetService.ConferenceRecords.List()
+	if len(clauses) > 0 {
+		call = call.Filter(strings.Join(clauses, " AND "))
+	}
+
+	var records []ConferenceRecordInfo
+	err = call.Pages(ctx, func(page *meet.ListConferenceRecordsResponse) error {
+		for _, record := range page.ConferenceRecords {
+			info := ConferenceRecordInfo{
+				Name:      record.Name,
+				SpaceName: record.Space,
+			}
+			info.StartTime, _ = time.Parse(time.RFC3339, record.StartTime)
+			info.EndTime, _ = time.Parse(time.RFC3339, record.EndTime)
+			info.ExpireTime, _ = time.Parse(time.RFC3339, record.ExpireTime)
+			records = append(records, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to list conference records: %w", err)
+	}
+
+	if countParticipants {
+		for i := range records {
+			count := 0
+			err := meetService.ConferenceRecords.Participants.List(records[i].Name).Pages(ctx, func(page *meet.ListParticipantsResponse) error {
+				count += len(page.Participants)
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("gMeetHelper: unable to list participants: %w", err)
+			}
+			records[i].ParticipantCount = count
+		}
+	}
+	return records, nil
+}
+
+// RecordingInfo is a simplified view of a Meet recording.
+type RecordingInfo struct {
+	Name        string // e.g., "conferenceRecords/abc-123/recordings/def-456"
+	State       string // "STARTED", "ENDED" or "FILE_GENERATED"
+	DriveFileID string // set once the file is generated
+	ExportURI   string
+	StartTime   time.Time
+	EndTime     time.Time
+}
+
+// ListRecordings lists the recordings of a conference record.
+func ListRecordings(ctx context.Context, config auth.Config, conferenceRecordName string) ([]RecordingInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	meetService, err := meet.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create meet service: %w", err)
+	}
+
+	var recordings []RecordingInfo
+	err = meetService.ConferenceRecords.Recordings.List(conferenceRecordName).Pages(ctx, func(page *meet.ListRecordingsResponse) error {
+		for _, recording := range page.Recordings {
+			info := RecordingInfo{
+				Name:  recording.Name,
+				State: recording.State,
+			}
+			if recording.DriveDestination != nil {
+				info.DriveFileID = recording.DriveDestination.File
+				info.ExportURI = recording.DriveDestination.ExportUri
+			}
+			info.StartTime, _ = time.Parse(time.RFC3339, recording.StartTime)
+			info.EndTime, _ = time.Parse(time.RFC3339, recording.EndTime)
+			recordings = append(recordings, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to list recordings: %w", err)
+	}
+	return recordings, nil
+}
+
+// DownloadRecordings downloads every generated recording of a conference record into localDir and
+// returns the paths of the written files. Recordings that are still being processed are skipped.
+func DownloadRecordings(ctx context.Context, config auth.Config, conferenceRecordName, localDir string) ([]string, error) {
+	recordings, err := ListRecordings(ctx, config, conferenceRecordName)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create Drive service: %w", err)
+	}
+
+	var paths []string
+	for _, recording := range recordings {
+		if recording.State != "FILE_GENERATED" || recording.DriveFileID == "" {
+			continue
+		}
+
+		file, err := driveService.Files.Get(recording.DriveFileID).Fields("name").SupportsAllDrives(true).Do()
+		if err != nil {
+			return paths, fmt.Errorf("gMeetHelper: unable to retrieve recording metadata: %w", err)
+		}
+
+		path := filepath.Join(localDir, filepath.Base(file.Name))
+		if err := downloadToFile(ctx, config, recording.DriveFileID, path); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// downloadToFile downloads a Drive file to a local path, verifying its checksum.
+func downloadToFile(ctx context.Context, config auth.Config, fileID, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to create local file: %w", err)
+	}
+
+	err = gDriveHelper.DownloadFile(ctx, config, fileID, f, true)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("gMeetHelper: unable to write local file: %w", closeErr)
+	}
+	return err
+}
+
+// CopyRecordingsToFolder copies every generated recording of a conference record into a Drive folder
+// and returns the copies.
+func CopyRecordingsToFolder(ctx context.Context, config auth.Config, conferenceRecordName, folderID string) ([]*drive.File, error) {
+	recordings, err := ListRecordings(ctx, config, conferenceRecordName)
+	if err != nil {
+		return nil, err
+	}
+
+	var copies []*drive.File
+	for _, recording := range recordings {
+		if recording.State != "FILE_GENERATED" || recording.DriveFileID == "" {
+			continue
+		}
+
+		copied, err := gDriveHelper.CopyFileToFolder(ctx, config, recording.DriveFileID, folderID)
+		if err != nil {
+			return copies, err
+		}
+		copies = append(copies, copied)
+	}
+	return copies, nil
+}
+
+// TranscriptEntryInfo is a single spoken entry of a Meet transcript.
+type TranscriptEntryInfo struct {
+	Speaker      string // display name of the participant
+	StartTime    time.Time
+	EndTime      time.Time
+	LanguageCode string
+	Text         string
+}
+
+// ListTranscriptEntries returns the entries of every transcript of a conference record in order.
+func ListTranscriptEntries(ctx context.Context, config auth.Config, conferenceRecordName string) ([]TranscriptEntryInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	meetService, err := meet.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create meet service: %w", err)
+	}
+
+	var transcripts []*meet.Transcript
+	err = meetService.ConferenceRecords.Transcripts.List(conferenceRecordName).Pages(ctx, func(page *meet.ListTranscriptsResponse) error {
+		transcripts = append(transcripts, page.Transcripts...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to list transcripts: %w", err)
+	}
+
+	// Participants speak many times, so resolve each name only once
+	speakers := map[string]string{}
+	speakerName := func(participantName string) (string, error) {
+		if name, ok := speakers[participantName]; ok {
+			return name, nil
+		}
+		participant, err := meetService.ConferenceRecords.Participants.Get(participantName).Do()
+		if err != nil {
+			return "", fmt.Errorf("gMeetHelper: unable to retrieve participant: %w", err)
+		}
+		name := participantName
+		switch {
+		case participant.SignedinUser != nil:
+			name = participant.SignedinUser.DisplayName
+		case participant.AnonymousUser != nil:
+			name = participant.AnonymousUser.DisplayName
+		case participant.PhoneUser != nil:
+			name = participant.PhoneUser.DisplayName
+		}
+		speakers[participantName] = name
+		return name, nil
+	}
+
+	var entries []TranscriptEntryInfo
+	for _, transcript := range transcripts {
+		var rawEntries []*meet.TranscriptEntry
+		err := meetService.ConferenceRecords.Transcripts.Entries.List(transcript.Name).Pages(ctx, func(page *meet.ListTranscriptEntriesResponse) error {
+			rawEntries = append(rawEntries, page.TranscriptEntries...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("gMeetHelper: unable to list transcript entries: %w", err)
+		}
+
+		for _, rawEntry := range rawEntries {
+			speaker, err := speakerName(rawEntry.Participant)
+			if err != nil {
+				return nil, err
+			}
+			entry := TranscriptEntryInfo{
+				Speaker:      speaker,
+				LanguageCode: rawEntry.LanguageCode,
+				Text:         rawEntry.Text,
+			}
+			entry.StartTime, _ = time.Parse(time.RFC3339, rawEntry.StartTime)
+			entry.EndTime, _ = time.Parse(time.RFC3339, rawEntry.EndTime)
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// ExportTranscriptToDoc writes the transcript of a conference record into a new Google Doc with one
+// "[time] speaker: text" line per entry. When eventID is non-empty the document is attached to
+// that event. It returns the ID of the new document.
+func ExportTranscriptToDoc(ctx context.Context, config auth.Config, conferenceRecordName, title, calendarID, eventID string) (string, error) {
+	entries, err := ListTranscriptEntries(ctx, config, conferenceRecordName)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&builder, "[%s] %s: %s\n", entry.StartTime.Format("15:04:05"), entry.Speaker, entry.Text)
+	}
+
+	doc, err := gdocsHelper.CreateGoogleDoc(ctx, config, title)
+	if err != nil {
+		return "", err
+	}
+
+	if builder.Len() > 0 {
+		if err := gdocsHelper.AddText(ctx, config, doc.DocumentId, builder.String()); err != nil {
+			return doc.DocumentId, err
+		}
+	}
+
+	if eventID != "" {
+		if err := AttachFileToEvent(ctx, config, calendarID, eventID, doc.DocumentId); err != nil {
+			return doc.DocumentId, err
+		}
+	}
+	return doc.DocumentId, nil
+}
+
+// SpaceOptions configures a Meet space. Empty fields keep the organization defaults.
+// Moderation settings (host management, chat, reactions and presentation restrictions) cannot be
+// set: the Meet API version this module is built against has no fields for them.
+type SpaceOptions struct {
+	AccessType       string // "OPEN", "TRUSTED" or "RESTRICTED"
+	EntryPointAccess string // "ALL" or "CREATOR_APP_ONLY"
+}
+
+// toSpaceConfig converts options into a Meet space configuration and the matching update mask.
+func toSpaceConfig(opts SpaceOptions) (*meet.SpaceConfig, string) {
+	spaceConfig := &meet.SpaceConfig{
+		AccessType:       opts.AccessType,
+		EntryPointAccess: opts.EntryPointAccess,
+	}
+
+	var mask []string
+	if opts.AccessType != "" {
+		mask = append(mask, "config.accessType")
+	}
+	if opts.EntryPointAccess != "" {
+		mask = append(mask, "config.entryPointAccess")
+	}
+	return spaceConfig, strings.Join(mask, ",")
+}
+
+// CreateMeetSpace creates a standalone Meet space that is not tied to a calendar event.
+func CreateMeetSpace(ctx context.Context, config auth.Config, opts SpaceOptions) (*meet.Space, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	meetService, err := meet.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create meet service: %w", err)
+	}
+
+	spaceConfig, _ := toSpaceConfig(opts)
+	space, err := meetService.Spaces.Create(&meet.Space{Config: spaceConfig}).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create meet space: %w", err)
+	}
+	return space, nil
+}
+
+// GetMeetSpace retrieves a Meet space by resource name ("spaces/...") or meeting code.
+func GetMeetSpace(ctx context.Context, config auth.Config, spaceName string) (*meet.Space, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	meetService, err := meet.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create meet service: %w", err)
+	}
+
+	space, err := meetService.Spaces.Get(spaceResourceName(spaceName)).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to retrieve meet space: %w", err)
+	}
+	return space, nil
+}
+
+// UpdateMeetSpace changes the configuration of an existing Meet space.
+func UpdateMeetSpace(ctx context.Context, config auth.Config, spaceName string, opts SpaceOptions) (*meet.Space, error) {
+	spaceConfig, mask := toSpaceConfig(opts)
+	if mask == "" {
+		return nil, fmt.Errorf("gMeetHelper: no space settings to update")
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	meetService, err := meet.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create meet service: %w", err)
+	}
+
+	space, err := meetService.Spaces.Patch(spaceResourceName(spaceName), &meet.Space{Config: spaceConfig}).UpdateMask(mask).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to update meet space: %w", err)
+	}
+	return space, nil
+}
+
+// EndActiveConference ends the conference currently running in a Meet space, if any.
+func EndActiveConference(ctx context.Context, config auth.Config, spaceName string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	meetService, err := meet.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to create meet service: %w", err)
+	}
+
+	_, err = meetService.Spaces.EndActiveConference(spaceResourceName(spaceName), &meet.EndActiveConferenceRequest{}).Do()
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to end active conference: %w", err)
+	}
+	return nil
+}
+
+// spaceResourceName turns a meeting code into a space resource name; resource names are returned as is.
+func spaceResourceName(spaceName string) string {
+	if strings.HasPrefix(spaceName, "spaces/") {
+		return spaceName
+	}
+	return "spaces/" + spaceName
+}
+
+// WatchChannel describes a Calendar push notification channel.
+type WatchChannel struct {
+	ID         string    `json:"id"`
+	ResourceID string    `json:"resourceId"`
+	CalendarID string    `json:"calendarId"`
+	Address    string    `json:"address"`
+	Token      string    `json:"token,omitempty"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// ChannelStore persists watch channels so they can be renewed and stopped later.
+type ChannelStore interface {
+	Save(channel WatchChannel) error
+	Delete(channelID string) error
+	List() ([]WatchChannel, error)
+}
+
+// FileChannelStore is a ChannelStore backed by a JSON file.
+type FileChannelStore struct {
+	Path string
+	file channelstore.File[WatchChannel]
+}
+
+// Save adds or replaces a channel in the store.
+func (s *FileChannelStore) Save(channel WatchChannel) error {
+	if err := s.file.Save(s.Path, channel.ID, channel); err != nil {
+		return fmt.Errorf("gMeetHelper: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a channel from the store.
+func (s *FileChannelStore) Delete(channelID string) error {
+	if err := s.file.Delete(s.Path, channelID); err != nil {
+		return fmt.Errorf("gMeetHelper: %w", err)
+	}
+	return nil
+}
+
+// List returns all stored channels, soonest to expire first.
+func (s *FileChannelStore) List() ([]WatchChannel, error) {
+	channels, err := s.file.List(s.Path, func(channel WatchChannel) time.Time { return channel.Expiration })
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: %w", err)
+	}
+	return channels, nil
+}
+
+// WatchCalendar registers a push notification channel that posts to webhookURL whenever an event of
+// the calendar changes. The channel is saved to store when store is non-nil.
+// A zero ttl lets Calendar pick its default expiration.
+func WatchCalendar(ctx context.Context, config auth.Config, store ChannelStore, calendarID, webhookURL, channelToken string, ttl time.Duration) (*WatchChannel, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	channel := WatchChannel{CalendarID: calendarOrPrimary(calendarID), Address: webhookURL, Token: channelToken}
+	return registerChannel(calendarService, store, channel, ttl)
+}
+
+// registerChannel creates an events watch for channel under a fresh channel ID.
+func registerChannel(calendarService *calendar.Service, store ChannelStore, channel WatchChannel, ttl time.Duration) (*WatchChannel, error) {
+	request := &calendar.Channel{
+		Id:      uuid.NewString(),
+		Type:    "web_hook",
+		Address: channel.Address,
+		Token:   channel.Token,
+	}
+	if ttl > 0 {
+		request.Params = map[string]string{"ttl": fmt.Sprintf("%d", int64(ttl.Seconds()))}
+	}
+
+	response, err := calendarService.Events.Watch(channel.CalendarID, request).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to register watch channel: %w", err)
+	}
+
+	channel.ID = response.Id
+	channel.ResourceID = response.ResourceId
+	channel.Expiration = time.UnixMilli(response.Expiration)
+
+	if store != nil {
+		if err := store.Save(channel); err != nil {
+			return nil, err
+		}
+	}
+	return &channel, nil
+}
+
+// StopCalendarWatch stops a push notification channel and removes it from store when store is non-nil.
+func StopCalendarWatch(ctx context.Context, config auth.Config, store ChannelStore, channel WatchChannel) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	return stopChannel(calendarService, store, channel)
+}
+
+// stopChannel stops channel and removes it from store.
+func stopChannel(calendarService *calendar.Service, store ChannelStore, channel WatchChannel) error {
+	err := calendarService.Channels.Stop(&calendar.Channel{Id: channel.ID, ResourceId: channel.ResourceID}).Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		// An unknown channel has already expired, which is what we want
+		if !errors.As(err, &apiErr) || apiErr.Code != 404 {
+			return fmt.Errorf("gMeetHelper: unable to stop watch channel: %w", err)
+		}
+	}
+
+	if store != nil {
+		if err := store.Delete(channel.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenewCalendarWatches replaces every stored channel that expires within renewBefore with a new
+// channel for the same calendar and stops the old one. It returns the renewed channels.
+func RenewCalendarWatches(ctx context.Context, config auth.Config, store ChannelStore, renewBefore, ttl time.Duration) ([]WatchChannel, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	channels, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	return channelstore.Renew(channels, time.Now().Add(renewBefore),
+		func(channel WatchChannel) time.Time { return channel.Expiration },
+		func(channel WatchChannel) (*WatchChannel, error) {
+			return registerChannel(calendarService, store, channel, ttl)
+		},
+		func(channel WatchChannel) error { return stopChannel(calendarService, store, channel) },
+	)
+}
+
+// ErrSyncTokenExpired is returned by SyncEvents when the sync token is no longer valid and a full
+// sync is required.
+var ErrSyncTokenExpired = errors.New("gMeetHelper: sync token expired, a full sync is required")
+
+// SyncResult holds the events changed since the previous sync.
+type SyncResult struct {
+	Changed       []EventInfo // created or updated events
+	Deleted       []string    // IDs of cancelled events
+	NextSyncToken string
+	FullSync      bool // true when all events were returned instead of only the changes
+}
+
+// SyncEvents returns the events of a calendar that changed since syncToken was issued. An empty
+// syncToken performs a full sync. Recurring events are expanded into single instances.
+func SyncEvents(ctx context.Context, config auth.Config, calendarID, syncToken string) (*SyncResult, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	result := &SyncResult{FullSync: syncToken == ""}
+	call := calendarService.Events.List(calendarOrPrimary(calendarID)).SingleEvents(true).ShowDeleted(true)
+	if syncToken != "" {
+		call = call.SyncToken(syncToken)
+	}
+
+	err = call.Pages(ctx, func(page *calendar.Events) error {
+		for _, event := range page.Items {
+			if event.Status == "cancelled" {
+				result.Deleted = append(result.Deleted, event.Id)
+				continue
+			}
+			result.Changed = append(result.Changed, toEventInfo(event))
+		}
+		if page.NextSyncToken != "" {
+			result.NextSyncToken = page.NextSyncToken
+		}
+		return nil
+	})
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == 410 {
+			return nil, ErrSyncTokenExpired
+		}
+		return nil, fmt.Errorf("gMeetHelper: unable to sync events: %w", err)
+	}
+	return result, nil
+}
+
+// SyncEventsWithTokenFile runs SyncEvents using the sync token stored in tokenPath and saves the
+// new token afterwards. An expired or missing token triggers a full sync.
+func SyncEventsWithTokenFile(ctx context.Context, config auth.Config, calendarID, tokenPath string) (*SyncResult, error) {
+	syncToken := ""
+	data, err := os.ReadFile(tokenPath)
+	if err == nil {
+		syncToken = strings.TrimSpace(string(data))
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("gMeetHelper: unable to read sync token: %w", err)
+	}
+
+	result, err := SyncEvents(ctx, config, calendarID, syncToken)
+	if errors.Is(err, ErrSyncTokenExpired) {
+		result, err = SyncEvents(ctx, config, calendarID, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(tokenPath, []byte(result.NextSyncToken), 0600); err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to save sync token: %w", err)
+	}
+	return result, nil
+}
+
+// ExportEventsToICS retrieves the given events and writes them to w as an iCalendar (.ics) file.
+func ExportEventsToICS(ctx context.Context, config auth.Config, calendarID string, eventIDs []string, w io.Writer) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	events := make([]*calendar.Event, 0, len(eventIDs))
+	for _, eventID := range eventIDs {
+		event, err := calendarService.Events.Get(calendarOrPrimary(calendarID), eventID).Do()
+		if err != nil {
+			return fmt.Errorf("gMeetHelper: unable to retrieve event %s: %w", eventID, err)
+		}
+		events = append(events, event)
+	}
+
+	return WriteICS(w, events)
+}
+
+// WriteICS writes events to w as an RFC 5545 iCalendar document, including recurrence rules,
+// organizer and attendees. Times keep the time zone of the event, described by a VTIMEZONE
+// component for every zone used.
+func WriteICS(w io.Writer, events []*calendar.Event) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//gworkspace-helper//gMeetHelper//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+	}
+
+	// Every TZID referenced by DTSTART or DTEND needs a matching VTIMEZONE, covering the years
+	// the events span; recurring events are covered for ten more years
+	type zoneSpan struct{ from, to time.Time }
+	var zoneIDs []string
+	spans := map[string]*zoneSpan{}
+	for _, event := range events {
+		for _, eventTime := range []*calendar.EventDateTime{event.Start, event.End} {
+			loc, t, ok := icsZonedTime(eventTime)
+			if !ok {
+				continue
+			}
+			to := t
+			if len(event.Recurrence) > 0 {
+				to = t.AddDate(10, 0, 0)
+			}
+			span, seen := spans[loc.String()]
+			if !seen {
+				zoneIDs = append(zoneIDs, loc.String())
+				spans[loc.String()] = &zoneSpan{from: t, to: to}
+				continue
+			}
+			if t.Before(span.from) {
+				span.from = t
+			}
+			if to.After(span.to) {
+				span.to = to
+			}
+		}
+	}
+	for _, zoneID := range zoneIDs {
+		lines = append(lines, icsTimeZone(zoneID, spans[zoneID].from, spans[zoneID].to)...)
+	}
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, event := range events {
+		uid := event.ICalUID
+		if uid == "" {
+			uid = event.Id
+		}
+
+		lines = append(lines, "BEGIN:VEVENT", "UID:"+uid, "DTSTAMP:"+stamp)
+		lines = append(lines, icsDateTime("DTSTART", event.Start), icsDateTime("DTEND", event.End))
+		if event.Summary != "" {
+			lines = append(lines, "SUMMARY:"+icsEscape(event.Summary))
+		}
+		if event.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+icsEscape(event.Description))
+		}
+		if event.Location != "" {
+			lines = append(lines, "LOCATION:"+icsEscape(event.Location))
+		}
+		if event.Status != "" {
+			lines = append(lines, "STATUS:"+strings.ToUpper(event.Status))
+		}
+		if event.Transparency == "transparent" {
+			lines = append(lines, "TRANSP:TRANSPARENT")
+		}
+		if event.HtmlLink != "" {
+			lines = append(lines, "URL:"+event.HtmlLink)
+		}
+		// Recurrence entries are already RRULE, EXRULE, RDATE or EXDATE lines
+		lines = append(lines, event.Recurrence...)
+		if event.Organizer != nil && event.Organizer.Email != "" {
+			lines = append(lines, "ORGANIZER"+icsCommonName(event.Organizer.DisplayName)+":mailto:"+event.Organizer.Email)
+		}
+		for _, attendee := range event.Attendees {
+			line := "ATTENDEE" + icsCommonName(attendee.DisplayName)
+			if attendee.Optional {
+				line += ";ROLE=OPT-PARTICIPANT"
+			} else {
+				line += ";ROLE=REQ-PARTICIPANT"
+			}
+			if status, ok := icsPartStat[attendee.ResponseStatus]; ok {
+				line += ";PARTSTAT=" + status
+			}
+			lines = append(lines, line+":mailto:"+attendee.Email)
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString(icsFold(line))
+		builder.WriteString("\r\n")
+	}
+	if _, err := io.WriteString(w, builder.String()); err != nil {
+		return fmt.Errorf("gMeetHelper: unable to write iCalendar data: %w", err)
+	}
+	return nil
+}
+
+// icsPartStat maps Calendar response statuses to iCalendar participation statuses.
+var icsPartStat = map[string]string{
+	"needsAction": "NEEDS-ACTION",
+	"accepted":    "ACCEPTED",
+	"declined":    "DECLINED",
+	"tentative":   "TENTATIVE",
+}
+
+// icsDateTime formats an event start or end as an iCalendar property.
+func icsDateTime(property string, eventTime *calendar.EventDateTime) string {
+	if eventTime == nil {
+		return property + ":"
+	}
+	if eventTime.Date != "" {
+		return property + ";VALUE=DATE:" + strings.ReplaceAll(eventTime.Date, "-", "")
+	}
+
+	// Keep the time zone so that recurring events follow daylight saving changes
+	if loc, t, ok := icsZonedTime(eventTime); ok {
+		return property + ";TZID=" + loc.String() + ":" + t.In(loc).Format("20060102T150405")
+	}
+	t, err := time.Parse(time.RFC3339, eventTime.DateTime)
+	if err != nil {
+		return property + ":"
+	}
+	return property + ":" + t.UTC().Format("20060102T150405Z")
+}
+
+// icsZonedTime returns the time zone and instant of a timed event start or end, when it has a
+// time zone known to the time package.
+func icsZonedTime(eventTime *calendar.EventDateTime) (*time.Location, time.Time, bool) {
+	if eventTime == nil || eventTime.DateTime == "" || eventTime.TimeZone == "" {
+		return nil, time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, eventTime.DateTime)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	loc, err := time.LoadLocation(eventTime.TimeZone)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	return loc, t, true
+}
+
+// icsTimeZone returns a VTIMEZONE component for zoneID, listing its offset changes from the year
+// before from until the year after to. Each change is written as its own observance, taken from
+// the time zone database, rather than as a recurrence rule.
+func icsTimeZone(zoneID string, from, to time.Time) []string {
+	loc, err := time.LoadLocation(zoneID)
+	if err != nil {
+		return nil
+	}
+
+	lines := []string{"BEGIN:VTIMEZONE", "TZID:" + zoneID}
+	observance := func(at time.Time, offsetFrom int) {
+		name, offsetTo := at.Zone()
+		kind := "STANDARD"
+		if at.IsDST() {
+			kind = "DAYLIGHT"
+		}
+		// The onset is the local time just before the change, in the previous offset
+		onset := at.In(time.FixedZone("", offsetFrom)).Format("20060102T150405")
+		lines = append(lines,
+			"BEGIN:"+kind,
+			"DTSTART:"+onset,
+			"TZOFFSETFROM:"+icsOffset(offsetFrom),
+			"TZOFFSETTO:"+icsOffset(offsetTo),
+			"TZNAME:"+name,
+			"END:"+kind,
+		)
+	}
+
+	start := time.Date(from.Year()-1, 1, 1, 0, 0, 0, 0, loc)
+	end := time.Date(to.Year()+2, 1, 1, 0, 0, 0, 0, loc)
+	_, offset := start.Zone()
+	observance(start, offset)
+
+	// Scan day by day and narrow every change of offset down to the second
+	for day := start; day.Before(end); {
+		next := day.Add(24 * time.Hour)
+		if _, nextOffset := next.Zone(); nextOffset != offset {
+			low, high := day, next
+			for high.Sub(low) > time.Second {
+				middle := low.Add(high.Sub(low) / 2)
+				if _, middleOffset := middle.Zone(); middleOffset == offset {
+					low = middle
+				} else {
+					high = middle
+				}
+			}
+			observance(high, offset)
+			_, offset = high.Zone()
+		}
+		day = next
+	}
+
+	return append(lines, "END:VTIMEZONE")
+}
+
+// icsOffset formats a UTC offset in seconds as an iCalendar UTC-OFFSET value, e.g., "-0500".
+func icsOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	offset := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+	if seconds%60 != 0 {
+		offset += fmt.Sprintf("%02d", seconds%60)
+	}
+	return offset
+}
+
+// icsCommonName returns a CN parameter for name, or an empty string when name is empty.
+func icsCommonName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return ";CN=" + icsQuote(name)
+}
+
+// icsQuote wraps a parameter value in double quotes, dropping characters that are not allowed.
+func icsQuote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, "") + `"`
+}
+
+// icsEscape escapes a TEXT value as described in RFC 5545 section 3.3.11.
+func icsEscape(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+// icsFold splits a content line into 75-octet lines as required by RFC 5545 section 3.1,
+// without breaking multi-byte characters.
+func icsFold(line string) string {
+	if len(line) <= 75 {
+		return line
+	}
+
+	var builder strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			builder.WriteString("\r\n ")
+			width = 1
+		}
+		builder.WriteRune(r)
+		width += size
+	}
+	return builder.String()
+}
+
+// ImportICS parses an iCalendar file and imports its events into a calendar. Events are matched by
+// their UID, so importing the same file again updates the existing events instead of duplicating them.
+func ImportICS(ctx context.Context, config auth.Config, calendarID string, r io.Reader) ([]*calendar.Event, error) {
+	events, err := parseICS(r)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	imported := make([]*calendar.Event, 0, len(events))
+	for _, event := range events {
+		importedEvent, err := calendarService.Events.Import(calendarOrPrimary(calendarID), event).Do()
+		if err != nil {
+			return imported, fmt.Errorf("gMeetHelper: unable to import event %s: %w", event.ICalUID, err)
+		}
+		imported = append(imported, importedEvent)
+	}
+	return imported, nil
+}
+
+// parseICS extracts the VEVENT components of an iCalendar document.
+func parseICS(r io.Reader) ([]*calendar.Event, error) {
+	// Unfold continuation lines first
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to read iCalendar data: %w", err)
+	}
+
+	var events []*calendar.Event
+	var event *calendar.Event
+	nested := 0
+	for _, line := range lines {
+		name, params, value := parseICSLine(line)
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT" && event == nil:
+			event = &calendar.Event{}
+			continue
+		case name == "BEGIN" && event != nil:
+			// Skip nested components such as VALARM
+			nested++
+			continue
+		case name == "END" && event != nil && nested > 0:
+			nested--
+			continue
+		case name == "END" && value == "VEVENT" && event != nil:
+			if event.ICalUID == "" {
+				return nil, fmt.Errorf("gMeetHelper: iCalendar event %q has no UID", event.Summary)
+			}
+			if event.Start == nil {
+				return nil, fmt.Errorf("gMeetHelper: iCalendar event %s has no DTSTART", event.ICalUID)
+			}
+			if event.End == nil {
+				event.End = defaultICSEnd(event.Start)
+			}
+			events = append(events, event)
+			event = nil
+			continue
+		}
+		if event == nil || nested > 0 {
+			continue
+		}
+
+		switch name {
+		case "UID":
+			event.ICalUID = value
+		case "SUMMARY":
+			event.Summary = icsUnescape(value)
+		case "DESCRIPTION":
+			event.Description = icsUnescape(value)
+		case "LOCATION":
+			event.Location = icsUnescape(value)
+		case "STATUS":
+			event.Status = strings.ToLower(value)
+		case "TRANSP":
+			event.Transparency = strings.ToLower(value)
+		case "DTSTART", "DTEND":
+			eventTime, err := parseICSDateTime(params, value)
+			if err != nil {
+				return nil, err
+			}
+			if name == "DTSTART" {
+				event.Start = eventTime
+			} else {
+				event.End = eventTime
+			}
+		case "RRULE", "EXRULE", "RDATE", "EXDATE":
+			event.Recurrence = append(event.Recurrence, line)
+		case "ORGANIZER":
+			event.Organizer = &calendar.EventOrganizer{
+				Email:       icsMailto(value),
+				DisplayName: strings.Trim(params["CN"], `"`),
+			}
+		case "ATTENDEE":
+			attendee := &calendar.EventAttendee{
+				Email:       icsMailto(value),
+				DisplayName: strings.Trim(params["CN"], `"`),
+				Optional:    params["ROLE"] == "OPT-PARTICIPANT",
+			}
+			for status, partStat := range icsPartStat {
+				if params["PARTSTAT"] == partStat {
+					attendee.ResponseStatus = status
+				}
+			}
+			event.Attendees = append(event.Attendees, attendee)
+		}
+	}
+	return events, nil
+}
+
+// parseICSLine splits a content line into its name, parameters and value.
+func parseICSLine(line string) (string, map[string]string, string) {
+	// The value starts at the first colon that is not inside a quoted parameter value
+	colon := -1
+	quoted := false
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon == -1 {
+		return strings.ToUpper(line), nil, ""
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	params := map[string]string{}
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			params[strings.ToUpper(key)] = value
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:]
+}
+
+// parseICSDateTime converts a DTSTART or DTEND value into an EventDateTime. Floating times
+// without a time zone are interpreted as UTC.
+func parseICSDateTime(params map[string]string, value string) (*calendar.EventDateTime, error) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.Parse("20060102", value)
+		if err != nil {
+			return nil, fmt.Errorf("gMeetHelper: invalid iCalendar date %q: %w", value, err)
+		}
+		return &calendar.EventDateTime{Date: t.Format("2006-01-02")}, nil
+	}
+
+	loc := time.UTC
+	timeZone := ""
+	if tzid := strings.Trim(params["TZID"], `"`); tzid != "" {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return nil, fmt.Errorf("gMeetHelper: unknown iCalendar time zone %q: %w", tzid, err)
+		}
+		loc, timeZone = l, tzid
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", strings.TrimSuffix(value, "Z"), loc)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: invalid iCalendar date-time %q: %w", value, err)
+	}
+	return &calendar.EventDateTime{DateTime: t.Format(time.RFC3339), TimeZone: timeZone}, nil
+}
+
+// defaultICSEnd returns the implicit end of an event without DTEND: one day for all-day events
+// and the start time otherwise.
+func defaultICSEnd(start *calendar.EventDateTime) *calendar.EventDateTime {
+	if start.Date != "" {
+		t, _ := time.Parse("2006-01-02", start.Date)
+		return &calendar.EventDateTime{Date: t.AddDate(0, 0, 1).Format("2006-01-02")}
+	}
+	end := *start
+	return &end
+}
+
+// icsMailto strips the mailto: prefix from a CAL-ADDRESS value.
+func icsMailto(value string) string {
+	if len(value) >= 7 && strings.EqualFold(value[:7], "mailto:") {
+		return value[7:]
+	}
+	return value
+}
+
+// icsUnescape reverses icsEscape.
+func icsUnescape(text string) string {
+	replacer := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	return replacer.Replace(text)
+}
+
+// EventSpec describes one event to be created by CreateCalendarEvents.
+type EventSpec struct {
+	Summary     string
+	Location    string
+	Description string
+	StartTime   time.Time
+	EndTime     time.Time
+	Attendees   []string
+	Options     EventOptions
+}
+
+// BatchOptions controls how CreateCalendarEvents spreads its requests.
+type BatchOptions struct {
+	// Concurrency is the number of events created in parallel, defaults to 4.
+	Concurrency int
+
+	// RequestsPerSecond caps how fast new events are started, defaults to 5.
+	RequestsPerSecond float64
+
+	// MaxRetries is the number of times a rate-limited or failed request is retried with
+	// exponential backoff, defaults to 5.
+	MaxRetries int
+}
+
+// EventResult reports the outcome of creating one event in a batch.
+type EventResult struct {
+	Index    int             // position of the spec in the input slice
+	Event    *calendar.Event // created event, nil on failure
+	Attempts int
+	Err      error
+}
+
+// CreateCalendarEvents creates many events with bounded concurrency, retrying rate-limited and
+// transient failures with exponential backoff. Every event is given its ID up front, so a retried
+// request never creates a duplicate. One result is returned per spec, in the same order; events
+// not attempted because ctx was cancelled report ctx.Err().
+func CreateCalendarEvents(ctx context.Context, config auth.Config, specs []EventSpec, opts BatchOptions) ([]EventResult, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	maxRetries := opts.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 5
+	}
+
+	// Look up each calendar's time zone once rather than once per event
+	var tzMu sync.Mutex
+	timeZones := make(map[string]string)
+	timeZoneFor := func(spec EventSpec) (string, error) {
+		if spec.Options.TimeZone != "" {
+			return spec.Options.TimeZone, nil
+		}
+		calendarID := calendarOrPrimary(spec.Options.CalendarID)
+		tzMu.Lock()
+		defer tzMu.Unlock()
+		if tz, ok := timeZones[calendarID]; ok {
+			return tz, nil
+		}
+		tz, err := resolveTimeZone(calendarService, calendarID, "")
+		if err != nil {
+			return "", err
+		}
+		timeZones[calendarID] = tz
+		return tz, nil
+	}
+
+	results := make([]EventResult, len(specs))
+	started := make([]bool, len(specs))
+	for i := range results {
+		results[i].Index = i
+	}
+
+	concurrency.ForEach(ctx, len(specs), opts.Concurrency, opts.RequestsPerSecond, func(i int) {
+		spec := specs[i]
+		result := &results[i]
+		started[i] = true
+
+		timeZone, err := timeZoneFor(spec)
+		if err != nil {
+			result.Err = err
+			return
+		}
+		event, err := newEvent(spec.Summary, spec.Location, spec.Description, spec.StartTime, spec.EndTime, timeZone, spec.Options)
+		if err != nil {
+			result.Err = err
+			return
+		}
+		event.Id = strings.ReplaceAll(uuid.NewString(), "-", "")
+		for _, email := range spec.Attendees {
+			event.Attendees = append(event.Attendees, &calendar.EventAttendee{Email: email})
+		}
+
+		calendarID := calendarOrPrimary(spec.Options.CalendarID)
+		for {
+			result.Attempts++
+			call := calendarService.Events.Insert(calendarID, event).ConferenceDataVersion(1).Context(ctx)
+			if spec.Options.SendUpdates != "" {
+				call = call.SendUpdates(spec.Options.SendUpdates)
+			}
+			created, err := call.Do()
+			if err == nil {
+				result.Event = created
+				return
+			}
+
+			var apiErr *googleapi.Error
+			if result.Attempts > 1 && errors.As(err, &apiErr) && apiErr.Code == 409 {
+				// An earlier attempt succeeded even though its response was lost
+				existing, getErr := calendarService.Events.Get(calendarID, event.Id).Context(ctx).Do()
+				if getErr == nil {
+					result.Event = existing
+					return
+				}
+			}
+
+			if result.Attempts > maxRetries || !isRetryable(err) {
+				result.Err = fmt.Errorf("gMeetHelper: unable to create calendar event %q: %w", spec.Summary, err)
+				return
+			}
+			if err := sleepBackoff(ctx, result.Attempts); err != nil {
+				result.Err = err
+				return
+			}
+		}
+	})
+
+	for i := range results {
+		if !started[i] {
+			results[i].Err = ctx.Err()
+		}
+	}
+	return results, nil
+}
+
+// isRetryable reports whether err is a rate-limit or transient server error worth retrying.
+func isRetryable(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch {
+	case apiErr.Code == 429 || apiErr.Code >= 500:
+		return true
+	case apiErr.Code == 403:
+		for _, item := range apiErr.Errors {
+			if item.Reason == "rateLimitExceeded" || item.Reason == "userRateLimitExceeded" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sleepBackoff waits before retry number attempt, doubling from one second up to 32 seconds with
+// up to one second of jitter.
+func sleepBackoff(ctx context.Context, attempt int) error {
+	delay := time.Second << min(attempt-1, 5)
+	delay += time.Duration(rand.Int63n(int64(time.Second)))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// MeetingPackOptions describes the meeting prepared by CreateMeetingPack.
+type MeetingPackOptions struct {
+	Title       string // summary of the event, also used to name the folder and the notes doc
+	Description string
+	Location    string
+	StartTime   time.Time
+	EndTime     time.Time
+	Attendees   []string
+
+	// NotesTemplateID is a Google Doc copied as the meeting notes. When empty, a blank doc is created.
+	NotesTemplateID string
+
+	// ParentFolderID is the folder in which the dated meeting folder is created, defaults to My Drive.
+	ParentFolderID string
+
+	// AttendeeRole is the access attendees get to the notes doc, defaults to "writer".
+	AttendeeRole string
+
+	// Event holds the calendar, time zone, reminders and notification settings of the event.
+	Event EventOptions
+}
+
+// MeetingPack holds the IDs of everything created by CreateMeetingPack.
+type MeetingPack struct {
+	FolderID string
+	DocID    string
+	EventID  string
+	MeetLink string
+}
+
+// CreateMeetingPack prepares a meeting in one call: it creates a folder named after the date and
+// title, copies the notes template into it, shares the notes with the attendees and creates the
+// calendar event with a Google Meet conference and the notes attached. The event is created
+// last, so invitations only go out once everything else is in place. If any step fails, the
+// folder and its contents are deleted again and the returned error describes both failures.
+func CreateMeetingPack(ctx context.Context, config auth.Config, opts MeetingPackOptions) (*MeetingPack, error) {
+	if opts.Title == "" {
+		return nil, fmt.Errorf("gMeetHelper: meeting pack title is required")
+	}
+	if err := checkSendUpdates(opts.Event.SendUpdates); err != nil {
+		return nil, err
+	}
+	role := opts.AttendeeRole
+	if role == "" {
+		role = "writer"
+	}
+	parentID := opts.ParentFolderID
+	if parentID == "" {
+		parentID = "root"
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create Drive service: %w", err)
+	}
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+
+	timeZone, err := resolveTimeZone(calendarService, opts.Event.CalendarID, opts.Event.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("gMeetHelper: unable to load timezone %s: %w", timeZone, err)
+	}
+	name := opts.StartTime.In(loc).Format("2006-01-02") + " " + opts.Title
+
+	folder, err := gDriveHelper.CreateFolderInParent(ctx, config, parentID, name, gDriveHelper.ConflictAutoSuffix)
+	if err != nil {
+		return nil, err
+	}
+	pack := &MeetingPack{FolderID: folder.Id}
+
+	rollback := func(cause error) error {
+		if err := gDriveHelper.DeleteFileOrFolder(ctx, config, folder.Id); err != nil {
+			return fmt.Errorf("%w (rollback failed, folder %s was left behind: %v)", cause, folder.Id, err)
+		}
+		return cause
+	}
+
+	notes := &drive.File{Name: opts.Title + " notes", Parents: []string{folder.Id}}
+	var doc *drive.File
+	if opts.NotesTemplateID != "" {
+		doc, err = driveService.Files.Copy(opts.NotesTemplateID, notes).Fields("id", "name", "mimeType", "webViewLink").SupportsAllDrives(true).Do()
+	} else {
+		notes.MimeType = "application/vnd.google-apps.document"
+		doc, err = driveService.Files.Create(notes).Fields("id", "name", "mimeType", "webViewLink").SupportsAllDrives(true).Do()
+	}
+	if err != nil {
+		return nil, rollback(fmt.Errorf("gMeetHelper: unable to create meeting notes: %w", err))
+	}
+	pack.DocID = doc.Id
+
+	for _, email := range opts.Attendees {
+		_, err = gDriveHelper.AddPermission(ctx, config, doc.Id, gDriveHelper.PermissionOptions{
+			Type:         "user",
+			Role:         role,
+			EmailAddress: email,
+		})
+		if err != nil {
+			return nil, rollback(err)
+		}
+	}
+
+	event, err := newEvent(opts.Title, opts.Location, opts.Description, opts.StartTime, opts.EndTime, timeZone, opts.Event)
+	if err != nil {
+		return nil, rollback(err)
+	}
+	event.Attendees = mergeAttendees(nil, opts.Attendees, false)
+	event.Attachments = []*calendar.EventAttachment{{
+		FileId:   doc.Id,
+		FileUrl:  doc.WebViewLink,
+		Title:    doc.Name,
+		MimeType: doc.MimeType,
+	}}
+
+	call := calendarService.Events.Insert(calendarOrPrimary(opts.Event.CalendarID), event).ConferenceDataVersion(1).SupportsAttachments(true)
+	if opts.Event.SendUpdates != "" {
+		call = call.SendUpdates(opts.Event.SendUpdates)
+	}
+	createdEvent, err := call.Do()
+	if err != nil {
+		return nil, rollback(fmt.Errorf("gMeetHelper: unable to create calendar event: %w", err))
+	}
+	pack.EventID = createdEvent.Id
+	pack.MeetLink = GetConferenceInfo(createdEvent).MeetLink
+	return pack, nil
+}
+
+// htmlTag matches the markup the Calendar UI adds to event descriptions.
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// AgendaPlaceholders lists the placeholders replaced by CreateAgendaDoc in an agenda template.
+var AgendaPlaceholders = []string{"{{title}}", "{{date}}", "{{time}}", "{{location}}", "{{description}}", "{{attendees}}", "{{meet_link}}"}
+
+// CreateAgendaDoc generates an agenda Google Doc for an existing event, attaches it to the event
+// and gives every attendee comment access. The doc is a copy of templateID with AgendaPlaceholders
+// filled in from the event, or a plain agenda when templateID is empty. It is created in folderID,
+// or in My Drive when folderID is empty. Running it again creates a new doc but never attaches the
+// same doc twice. It returns the ID of the doc; attendees the doc could not be shared with are
+// reported together in the error, after every other attendee got access.
+func CreateAgendaDoc(ctx context.Context, config auth.Config, calendarID, eventID, templateID, folderID string) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gMeetHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	calendarService, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gMeetHelper: unable to create calendar service: %w", err)
+	}
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gMeetHelper: unable to create Drive service: %w", err)
+	}
+
+	event, err := calendarService.Events.Get(calendarOrPrimary(calendarID), eventID).Do()
+	if err != nil {
+		return "", fmt.Errorf("gMeetHelper: unable to retrieve event: %w", err)
+	}
+	info := toEventInfo(event)
+
+	var attendees, shareWith []string
+	for _, attendee := range event.Attendees {
+		if attendee.Resource {
+			continue
+		}
+		attendees = append(attendees, attendee.Email)
+		// The caller owns the doc, whether they organize the event or only attend it
+		if !attendee.Self {
+			shareWith = append(shareWith, attendee.Email)
+		}
+	}
+
+	description := strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n", "</li>", "\n").Replace(event.Description)
+	description = strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(description, "")))
+
+	when := info.Start.Format("15:04") + " - " + info.End.Format("15:04")
+	if info.AllDay {
+		when = "All day"
+	}
+	values := map[string]string{
+		"{{title}}":       event.Summary,
+		"{{date}}":        info.Start.Format("Monday, January 2, 2006"),
+		"{{time}}":        when,
+		"{{location}}":    event.Location,
+		"{{description}}": description,
+		"{{attendees}}":   strings.Join(attendees, "\n"),
+		"{{meet_link}}":   info.MeetLink,
+	}
+
+	agenda := &drive.File{Name: "Agenda: " + event.Summary}
+	if folderID != "" {
+		agenda.Parents = []string{folderID}
+	}
+	var doc *drive.File
+	if templateID != "" {
+		doc, err = driveService.Files.Copy(templateID, agenda).SupportsAllDrives(true).Do()
+		if err != nil {
+			return "", fmt.Errorf("gMeetHelper: unable to copy agenda template: %w", err)
+		}
+		if err := gdocsHelper.ReplaceMultipleTexts(ctx, config, doc.Id, values); err != nil {
+			return doc.Id, err
+		}
+	} else {
+		agenda.MimeType = "application/vnd.google-apps.document"
+		doc, err = driveService.Files.Create(agenda).SupportsAllDrives(true).Do()
+		if err != nil {
+			return "", fmt.Errorf("gMeetHelper: unable to create agenda doc: %w", err)
+		}
+
+		var builder strings.Builder
+		fmt.Fprintf(&builder, "%s\n%s, %s\n", event.Summary, values["{{date}}"], when)
+		if event.Location != "" {
+			fmt.Fprintf(&builder, "Location: %s\n", event.Location)
+		}
+		if info.MeetLink != "" {
+			fmt.Fprintf(&builder, "Meet: %s\n", info.MeetLink)
+		}
+		if len(attendees) > 0 {
+			fmt.Fprintf(&builder, "\nAttendees\n%s\n", values["{{attendees}}"])
+		}
+		if description != "" {
+			fmt.Fprintf(&builder, "\nAgenda\n%s\n", description)
+		}
+		if err := gdocsHelper.AddText(ctx, config, doc.Id, builder.String()); err != nil {
+			return doc.Id, err
+		}
+	}
+
+	err = updateAttachments(ctx, config, calendarID, eventID, []string{doc.Id}, func(current []*calendar.EventAttachment, lookedUp map[string]*calendar.EventAttachment) []*calendar.EventAttachment {
+		if attachment, ok := lookedUp[doc.Id]; ok {
+			current = append(current, attachment)
+		}
+		return current
+	})
+	if err != nil {
+		return doc.Id, err
+	}
+
+	// A failure for one attendee, e.g., an external user blocked by policy, must not keep the
+	// others from getting access
+	var shareErrs []error
+	for _, email := range shareWith {
+		_, err = gDriveHelper.AddPermission(ctx, config, doc.Id, gDriveHelper.PermissionOptions{
+			Type:         "user",
+			Role:         "commenter",
+			EmailAddress: email,
+		})
+		if err != nil {
+			shareErrs = append(shareErrs, fmt.Errorf("gMeetHelper: unable to share agenda with %s: %w", email, err))
+		}
+	}
+	return doc.Id, errors.Join(shareErrs...)
+}
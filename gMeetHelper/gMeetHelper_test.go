@@ -0,0 +1,302 @@
+package gMeetHelper
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestFindFreeSlotsAcrossDaylightSaving(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	workingHours := WorkingHours{
+		Start:    9 * time.Hour,
+		End:      10 * time.Hour,
+		Location: newYork,
+		Weekdays: []time.Weekday{time.Sunday, time.Monday},
+	}
+
+	tests := []struct {
+		name string
+		day  time.Time
+	}{
+		{name: "clocks go forward", day: time.Date(2024, time.March, 10, 0, 0, 0, 0, newYork)},
+		{name: "clocks go back", day: time.Date(2024, time.November, 3, 0, 0, 0, 0, newYork)},
+		{name: "regular day", day: time.Date(2024, time.March, 11, 0, 0, 0, 0, newYork)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := Interval{Start: tt.day, End: tt.day.AddDate(0, 0, 1)}
+			slots := findFreeSlots(nil, time.Hour, window, workingHours)
+			if len(slots) != 1 {
+				t.Fatalf("got %d slots %v, want one", len(slots), slots)
+			}
+			if got := slots[0].In(newYork); got.Hour() != 9 || got.Minute() != 0 {
+				t.Fatalf("slot starts at %s, want 09:00 local time", got)
+			}
+		})
+	}
+}
+
+func TestWriteICSRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *calendar.Event
+	}{
+		{
+			name: "zoned recurring event with guests",
+			event: &calendar.Event{
+				ICalUID:     "weekly@example.com",
+				Summary:     "Sync; planning, review",
+				Description: "Agenda:\n1. Notes \\ actions\n" + strings.Repeat("A long line of text. ", 10),
+				Location:    "Room 1",
+				Start:       &calendar.EventDateTime{DateTime: "2024-05-01T10:00:00-04:00", TimeZone: "America/New_York"},
+				End:         &calendar.EventDateTime{DateTime: "2024-05-01T11:00:00-04:00", TimeZone: "America/New_York"},
+				Recurrence:  []string{"RRULE:FREQ=WEEKLY;BYDAY=WE"},
+				Organizer:   &calendar.EventOrganizer{Email: "alice@example.com", DisplayName: "Alice"},
+				Attendees: []*calendar.EventAttendee{
+					{Email: "bob@example.com", DisplayName: "Bob", ResponseStatus: "accepted"},
+					{Email: "carol@example.com", Optional: true, ResponseStatus: "needsAction"},
+				},
+			},
+		},
+		{
+			name: "all-day event",
+			event: &calendar.Event{
+				ICalUID: "holiday@example.com",
+				Summary: "Holiday",
+				Start:   &calendar.EventDateTime{Date: "2024-12-25"},
+				End:     &calendar.EventDateTime{Date: "2024-12-26"},
+			},
+		},
+		{
+			name: "UTC event without time zone",
+			event: &calendar.Event{
+				Id:           "utc1",
+				Summary:      "Call",
+				Status:       "tentative",
+				Transparency: "transparent",
+				Start:        &calendar.EventDateTime{DateTime: "2024-05-01T14:00:00Z"},
+				End:          &calendar.EventDateTime{DateTime: "2024-05-01T15:00:00Z"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := WriteICS(&buffer, []*calendar.Event{tt.event}); err != nil {
+				t.Fatalf("WriteICS: %v", err)
+			}
+			written := buffer.String()
+			for _, line := range strings.Split(strings.TrimSuffix(written, "\r\n"), "\r\n") {
+				if len(line) > 75 {
+					t.Errorf("line longer than 75 octets: %q", line)
+				}
+			}
+			if tz := tt.event.Start.TimeZone; tz != "" && !strings.Contains(written, "BEGIN:VTIMEZONE\r\nTZID:"+tz+"\r\n") {
+				t.Errorf("no VTIMEZONE for %s in\n%s", tz, written)
+			}
+
+			events, err := parseICS(strings.NewReader(written))
+			if err != nil {
+				t.Fatalf("parseICS: %v", err)
+			}
+			if len(events) != 1 {
+				t.Fatalf("parsed %d events, want 1", len(events))
+			}
+			got, want := events[0], tt.event
+
+			wantUID := want.ICalUID
+			if wantUID == "" {
+				wantUID = want.Id
+			}
+			if got.ICalUID != wantUID {
+				t.Errorf("UID = %q, want %q", got.ICalUID, wantUID)
+			}
+			if got.Summary != want.Summary || got.Description != want.Description || got.Location != want.Location {
+				t.Errorf("text = %q, %q, %q, want %q, %q, %q", got.Summary, got.Description, got.Location, want.Summary, want.Description, want.Location)
+			}
+			if got.Status != want.Status || got.Transparency != want.Transparency {
+				t.Errorf("status = %q, %q, want %q, %q", got.Status, got.Transparency, want.Status, want.Transparency)
+			}
+			if !reflect.DeepEqual(got.Start, want.Start) || !reflect.DeepEqual(got.End, want.End) {
+				t.Errorf("times = %+v to %+v, want %+v to %+v", got.Start, got.End, want.Start, want.End)
+			}
+			if !reflect.DeepEqual(got.Recurrence, want.Recurrence) {
+				t.Errorf("recurrence = %v, want %v", got.Recurrence, want.Recurrence)
+			}
+			if !reflect.DeepEqual(got.Organizer, want.Organizer) {
+				t.Errorf("organizer = %+v, want %+v", got.Organizer, want.Organizer)
+			}
+			if !reflect.DeepEqual(got.Attendees, want.Attendees) {
+				t.Errorf("attendees = %+v, want %+v", got.Attendees, want.Attendees)
+			}
+		})
+	}
+}
+
+func TestICSTimeZone(t *testing.T) {
+	tests := []struct {
+		name     string
+		zoneID   string
+		wantDST  bool
+		wantLine string
+	}{
+		{name: "zone with daylight saving", zoneID: "Europe/Berlin", wantDST: true, wantLine: "DTSTART:20240331T020000"},
+		{name: "zone without daylight saving", zoneID: "Asia/Tokyo", wantLine: "TZOFFSETTO:+0900"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := time.LoadLocation(tt.zoneID); err != nil {
+				t.Skipf("time zone database unavailable: %v", err)
+			}
+			at := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+			lines := strings.Join(icsTimeZone(tt.zoneID, at, at), "\n")
+			if !strings.HasPrefix(lines, "BEGIN:VTIMEZONE\nTZID:"+tt.zoneID+"\n") || !strings.HasSuffix(lines, "END:VTIMEZONE") {
+				t.Fatalf("malformed component:\n%s", lines)
+			}
+			if strings.Contains(lines, "BEGIN:DAYLIGHT") != tt.wantDST {
+				t.Errorf("daylight observances = %v, want %v:\n%s", !tt.wantDST, tt.wantDST, lines)
+			}
+			if !strings.Contains(lines, tt.wantLine) {
+				t.Errorf("missing %q in\n%s", tt.wantLine, lines)
+			}
+		})
+	}
+}
+
+func TestICSFold(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "short line", line: "SUMMARY:Sync"},
+		{name: "exactly 75 octets", line: strings.Repeat("a", 75)},
+		{name: "long ASCII line", line: "DESCRIPTION:" + strings.Repeat("x", 200)},
+		{name: "multi-byte characters", line: "SUMMARY:" + strings.Repeat("会議", 40)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folded := icsFold(tt.line)
+			for _, line := range strings.Split(folded, "\r\n") {
+				if len(line) > 75 {
+					t.Errorf("folded line has %d octets: %q", len(line), line)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("folding split a character: %q", line)
+				}
+			}
+			if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != tt.line {
+				t.Errorf("unfolded = %q, want %q", unfolded, tt.line)
+			}
+		})
+	}
+}
+
+func TestICSEscape(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "plain", want: "plain"},
+		{text: "a;b,c", want: `a\;b\,c`},
+		{text: `back\slash`, want: `back\\slash`},
+		{text: "two\r\nlines\nhere", want: `two\nlines\nhere`},
+	}
+	for _, tt := range tests {
+		if got := icsEscape(tt.text); got != tt.want {
+			t.Errorf("icsEscape(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		if got, want := icsUnescape(icsEscape(tt.text)), strings.ReplaceAll(tt.text, "\r\n", "\n"); got != want {
+			t.Errorf("icsUnescape(icsEscape(%q)) = %q, want %q", tt.text, got, want)
+		}
+	}
+}
+
+func TestParseICS(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []*calendar.Event
+		wantErr bool
+	}{
+		{
+			name: "skips alarms and defaults the end",
+			data: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:a\r\nDTSTART;VALUE=DATE:20240101\r\n" +
+				"BEGIN:VALARM\r\nDESCRIPTION:Reminder\r\nEND:VALARM\r\nSUMMARY:New\r\n  year\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			want: []*calendar.Event{{
+				ICalUID: "a",
+				Summary: "New year",
+				Start:   &calendar.EventDateTime{Date: "2024-01-01"},
+				End:     &calendar.EventDateTime{Date: "2024-01-02"},
+			}},
+		},
+		{
+			name: "quoted parameter with a colon",
+			data: "BEGIN:VEVENT\nUID:b\nDTSTART:20240101T090000Z\nDTEND:20240101T100000Z\n" +
+				"ATTENDEE;CN=\"Doe: Jane\";PARTSTAT=DECLINED:mailto:jane@example.com\nEND:VEVENT\n",
+			want: []*calendar.Event{{
+				ICalUID:   "b",
+				Start:     &calendar.EventDateTime{DateTime: "2024-01-01T09:00:00Z"},
+				End:       &calendar.EventDateTime{DateTime: "2024-01-01T10:00:00Z"},
+				Attendees: []*calendar.EventAttendee{{Email: "jane@example.com", DisplayName: "Doe: Jane", ResponseStatus: "declined"}},
+			}},
+		},
+		{name: "missing UID", data: "BEGIN:VEVENT\nDTSTART:20240101T090000Z\nEND:VEVENT\n", wantErr: true},
+		{name: "missing DTSTART", data: "BEGIN:VEVENT\nUID:c\nEND:VEVENT\n", wantErr: true},
+		{name: "unknown time zone", data: "BEGIN:VEVENT\nUID:d\nDTSTART;TZID=Nowhere/City:20240101T090000\nEND:VEVENT\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := parseICS(strings.NewReader(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseICS error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(events, tt.want) {
+				t.Errorf("parseICS = %+v, want %+v", events, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchEventTime(t *testing.T) {
+	at := time.Date(2024, time.May, 1, 14, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		timeZone  string
+		clearDate bool
+		want      string
+		wantErr   bool
+	}{
+		{name: "event time zone", timeZone: "America/New_York", want: `{"dateTime":"2024-05-01T10:00:00-04:00","timeZone":"America/New_York"}`},
+		{name: "no time zone", want: `{"dateTime":"2024-05-01T14:00:00Z"}`},
+		{name: "all-day event", clearDate: true, want: `{"date":null,"dateTime":"2024-05-01T14:00:00Z"}`},
+		{name: "unknown time zone", timeZone: "Nowhere/City", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventTime, err := patchEventTime(at, tt.timeZone, tt.clearDate)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("patchEventTime error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			data, err := json.Marshal(eventTime)
+			if err != nil {
+				t.Fatalf("unable to encode event time: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Fatalf("patchEventTime = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,1600 @@
+package gSheetsHelper
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gnzdotmx/gworkspace-helper/auth"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// SheetInfo describes a tab of a spreadsheet.
+type SheetInfo struct {
+	SheetID     int64
+	Title       string
+	Index       int64
+	RowCount    int64
+	ColumnCount int64
+}
+
+// SpreadsheetInfo holds the metadata of a spreadsheet.
+type SpreadsheetInfo struct {
+	ID       string
+	Title    string
+	URL      string
+	Locale   string
+	TimeZone string
+	Sheets   []SheetInfo
+}
+
+// CreateSpreadsheet creates a new spreadsheet with the given title. When folderID is set, the
+// spreadsheet is created in that folder, which may be in a shared drive; otherwise it is created
+// in My Drive.
+func CreateSpreadsheet(ctx context.Context, config auth.Config, title, folderID string) (*SpreadsheetInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	if folderID == "" {
+		spreadsheet, err := sheetsService.Spreadsheets.Create(&sheets.Spreadsheet{
+			Properties: &sheets.SpreadsheetProperties{Title: title},
+		}).Do()
+		if err != nil {
+			return nil, fmt.Errorf("gSheetsHelper: unable to create spreadsheet: %w", err)
+		}
+		return toSpreadsheetInfo(spreadsheet), nil
+	}
+
+	// The Sheets API cannot choose the parent folder, so the file is created through Drive
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: unable to create drive service: %w", err)
+	}
+	file, err := driveService.Files.Create(&drive.File{
+		Name:     title,
+		MimeType: "application/vnd.google-apps.spreadsheet",
+		Parents:  []string{folderID},
+	}).Fields("id").SupportsAllDrives(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: unable to create spreadsheet: %w", err)
+	}
+
+	spreadsheet, err := sheetsService.Spreadsheets.Get(file.Id).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: unable to retrieve spreadsheet: %w", err)
+	}
+	return toSpreadsheetInfo(spreadsheet), nil
+}
+
+// GetSpreadsheetInfo returns the metadata of a spreadsheet and its tabs.
+func GetSpreadsheetInfo(ctx context.Context, config auth.Config, spreadsheetID string) (*SpreadsheetInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	spreadsheet, err := sheetsService.Spreadsheets.Get(spreadsheetID).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: unable to retrieve spreadsheet: %w", err)
+	}
+	return toSpreadsheetInfo(spreadsheet), nil
+}
+
+// toSpreadsheetInfo converts a spreadsheet into a SpreadsheetInfo.
+func toSpreadsheetInfo(spreadsheet *sheets.Spreadsheet) *SpreadsheetInfo {
+	info := &SpreadsheetInfo{
+		ID:  spreadsheet.SpreadsheetId,
+		URL: spreadsheet.SpreadsheetUrl,
+	}
+	if spreadsheet.Properties != nil {
+		info.Title = spreadsheet.Properties.Title
+		info.Locale = spreadsheet.Properties.Locale
+		info.TimeZone = spreadsheet.Properties.TimeZone
+	}
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties == nil {
+			continue
+		}
+		sheetInfo := SheetInfo{
+			SheetID: sheet.Properties.SheetId,
+			Title:   sheet.Properties.Title,
+			Index:   sheet.Properties.Index,
+		}
+		if grid := sheet.Properties.GridProperties; grid != nil {
+			sheetInfo.RowCount = grid.RowCount
+			sheetInfo.ColumnCount = grid.ColumnCount
+		}
+		info.Sheets = append(info.Sheets, sheetInfo)
+	}
+	return info
+}
+
+// ReadOptions controls how ReadRangeValues renders cell values.
+type ReadOptions struct {
+	// ValueRender is "FORMATTED_VALUE" (the default, as displayed), "UNFORMATTED_VALUE" (numbers
+	// and booleans as such) or "FORMULA".
+	ValueRender string
+
+	// DateTimeRender is "SERIAL_NUMBER" (the default) or "FORMATTED_STRING". It is ignored with
+	// the default FORMATTED_VALUE rendering.
+	DateTimeRender string
+
+	// MajorDimension is "ROWS" (the default) or "COLUMNS".
+	MajorDimension string
+}
+
+// ReadRange reads a range in A1 notation, e.g., "Sheet1!A1:D50", as the strings displayed in the
+// cells. Trailing empty rows and cells are omitted by the API, so rows may have different lengths.
+func ReadRange(ctx context.Context, config auth.Config, spreadsheetID, readRange string) ([][]string, error) {
+	values, err := ReadRangeValues(ctx, config, spreadsheetID, readRange, ReadOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, len(values))
+	for i, row := range values {
+		rows[i] = make([]string, len(row))
+		for j, cell := range row {
+			rows[i][j] = fmt.Sprint(cell)
+		}
+	}
+	return rows, nil
+}
+
+// ReadRangeValues reads a range in A1 notation. Depending on opts.ValueRender, cells hold
+// strings, float64 numbers or bools.
+func ReadRangeValues(ctx context.Context, config auth.Config, spreadsheetID, readRange string, opts ReadOptions) ([][]interface{}, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	call := sheetsService.Spreadsheets.Values.Get(spreadsheetID, readRange)
+	if opts.ValueRender != "" {
+		call = call.ValueRenderOption(opts.ValueRender)
+	}
+	if opts.DateTimeRender != "" {
+		call = call.DateTimeRenderOption(opts.DateTimeRender)
+	}
+	if opts.MajorDimension != "" {
+		call = call.MajorDimension(opts.MajorDimension)
+	}
+
+	valueRange, err := call.Do()
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: unable to read range %s: %w", readRange, err)
+	}
+	return valueRange.Values, nil
+}
+
+// Value input options for the write helpers.
+const (
+	// InputRaw stores values exactly as given, so "=SUM(A1:A3)" and "1/2" stay strings.
+	InputRaw = "RAW"
+	// InputUserEntered parses values as if typed into the UI, evaluating formulas, numbers and dates.
+	InputUserEntered = "USER_ENTERED"
+)
+
+// UpdateRange writes values into a range in A1 notation, starting at its top-left cell. Cells of the
+// range not covered by values keep their content; a nil value leaves its cell unchanged and an
+// empty string clears it. inputOption is InputRaw or InputUserEntered. It returns the number of
+// cells updated.
+func UpdateRange(ctx context.Context, config auth.Config, spreadsheetID, writeRange string, values [][]interface{}, inputOption string) (int64, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+	return updateRange(sheetsService, spreadsheetID, writeRange, values, inputOption)
+}
+
+// WriteRange replaces the content of a range in A1 notation with values: the range is cleared
+// first, so cells not covered by values end up empty. Formatting is kept. inputOption is InputRaw
+// or InputUserEntered. It returns the number of cells written.
+func WriteRange(ctx context.Context, config auth.Config, spreadsheetID, writeRange string, values [][]interface{}, inputOption string) (int64, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	_, err = sheetsService.Spreadsheets.Values.Clear(spreadsheetID, writeRange, &sheets.ClearValuesRequest{}).Do()
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to clear range %s: %w", writeRange, err)
+	}
+	return updateRange(sheetsService, spreadsheetID, writeRange, values, inputOption)
+}
+
+// updateRange writes values into a range with the given input option.
+func updateRange(sheetsService *sheets.Service, spreadsheetID, writeRange string, values [][]interface{}, inputOption string) (int64, error) {
+	if err := checkInputOption(inputOption); err != nil {
+		return 0, err
+	}
+
+	response, err := sheetsService.Spreadsheets.Values.Update(spreadsheetID, writeRange, &sheets.ValueRange{
+		Values: values,
+	}).ValueInputOption(inputOption).Do()
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to write range %s: %w", writeRange, err)
+	}
+	return response.UpdatedCells, nil
+}
+
+// checkInputOption returns an error unless inputOption is InputRaw or InputUserEntered.
+func checkInputOption(inputOption string) error {
+	if inputOption != InputRaw && inputOption != InputUserEntered {
+		return fmt.Errorf("gSheetsHelper: value input option must be %s or %s, got %q", InputRaw, InputUserEntered, inputOption)
+	}
+	return nil
+}
+
+// AppendOptions controls how AppendRows adds rows.
+type AppendOptions struct {
+	// InputOption is InputRaw or InputUserEntered, defaults to InputUserEntered.
+	InputOption string
+
+	// Overwrite writes into empty cells below the table instead of inserting new rows. By default
+	// rows are inserted, so content and formatting below the table are pushed down.
+	Overwrite bool
+}
+
+// AppendRows appends rows after the last row of the table found in the given tab. It returns the
+// A1 range that the rows were written to.
+func AppendRows(ctx context.Context, config auth.Config, spreadsheetID, sheetName string, rows [][]interface{}, opts AppendOptions) (string, error) {
+	inputOption := opts.InputOption
+	if inputOption == "" {
+		inputOption = InputUserEntered
+	}
+	if err := checkInputOption(inputOption); err != nil {
+		return "", err
+	}
+	insertOption := "INSERT_ROWS"
+	if opts.Overwrite {
+		insertOption = "OVERWRITE"
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	response, err := sheetsService.Spreadsheets.Values.Append(spreadsheetID, quoteSheetName(sheetName), &sheets.ValueRange{
+		Values: rows,
+	}).ValueInputOption(inputOption).InsertDataOption(insertOption).Do()
+	if err != nil {
+		return "", fmt.Errorf("gSheetsHelper: unable to append rows to %s: %w", sheetName, err)
+	}
+	if response.Updates == nil {
+		return "", nil
+	}
+	return response.Updates.UpdatedRange, nil
+}
+
+// quoteSheetName quotes a tab name for use in A1 notation, doubling any single quote in it.
+func quoteSheetName(sheetName string) string {
+	return "'" + strings.ReplaceAll(sheetName, "'", "''") + "'"
+}
+
+// BatchWriter collects range writes and sends them to a spreadsheet in a single request when
+// flushed, which uses one unit of write quota no matter how many ranges are written. It is safe
+// for concurrent use.
+type BatchWriter struct {
+	spreadsheetID string
+	inputOption   string
+
+	mu   sync.Mutex
+	data []*sheets.ValueRange
+}
+
+// NewBatchWriter returns a BatchWriter for the spreadsheet. inputOption is InputRaw or
+// InputUserEntered and applies to every range.
+func NewBatchWriter(spreadsheetID, inputOption string) (*BatchWriter, error) {
+	if err := checkInputOption(inputOption); err != nil {
+		return nil, err
+	}
+	return &BatchWriter{spreadsheetID: spreadsheetID, inputOption: inputOption}, nil
+}
+
+// Add queues values to be written into a range in A1 notation, with the same semantics as
+// UpdateRange. Nothing is sent until Flush is called.
+func (b *BatchWriter) Add(writeRange string, values [][]interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, &sheets.ValueRange{Range: writeRange, Values: values})
+}
+
+// Len returns the number of queued ranges.
+func (b *BatchWriter) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.data)
+}
+
+// Flush writes all queued ranges in one batchUpdate call and returns the number of cells updated.
+// The queue is emptied only if the call succeeds, so a failed Flush can be retried.
+func (b *BatchWriter) Flush(ctx context.Context, config auth.Config) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.data) == 0 {
+		return 0, nil
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	response, err := sheetsService.Spreadsheets.Values.BatchUpdate(b.spreadsheetID, &sheets.BatchUpdateValuesRequest{
+		ValueInputOption: b.inputOption,
+		Data:             b.data,
+	}).Do()
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to write %d ranges: %w", len(b.data), err)
+	}
+
+	b.data = nil
+	return response.TotalUpdatedCells, nil
+}
+
+// CellFormat describes formatting applied by FormatRange. Only the fields that are set are
+// changed; the rest of the cells' formatting is kept.
+type CellFormat struct {
+	BackgroundColor *sheets.Color
+	TextColor       *sheets.Color
+	Bold            *bool
+	Italic          *bool
+
+	// NumberFormatType is e.g. "NUMBER", "CURRENCY", "PERCENT", "DATE", "TIME", "DATE_TIME" or
+	// "TEXT". NumberFormatPattern optionally refines it, e.g., "#,##0.00" or "yyyy-mm-dd".
+	NumberFormatType    string
+	NumberFormatPattern string
+
+	WrapStrategy        string // "WRAP", "CLIP" or "OVERFLOW_CELL"
+	HorizontalAlignment string // "LEFT", "CENTER" or "RIGHT"
+}
+
+// FormatRange applies format to every cell of a range in A1 notation, e.g., "Sheet1!A1:D1".
+func FormatRange(ctx context.Context, config auth.Config, spreadsheetID, a1Range string, format CellFormat) error {
+	cellFormat := &sheets.CellFormat{}
+	var fields []string
+	if format.BackgroundColor != nil {
+		cellFormat.BackgroundColor = format.BackgroundColor
+		fields = append(fields, "userEnteredFormat.backgroundColor")
+	}
+	if format.TextColor != nil || format.Bold != nil || format.Italic != nil {
+		cellFormat.TextFormat = &sheets.TextFormat{}
+		if format.TextColor != nil {
+			cellFormat.TextFormat.ForegroundColor = format.TextColor
+			fields = append(fields, "userEnteredFormat.textFormat.foregroundColor")
+		}
+		if format.Bold != nil {
+			cellFormat.TextFormat.Bold = *format.Bold
+			cellFormat.TextFormat.ForceSendFields = append(cellFormat.TextFormat.ForceSendFields, "Bold")
+			fields = append(fields, "userEnteredFormat.textFormat.bold")
+		}
+		if format.Italic != nil {
+			cellFormat.TextFormat.Italic = *format.Italic
+			cellFormat.TextFormat.ForceSendFields = append(cellFormat.TextFormat.ForceSendFields, "Italic")
+			fields = append(fields, "userEnteredFormat.textFormat.italic")
+		}
+	}
+	if format.NumberFormatType != "" {
+		cellFormat.NumberFormat = &sheets.NumberFormat{
+			Type:    format.NumberFormatType,
+			Pattern: format.NumberFormatPattern,
+		}
+		fields = append(fields, "userEnteredFormat.numberFormat")
+	}
+	if format.WrapStrategy != "" {
+		cellFormat.WrapStrategy = format.WrapStrategy
+		fields = append(fields, "userEnteredFormat.wrapStrategy")
+	}
+	if format.HorizontalAlignment != "" {
+		cellFormat.HorizontalAlignment = format.HorizontalAlignment
+		fields = append(fields, "userEnteredFormat.horizontalAlignment")
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	gridRange, err := toGridRange(sheetsService, spreadsheetID, a1Range)
+	if err != nil {
+		return err
+	}
+
+	_, err = sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			RepeatCell: &sheets.RepeatCellRequest{
+				Range:  gridRange,
+				Cell:   &sheets.CellData{UserEnteredFormat: cellFormat},
+				Fields: strings.Join(fields, ","),
+			},
+		}},
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to format range %s: %w", a1Range, err)
+	}
+	return nil
+}
+
+// SetBackgroundColor sets the background color of a range in A1 notation.
+func SetBackgroundColor(ctx context.Context, config auth.Config, spreadsheetID, a1Range string, color *sheets.Color) error {
+	return FormatRange(ctx, config, spreadsheetID, a1Range, CellFormat{BackgroundColor: color})
+}
+
+// SetTextColor sets the text color of a range in A1 notation.
+func SetTextColor(ctx context.Context, config auth.Config, spreadsheetID, a1Range string, color *sheets.Color) error {
+	return FormatRange(ctx, config, spreadsheetID, a1Range, CellFormat{TextColor: color})
+}
+
+// SetBold makes the text of a range in A1 notation bold or regular.
+func SetBold(ctx context.Context, config auth.Config, spreadsheetID, a1Range string, bold bool) error {
+	return FormatRange(ctx, config, spreadsheetID, a1Range, CellFormat{Bold: &bold})
+}
+
+// SetNumberFormat sets the number or date format of a range in A1 notation, e.g., "DATE" with
+// pattern "yyyy-mm-dd". The pattern may be empty to use the locale's default for formatType.
+func SetNumberFormat(ctx context.Context, config auth.Config, spreadsheetID, a1Range, formatType, pattern string) error {
+	return FormatRange(ctx, config, spreadsheetID, a1Range, CellFormat{NumberFormatType: formatType, NumberFormatPattern: pattern})
+}
+
+// SetTextWrap enables or disables text wrapping in a range in A1 notation.
+func SetTextWrap(ctx context.Context, config auth.Config, spreadsheetID, a1Range string, wrap bool) error {
+	strategy := "OVERFLOW_CELL"
+	if wrap {
+		strategy = "WRAP"
+	}
+	return FormatRange(ctx, config, spreadsheetID, a1Range, CellFormat{WrapStrategy: strategy})
+}
+
+// a1Cell matches one end of an A1 range: a column, a row or both, e.g., "B", "7" or "B7".
+var a1Cell = regexp.MustCompile(`^([A-Za-z]*)([0-9]*)$`)
+
+// toGridRange converts a range in A1 notation into a GridRange, looking up the sheet ID of the
+// tab. A bare tab name covers the whole tab and a range without a tab name refers to the first tab.
+func toGridRange(sheetsService *sheets.Service, spreadsheetID, a1Range string) (*sheets.GridRange, error) {
+	spreadsheet, err := sheetsService.Spreadsheets.Get(spreadsheetID).Fields("sheets.properties(sheetId,title)").Do()
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: unable to retrieve spreadsheet: %w", err)
+	}
+	if len(spreadsheet.Sheets) == 0 {
+		return nil, fmt.Errorf("gSheetsHelper: spreadsheet %s has no tabs", spreadsheetID)
+	}
+	gridRange, err := parseGridRange(spreadsheet.Sheets, a1Range)
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: spreadsheet %s: %w", spreadsheetID, err)
+	}
+	return gridRange, nil
+}
+
+// parseGridRange converts a range in A1 notation into a GridRange on one of tabs, which must
+// not be empty.
+func parseGridRange(tabs []*sheets.Sheet, a1Range string) (*sheets.GridRange, error) {
+	sheetName, cells := a1Range, ""
+	if index := strings.LastIndex(a1Range, "!"); index >= 0 {
+		sheetName, cells = a1Range[:index], a1Range[index+1:]
+	}
+	sheetName = unquoteSheetName(sheetName)
+
+	gridRange := &sheets.GridRange{ForceSendFields: []string{"SheetId"}}
+	found := false
+	for _, sheet := range tabs {
+		if sheet.Properties.Title == sheetName {
+			gridRange.SheetId = sheet.Properties.SheetId
+			found = true
+			break
+		}
+	}
+	if !found {
+		if cells != "" {
+			return nil, fmt.Errorf("no tab named %q", sheetName)
+		}
+		// Not a tab name, so a cell range on the first tab
+		gridRange.SheetId = tabs[0].Properties.SheetId
+		cells = a1Range
+	}
+	if cells == "" {
+		return gridRange, nil
+	}
+
+	start, end, isRange := strings.Cut(cells, ":")
+	if !isRange {
+		end = start
+	}
+	startMatch := a1Cell.FindStringSubmatch(start)
+	endMatch := a1Cell.FindStringSubmatch(end)
+	if startMatch == nil || endMatch == nil || start == "" || end == "" {
+		return nil, fmt.Errorf("invalid A1 range %q", a1Range)
+	}
+
+	if startMatch[1] != "" {
+		gridRange.StartColumnIndex = columnIndex(startMatch[1])
+		gridRange.ForceSendFields = append(gridRange.ForceSendFields, "StartColumnIndex")
+	}
+	if endMatch[1] != "" {
+		gridRange.EndColumnIndex = columnIndex(endMatch[1]) + 1
+	}
+	if startMatch[2] != "" {
+		row, _ := strconv.ParseInt(startMatch[2], 10, 64)
+		gridRange.StartRowIndex = row - 1
+		gridRange.ForceSendFields = append(gridRange.ForceSendFields, "StartRowIndex")
+	}
+	if endMatch[2] != "" {
+		row, _ := strconv.ParseInt(endMatch[2], 10, 64)
+		gridRange.EndRowIndex = row
+	}
+	return gridRange, nil
+}
+
+// unquoteSheetName reverses quoteSheetName.
+func unquoteSheetName(sheetName string) string {
+	if len(sheetName) >= 2 && strings.HasPrefix(sheetName, "'") && strings.HasSuffix(sheetName, "'") {
+		return strings.ReplaceAll(sheetName[1:len(sheetName)-1], "''", "'")
+	}
+	return sheetName
+}
+
+// columnIndex converts column letters into a zero-based index, e.g., "A" is 0 and "AA" is 26.
+func columnIndex(letters string) int64 {
+	var index int64
+	for _, letter := range strings.ToUpper(letters) {
+		index = index*26 + int64(letter-'A'+1)
+	}
+	return index - 1
+}
+
+// ChartSpec describes a chart added by AddChart.
+type ChartSpec struct {
+	// Type is "LINE", "BAR", "COLUMN", "AREA" or "PIE".
+	Type  string
+	Title string
+
+	// DataRange is the data in A1 notation, e.g., "Sheet1!A1:C13". Its first column holds the
+	// labels (x-axis or pie slices) and every other column is a series. The first row is used as
+	// headers. A pie chart uses only the second column.
+	DataRange string
+
+	// AnchorCell is the cell in A1 notation where the top-left corner of the chart is placed,
+	// e.g., "Sheet1!E2". When empty, the chart is placed on a new tab of its own.
+	AnchorCell string
+
+	// WidthPixels and HeightPixels set the size of the chart, defaulting to 600x371.
+	WidthPixels  int64
+	HeightPixels int64
+}
+
+// AddChart adds a chart to a spreadsheet and returns its chart ID, which can be used to embed the
+// chart in Google Docs or Slides.
+func AddChart(ctx context.Context, config auth.Config, spreadsheetID string, spec ChartSpec) (int64, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	data, err := toGridRange(sheetsService, spreadsheetID, spec.DataRange)
+	if err != nil {
+		return 0, err
+	}
+	if data.EndColumnIndex-data.StartColumnIndex < 2 {
+		return 0, fmt.Errorf("gSheetsHelper: chart data range %s needs a label column and at least one series column", spec.DataRange)
+	}
+
+	// column returns the given column of the data range
+	column := func(index int64) *sheets.ChartData {
+		return &sheets.ChartData{SourceRange: &sheets.ChartSourceRange{Sources: []*sheets.GridRange{{
+			SheetId:          data.SheetId,
+			StartRowIndex:    data.StartRowIndex,
+			EndRowIndex:      data.EndRowIndex,
+			StartColumnIndex: index,
+			EndColumnIndex:   index + 1,
+			ForceSendFields:  []string{"SheetId", "StartRowIndex", "StartColumnIndex"},
+		}}}}
+	}
+
+	chartSpec := &sheets.ChartSpec{Title: spec.Title}
+	switch spec.Type {
+	case "PIE":
+		chartSpec.PieChart = &sheets.PieChartSpec{
+			LegendPosition: "RIGHT_LEGEND",
+			Domain:         column(data.StartColumnIndex),
+			Series:         column(data.StartColumnIndex + 1),
+		}
+	case "LINE", "BAR", "COLUMN", "AREA":
+		basicChart := &sheets.BasicChartSpec{
+			ChartType:      spec.Type,
+			LegendPosition: "BOTTOM_LEGEND",
+			HeaderCount:    1,
+			Domains:        []*sheets.BasicChartDomain{{Domain: column(data.StartColumnIndex)}},
+		}
+		for index := data.StartColumnIndex + 1; index < data.EndColumnIndex; index++ {
+			targetAxis := "LEFT_AXIS"
+			if spec.Type == "BAR" {
+				targetAxis = "BOTTOM_AXIS" // bars are horizontal
+			}
+			basicChart.Series = append(basicChart.Series, &sheets.BasicChartSeries{
+				Series:     column(index),
+				TargetAxis: targetAxis,
+			})
+		}
+		chartSpec.BasicChart = basicChart
+	default:
+		return 0, fmt.Errorf("gSheetsHelper: unsupported chart type %q", spec.Type)
+	}
+
+	position := &sheets.EmbeddedObjectPosition{NewSheet: true}
+	if spec.AnchorCell != "" {
+		anchor, err := toGridRange(sheetsService, spreadsheetID, spec.AnchorCell)
+		if err != nil {
+			return 0, err
+		}
+		width, height := spec.WidthPixels, spec.HeightPixels
+		if width <= 0 {
+			width = 600
+		}
+		if height <= 0 {
+			height = 371
+		}
+		position = &sheets.EmbeddedObjectPosition{OverlayPosition: &sheets.OverlayPosition{
+			AnchorCell: &sheets.GridCoordinate{
+				SheetId:         anchor.SheetId,
+				RowIndex:        anchor.StartRowIndex,
+				ColumnIndex:     anchor.StartColumnIndex,
+				ForceSendFields: []string{"SheetId", "RowIndex", "ColumnIndex"},
+			},
+			WidthPixels:  width,
+			HeightPixels: height,
+		}}
+	}
+
+	response, err := sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			AddChart: &sheets.AddChartRequest{Chart: &sheets.EmbeddedChart{
+				Spec:     chartSpec,
+				Position: position,
+			}},
+		}},
+	}).Do()
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to add chart: %w", err)
+	}
+	return response.Replies[0].AddChart.Chart.ChartId, nil
+}
+
+// AddNamedRange defines a named range, e.g., "TaxRate" for "Settings!B2", that formulas can
+// refer to by name. It returns the ID of the named range.
+func AddNamedRange(ctx context.Context, config auth.Config, spreadsheetID, name, a1Range string) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	gridRange, err := toGridRange(sheetsService, spreadsheetID, a1Range)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			AddNamedRange: &sheets.AddNamedRangeRequest{
+				NamedRange: &sheets.NamedRange{Name: name, Range: gridRange},
+			},
+		}},
+	}).Do()
+	if err != nil {
+		return "", fmt.Errorf("gSheetsHelper: unable to add named range %s: %w", name, err)
+	}
+	return response.Replies[0].AddNamedRange.NamedRange.NamedRangeId, nil
+}
+
+// DeleteNamedRange removes the named range with the given name. The cells are left unchanged.
+func DeleteNamedRange(ctx context.Context, config auth.Config, spreadsheetID, name string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	spreadsheet, err := sheetsService.Spreadsheets.Get(spreadsheetID).Fields("namedRanges(namedRangeId,name)").Do()
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to retrieve spreadsheet: %w", err)
+	}
+
+	namedRangeID := ""
+	for _, namedRange := range spreadsheet.NamedRanges {
+		if namedRange.Name == name {
+			namedRangeID = namedRange.NamedRangeId
+			break
+		}
+	}
+	if namedRangeID == "" {
+		return fmt.Errorf("gSheetsHelper: spreadsheet %s has no named range %q", spreadsheetID, name)
+	}
+
+	_, err = sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			DeleteNamedRange: &sheets.DeleteNamedRangeRequest{NamedRangeId: namedRangeID},
+		}},
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to delete named range %s: %w", name, err)
+	}
+	return nil
+}
+
+// ProtectionOptions controls who can edit a range protected by ProtectRange.
+type ProtectionOptions struct {
+	Description string
+
+	// Editors and EditorGroups list the users and groups allowed to edit the range. The owner of
+	// the spreadsheet can always edit it.
+	Editors      []string
+	EditorGroups []string
+
+	// DomainUsersCanEdit lets everyone in the spreadsheet owner's domain edit the range.
+	DomainUsersCanEdit bool
+
+	// WarningOnly lets anyone edit the range after confirming a warning, instead of blocking edits.
+	// No editors may be set.
+	WarningOnly bool
+}
+
+// ProtectRange protects a range in A1 notation, or a whole tab when given only its name, so that
+// only the allowed editors can change it. It returns the ID of the protected range.
+func ProtectRange(ctx context.Context, config auth.Config, spreadsheetID, a1Range string, opts ProtectionOptions) (int64, error) {
+	if opts.WarningOnly && (len(opts.Editors) > 0 || len(opts.EditorGroups) > 0 || opts.DomainUsersCanEdit) {
+		return 0, fmt.Errorf("gSheetsHelper: warning-only protection cannot have editors")
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	gridRange, err := toGridRange(sheetsService, spreadsheetID, a1Range)
+	if err != nil {
+		return 0, err
+	}
+
+	protectedRange := &sheets.ProtectedRange{
+		Range:       gridRange,
+		Description: opts.Description,
+		WarningOnly: opts.WarningOnly,
+	}
+	if !opts.WarningOnly {
+		protectedRange.Editors = &sheets.Editors{
+			Users:              opts.Editors,
+			Groups:             opts.EditorGroups,
+			DomainUsersCanEdit: opts.DomainUsersCanEdit,
+		}
+	}
+
+	response, err := sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			AddProtectedRange: &sheets.AddProtectedRangeRequest{ProtectedRange: protectedRange},
+		}},
+	}).Do()
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to protect range %s: %w", a1Range, err)
+	}
+	return response.Replies[0].AddProtectedRange.ProtectedRange.ProtectedRangeId, nil
+}
+
+// UnprotectRange removes a protection added by ProtectRange.
+func UnprotectRange(ctx context.Context, config auth.Config, spreadsheetID string, protectedRangeID int64) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	_, err = sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			DeleteProtectedRange: &sheets.DeleteProtectedRangeRequest{
+				ProtectedRangeId: protectedRangeID,
+				ForceSendFields:  []string{"ProtectedRangeId"},
+			},
+		}},
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to remove protected range %d: %w", protectedRangeID, err)
+	}
+	return nil
+}
+
+// SetDropdown restricts the cells of a range in A1 notation to one of values, shown as a
+// dropdown. When strict is false, other values are accepted with a warning.
+func SetDropdown(ctx context.Context, config auth.Config, spreadsheetID, a1Range string, values []string, strict bool) error {
+	if len(values) == 0 {
+		return fmt.Errorf("gSheetsHelper: dropdown needs at least one value")
+	}
+
+	condition := &sheets.BooleanCondition{Type: "ONE_OF_LIST"}
+	for _, value := range values {
+		condition.Values = append(condition.Values, &sheets.ConditionValue{UserEnteredValue: value})
+	}
+	return setDataValidation(ctx, config, spreadsheetID, a1Range, &sheets.DataValidationRule{
+		Condition:    condition,
+		ShowCustomUi: true,
+		Strict:       strict,
+	})
+}
+
+// SetDropdownFromRange restricts the cells of a range to the values found in sourceRange, e.g.,
+// "Lists!A2:A20", shown as a dropdown. Editing the source range updates the choices.
+func SetDropdownFromRange(ctx context.Context, config auth.Config, spreadsheetID, a1Range, sourceRange string, strict bool) error {
+	return setDataValidation(ctx, config, spreadsheetID, a1Range, &sheets.DataValidationRule{
+		Condition: &sheets.BooleanCondition{
+			Type:   "ONE_OF_RANGE",
+			Values: []*sheets.ConditionValue{{UserEnteredValue: "=" + sourceRange}},
+		},
+		ShowCustomUi: true,
+		Strict:       strict,
+	})
+}
+
+// SetCheckbox turns the cells of a range in A1 notation into checkboxes holding TRUE or FALSE.
+func SetCheckbox(ctx context.Context, config auth.Config, spreadsheetID, a1Range string) error {
+	return setDataValidation(ctx, config, spreadsheetID, a1Range, &sheets.DataValidationRule{
+		Condition: &sheets.BooleanCondition{Type: "BOOLEAN"},
+		Strict:    true,
+	})
+}
+
+// ClearDataValidation removes dropdowns, checkboxes and any other data validation from a range.
+func ClearDataValidation(ctx context.Context, config auth.Config, spreadsheetID, a1Range string) error {
+	return setDataValidation(ctx, config, spreadsheetID, a1Range, nil)
+}
+
+// setDataValidation sets the data validation rule of every cell in a range; a nil rule clears it.
+func setDataValidation(ctx context.Context, config auth.Config, spreadsheetID, a1Range string, rule *sheets.DataValidationRule) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	gridRange, err := toGridRange(sheetsService, spreadsheetID, a1Range)
+	if err != nil {
+		return err
+	}
+
+	_, err = sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			SetDataValidation: &sheets.SetDataValidationRequest{Range: gridRange, Rule: rule},
+		}},
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to set data validation on %s: %w", a1Range, err)
+	}
+	return nil
+}
+
+// csvChunkRows is the number of CSV rows written per request by ImportCSV.
+const csvChunkRows = 10000
+
+// ImportCSV replaces the content of a tab with CSV data read from r, creating the tab if it does
+// not exist. inputOption is InputRaw to store every field as text or InputUserEntered to parse
+// numbers, dates and formulas. It returns the number of rows imported.
+func ImportCSV(ctx context.Context, config auth.Config, spreadsheetID, sheetName string, r io.Reader, inputOption string) (int, error) {
+	if err := checkInputOption(inputOption); err != nil {
+		return 0, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // rows may have different lengths
+	records, err := reader.ReadAll()
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to parse CSV: %w", err)
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	spreadsheet, err := sheetsService.Spreadsheets.Get(spreadsheetID).Fields("sheets.properties.title").Do()
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to retrieve spreadsheet: %w", err)
+	}
+	exists := false
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties.Title == sheetName {
+			exists = true
+			break
+		}
+	}
+
+	if exists {
+		_, err = sheetsService.Spreadsheets.Values.Clear(spreadsheetID, quoteSheetName(sheetName), &sheets.ClearValuesRequest{}).Do()
+		if err != nil {
+			return 0, fmt.Errorf("gSheetsHelper: unable to clear tab %s: %w", sheetName, err)
+		}
+	} else {
+		_, err = sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+			Requests: []*sheets.Request{{
+				AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: sheetName}},
+			}},
+		}).Do()
+		if err != nil {
+			return 0, fmt.Errorf("gSheetsHelper: unable to add tab %s: %w", sheetName, err)
+		}
+	}
+
+	// Values are written in chunks to stay below the request size limit; the grid grows as needed
+	for start := 0; start < len(records); start += csvChunkRows {
+		end := min(start+csvChunkRows, len(records))
+		values := make([][]interface{}, 0, end-start)
+		for _, record := range records[start:end] {
+			row := make([]interface{}, len(record))
+			for i, field := range record {
+				row[i] = field
+			}
+			values = append(values, row)
+		}
+
+		writeRange := fmt.Sprintf("%s!A%d", quoteSheetName(sheetName), start+1)
+		_, err = sheetsService.Spreadsheets.Values.Update(spreadsheetID, writeRange, &sheets.ValueRange{
+			Values: values,
+		}).ValueInputOption(inputOption).Do()
+		if err != nil {
+			return start, fmt.Errorf("gSheetsHelper: unable to write CSV rows %d-%d: %w", start+1, end, err)
+		}
+	}
+	return len(records), nil
+}
+
+// ExportSpreadsheetXLSX writes the whole spreadsheet to w as an Excel (.xlsx) file. Drive limits
+// exports to 10 MB.
+func ExportSpreadsheetXLSX(ctx context.Context, config auth.Config, spreadsheetID string, w io.Writer) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to create drive service: %w", err)
+	}
+
+	response, err := driveService.Files.Export(spreadsheetID, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet").Download()
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to export spreadsheet: %w", err)
+	}
+	defer response.Body.Close()
+
+	if _, err := io.Copy(w, response.Body); err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to write exported spreadsheet: %w", err)
+	}
+	return nil
+}
+
+// ExportSheetCSV writes one tab to w as CSV, with the values as they are displayed in the cells.
+// Rows are padded so that every record has the same number of fields.
+func ExportSheetCSV(ctx context.Context, config auth.Config, spreadsheetID, sheetName string, w io.Writer) error {
+	rows, err := ReadRange(ctx, config, spreadsheetID, quoteSheetName(sheetName))
+	if err != nil {
+		return err
+	}
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+
+	writer := csv.NewWriter(w)
+	for _, row := range rows {
+		record := make([]string, width)
+		copy(record, row)
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("gSheetsHelper: unable to write CSV: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to write CSV: %w", err)
+	}
+	return nil
+}
+
+// AddSheet adds an empty tab at the end of the spreadsheet and returns its sheet ID.
+func AddSheet(ctx context.Context, config auth.Config, spreadsheetID, title string) (int64, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	response, err := sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: title}},
+		}},
+	}).Do()
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to add tab %s: %w", title, err)
+	}
+	return response.Replies[0].AddSheet.Properties.SheetId, nil
+}
+
+// DeleteSheet deletes a tab and its content.
+func DeleteSheet(ctx context.Context, config auth.Config, spreadsheetID, sheetName string) error {
+	_, err := updateSheet(ctx, config, spreadsheetID, sheetName, func(sheetID int64) *sheets.Request {
+		return &sheets.Request{DeleteSheet: &sheets.DeleteSheetRequest{
+			SheetId:         sheetID,
+			ForceSendFields: []string{"SheetId"},
+		}}
+	})
+	return err
+}
+
+// RenameSheet renames a tab.
+func RenameSheet(ctx context.Context, config auth.Config, spreadsheetID, sheetName, newName string) error {
+	_, err := updateSheet(ctx, config, spreadsheetID, sheetName, func(sheetID int64) *sheets.Request {
+		return &sheets.Request{UpdateSheetProperties: &sheets.UpdateSheetPropertiesRequest{
+			Properties: &sheets.SheetProperties{
+				SheetId:         sheetID,
+				Title:           newName,
+				ForceSendFields: []string{"SheetId"},
+			},
+			Fields: "title",
+		}}
+	})
+	return err
+}
+
+// DuplicateSheet copies a tab, with its content and formatting, into a new tab named newName
+// placed right after it. It returns the sheet ID of the copy.
+func DuplicateSheet(ctx context.Context, config auth.Config, spreadsheetID, sheetName, newName string) (int64, error) {
+	response, err := updateSheet(ctx, config, spreadsheetID, sheetName, func(sheetID int64) *sheets.Request {
+		return &sheets.Request{DuplicateSheet: &sheets.DuplicateSheetRequest{
+			SourceSheetId:   sheetID,
+			NewSheetName:    newName,
+			ForceSendFields: []string{"SourceSheetId"},
+		}}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return response.DuplicateSheet.Properties.SheetId, nil
+}
+
+// FreezeRowsColumns keeps the first rows and columns of a tab visible while scrolling. Zero
+// unfreezes them.
+func FreezeRowsColumns(ctx context.Context, config auth.Config, spreadsheetID, sheetName string, rows, columns int64) error {
+	_, err := updateSheet(ctx, config, spreadsheetID, sheetName, func(sheetID int64) *sheets.Request {
+		return &sheets.Request{UpdateSheetProperties: &sheets.UpdateSheetPropertiesRequest{
+			Properties: &sheets.SheetProperties{
+				SheetId: sheetID,
+				GridProperties: &sheets.GridProperties{
+					FrozenRowCount:    rows,
+					FrozenColumnCount: columns,
+					ForceSendFields:   []string{"FrozenRowCount", "FrozenColumnCount"},
+				},
+				ForceSendFields: []string{"SheetId"},
+			},
+			Fields: "gridProperties.frozenRowCount,gridProperties.frozenColumnCount",
+		}}
+	})
+	return err
+}
+
+// SetColumnWidth sets the width in pixels of the columns of a range in A1 notation, e.g.,
+// "Sheet1!B:D".
+func SetColumnWidth(ctx context.Context, config auth.Config, spreadsheetID, a1Columns string, pixels int64) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	gridRange, err := toGridRange(sheetsService, spreadsheetID, a1Columns)
+	if err != nil {
+		return err
+	}
+
+	_, err = sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			UpdateDimensionProperties: &sheets.UpdateDimensionPropertiesRequest{
+				Range: &sheets.DimensionRange{
+					SheetId:         gridRange.SheetId,
+					Dimension:       "COLUMNS",
+					StartIndex:      gridRange.StartColumnIndex,
+					EndIndex:        gridRange.EndColumnIndex,
+					ForceSendFields: []string{"SheetId", "StartIndex"},
+				},
+				Properties: &sheets.DimensionProperties{PixelSize: pixels},
+				Fields:     "pixelSize",
+			},
+		}},
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to set column width of %s: %w", a1Columns, err)
+	}
+	return nil
+}
+
+// MergeCells merges the cells of a range in A1 notation. mergeType is "MERGE_ALL" (one cell),
+// "MERGE_ROWS" (one cell per row) or "MERGE_COLUMNS" (one cell per column). Only the value of the
+// top-left cell of each merged cell is kept.
+func MergeCells(ctx context.Context, config auth.Config, spreadsheetID, a1Range, mergeType string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	gridRange, err := toGridRange(sheetsService, spreadsheetID, a1Range)
+	if err != nil {
+		return err
+	}
+
+	_, err = sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			MergeCells: &sheets.MergeCellsRequest{Range: gridRange, MergeType: mergeType},
+		}},
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("gSheetsHelper: unable to merge cells %s: %w", a1Range, err)
+	}
+	return nil
+}
+
+// CopySheetToSpreadsheet copies a tab into another spreadsheet, where it is named "Copy of" the
+// original. It returns the sheet ID of the new tab in the destination spreadsheet.
+func CopySheetToSpreadsheet(ctx context.Context, config auth.Config, spreadsheetID, sheetName, destSpreadsheetID string) (int64, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	sheetID, err := lookupSheetID(sheetsService, spreadsheetID, sheetName)
+	if err != nil {
+		return 0, err
+	}
+
+	properties, err := sheetsService.Spreadsheets.Sheets.CopyTo(spreadsheetID, sheetID, &sheets.CopySheetToAnotherSpreadsheetRequest{
+		DestinationSpreadsheetId: destSpreadsheetID,
+	}).Do()
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to copy tab %s: %w", sheetName, err)
+	}
+	return properties.SheetId, nil
+}
+
+// updateSheet looks up the sheet ID of a tab and sends the request built by build for it,
+// returning the reply.
+func updateSheet(ctx context.Context, config auth.Config, spreadsheetID, sheetName string, build func(sheetID int64) *sheets.Request) (*sheets.Response, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: unable to create sheets service: %w", err)
+	}
+
+	sheetID, err := lookupSheetID(sheetsService, spreadsheetID, sheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{build(sheetID)},
+	}).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gSheetsHelper: unable to update tab %s: %w", sheetName, err)
+	}
+	return response.Replies[0], nil
+}
+
+// lookupSheetID returns the sheet ID of the tab with the given title.
+func lookupSheetID(sheetsService *sheets.Service, spreadsheetID, sheetName string) (int64, error) {
+	spreadsheet, err := sheetsService.Spreadsheets.Get(spreadsheetID).Fields("sheets.properties(sheetId,title)").Do()
+	if err != nil {
+		return 0, fmt.Errorf("gSheetsHelper: unable to retrieve spreadsheet: %w", err)
+	}
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties.Title == sheetName {
+			return sheet.Properties.SheetId, nil
+		}
+	}
+	return 0, fmt.Errorf("gSheetsHelper: spreadsheet %s has no tab named %q", spreadsheetID, sheetName)
+}
+
+// timeLayouts are the formats accepted for time.Time fields, tried in order.
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02", "2006/01/02", "1/2/2006 15:04:05", "1/2/2006"}
+
+// structField is a struct field mapped to a column.
+type structField struct {
+	column string
+	index  int
+}
+
+// ReadRowsInto reads the rows of a tab into dest, which must be a pointer to a slice of structs
+// or of pointers to structs. The first row is the header: each exported field is filled from the
+// column named in its `sheets:"..."` tag, or from the column named after the field when it has no
+// tag, matched case-insensitively. Fields tagged `sheets:"-"` are skipped. Strings, integers,
+// floats, bools, time.Time and pointers to them are supported; an empty cell leaves a pointer nil.
+// Empty rows are skipped and columns without a matching field are ignored.
+func ReadRowsInto(ctx context.Context, config auth.Config, spreadsheetID, sheetName string, dest interface{}) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("gSheetsHelper: destination must be a pointer to a slice, got %T", dest)
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("gSheetsHelper: destination must be a slice of structs, got %T", dest)
+	}
+
+	rows, err := ReadRange(ctx, config, spreadsheetID, quoteSheetName(sheetName))
+	if err != nil {
+		return err
+	}
+
+	result := reflect.MakeSlice(slice.Type(), 0, max(len(rows)-1, 0))
+	if len(rows) == 0 {
+		slice.Set(result)
+		return nil
+	}
+
+	// columns[i] is the field index for column i of the header, or -1
+	fields := structFields(structType)
+	columns := make([]int, len(rows[0]))
+	for i, header := range rows[0] {
+		columns[i] = -1
+		for _, field := range fields {
+			if strings.EqualFold(strings.TrimSpace(header), field.column) {
+				columns[i] = field.index
+				break
+			}
+		}
+	}
+
+	for rowIndex, row := range rows[1:] {
+		if isEmptyRow(row) {
+			continue
+		}
+		item := reflect.New(structType).Elem()
+		for i, cell := range row {
+			if i >= len(columns) || columns[i] < 0 {
+				continue
+			}
+			if err := setCell(item.Field(columns[i]), cell); err != nil {
+				// rowIndex is zero-based and skips the header
+				return fmt.Errorf("gSheetsHelper: row %d, column %q: %w", rowIndex+2, rows[0][i], err)
+			}
+		}
+		if elemType.Kind() == reflect.Pointer {
+			item = item.Addr()
+		}
+		result = reflect.Append(result, item)
+	}
+	slice.Set(result)
+	return nil
+}
+
+// AppendStructs appends rows, a slice of structs or of pointers to structs, to a tab. Fields are
+// mapped to the columns of the tab's header as in ReadRowsInto; when the tab is empty, a header
+// is written first from the struct fields. Fields without a matching column are not written.
+// Values are stored as they are (see InputRaw), so strings are never evaluated as formulas. It
+// returns the A1 range that the rows were written to.
+func AppendStructs(ctx context.Context, config auth.Config, spreadsheetID, sheetName string, rows interface{}) (string, error) {
+	slice := reflect.ValueOf(rows)
+	if slice.Kind() != reflect.Slice {
+		return "", fmt.Errorf("gSheetsHelper: rows must be a slice, got %T", rows)
+	}
+	structType := slice.Type().Elem()
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return "", fmt.Errorf("gSheetsHelper: rows must be a slice of structs, got %T", rows)
+	}
+	if slice.Len() == 0 {
+		return "", nil
+	}
+	fields := structFields(structType)
+
+	headerRows, err := ReadRange(ctx, config, spreadsheetID, quoteSheetName(sheetName)+"!1:1")
+	if err != nil {
+		return "", err
+	}
+	var header []string
+	if len(headerRows) > 0 {
+		header = headerRows[0]
+	}
+	if isEmptyRow(header) {
+		header = nil
+		headerRow := make([]interface{}, 0, len(fields))
+		for _, field := range fields {
+			header = append(header, field.column)
+			headerRow = append(headerRow, field.column)
+		}
+		_, err = UpdateRange(ctx, config, spreadsheetID, quoteSheetName(sheetName)+"!A1", [][]interface{}{headerRow}, InputRaw)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	values := make([][]interface{}, 0, slice.Len())
+	for i := 0; i < slice.Len(); i++ {
+		item := slice.Index(i)
+		if item.Kind() == reflect.Pointer {
+			if item.IsNil() {
+				continue
+			}
+			item = item.Elem()
+		}
+
+		row := make([]interface{}, len(header))
+		for column, name := range header {
+			row[column] = ""
+			for _, field := range fields {
+				if strings.EqualFold(strings.TrimSpace(name), field.column) {
+					row[column] = cellValue(item.Field(field.index))
+					break
+				}
+			}
+		}
+		values = append(values, row)
+	}
+
+	return AppendRows(ctx, config, spreadsheetID, sheetName, values, AppendOptions{InputOption: InputRaw})
+}
+
+// structFields returns the fields of a struct type that are mapped to columns.
+func structFields(structType reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		column := field.Name
+		if tag, ok := field.Tag.Lookup("sheets"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				column = tag
+			}
+		}
+		fields = append(fields, structField{column: column, index: i})
+	}
+	return fields
+}
+
+// isEmptyRow reports whether every cell of row is blank.
+func isEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// setCell parses the displayed value of a cell into a struct field.
+func setCell(field reflect.Value, cell string) error {
+	cell = strings.TrimSpace(cell)
+	if field.Kind() == reflect.Pointer {
+		if cell == "" {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+	if cell == "" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	if field.Type() == reflect.TypeOf(time.Time{}) {
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, cell); err == nil {
+				field.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("unrecognized time %q", cell)
+	}
+
+	// Displayed numbers may use thousands separators
+	number := strings.ReplaceAll(cell, ",", "")
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(cell)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(number, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(number, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		divisor := 1.0
+		if strings.HasSuffix(number, "%") {
+			number, divisor = strings.TrimSuffix(number, "%"), 100
+		}
+		f, err := strconv.ParseFloat(number, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f / divisor)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// cellValue converts a struct field into a value for the Sheets API.
+func cellValue(field reflect.Value) interface{} {
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return ""
+		}
+		field = field.Elem()
+	}
+	if t, ok := field.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return field.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return field.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return field.Uint()
+	case reflect.Float32, reflect.Float64:
+		return field.Float()
+	case reflect.Bool:
+		return field.Bool()
+	default:
+		return fmt.Sprint(field.Interface())
+	}
+}
@@ -0,0 +1,188 @@
+package gSheetsHelper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestStructFields(t *testing.T) {
+	type row struct {
+		Name    string
+		Email   string `sheets:"E-mail"`
+		Skipped string `sheets:"-"`
+		Empty   string `sheets:""`
+		hidden  string
+		Age     int
+	}
+
+	want := []structField{
+		{column: "Name", index: 0},
+		{column: "E-mail", index: 1},
+		{column: "Empty", index: 3},
+		{column: "Age", index: 5},
+	}
+	if got := structFields(reflect.TypeOf(row{})); !reflect.DeepEqual(got, want) {
+		t.Fatalf("structFields = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetCell(t *testing.T) {
+	type fields struct {
+		String  string
+		Int     int
+		Int8    int8
+		Uint    uint
+		Float   float64
+		Bool    bool
+		Time    time.Time
+		IntPtr  *int
+		TimePtr *time.Time
+		Slice   []string
+	}
+	ten := 10
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		field   string
+		cell    string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "string is trimmed", field: "String", cell: "  hello ", want: "hello"},
+		{name: "integer with thousands separator", field: "Int", cell: "1,234", want: 1234},
+		{name: "negative integer", field: "Int", cell: "-5", want: -5},
+		{name: "integer overflow", field: "Int8", cell: "300", wantErr: true},
+		{name: "not an integer", field: "Int", cell: "1.5", wantErr: true},
+		{name: "unsigned integer", field: "Uint", cell: "42", want: uint(42)},
+		{name: "float", field: "Float", cell: "3.25", want: 3.25},
+		{name: "percentage", field: "Float", cell: "12.5%", want: 0.125},
+		{name: "bool", field: "Bool", cell: "TRUE", want: true},
+		{name: "not a bool", field: "Bool", cell: "yes", wantErr: true},
+		{name: "date", field: "Time", cell: "2024-03-01", want: day},
+		{name: "US date", field: "Time", cell: "3/1/2024", want: day},
+		{name: "unrecognized time", field: "Time", cell: "March first", wantErr: true},
+		{name: "empty cell zeroes the field", field: "Int", cell: " ", want: 0},
+		{name: "pointer", field: "IntPtr", cell: "10", want: &ten},
+		{name: "empty cell leaves a nil pointer", field: "IntPtr", cell: "", want: (*int)(nil)},
+		{name: "time pointer", field: "TimePtr", cell: "2024-03-01", want: &day},
+		{name: "unsupported type", field: "Slice", cell: "a", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var row fields
+			field := reflect.ValueOf(&row).Elem().FieldByName(tt.field)
+			err := setCell(field, tt.cell)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setCell(%q) error = %v, want error %v", tt.cell, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := field.Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("setCell(%q) = %v, want %v", tt.cell, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGridRange(t *testing.T) {
+	tabs := []*sheets.Sheet{
+		{Properties: &sheets.SheetProperties{SheetId: 0, Title: "Sheet1"}},
+		{Properties: &sheets.SheetProperties{SheetId: 42, Title: "Q1 Budget"}},
+		{Properties: &sheets.SheetProperties{SheetId: 7, Title: "Bob's"}},
+	}
+
+	tests := []struct {
+		name    string
+		a1Range string
+		want    *sheets.GridRange
+		wantErr bool
+	}{
+		{
+			name:    "bare tab",
+			a1Range: "Q1 Budget",
+			want:    &sheets.GridRange{SheetId: 42, ForceSendFields: []string{"SheetId"}},
+		},
+		{
+			name:    "quoted tab",
+			a1Range: "'Q1 Budget'!B2:D5",
+			want: &sheets.GridRange{
+				SheetId: 42, StartColumnIndex: 1, EndColumnIndex: 4, StartRowIndex: 1, EndRowIndex: 5,
+				ForceSendFields: []string{"SheetId", "StartColumnIndex", "StartRowIndex"},
+			},
+		},
+		{
+			name:    "quoted tab with escaped quote",
+			a1Range: "'Bob''s'!A1",
+			want: &sheets.GridRange{
+				SheetId: 7, StartColumnIndex: 0, EndColumnIndex: 1, StartRowIndex: 0, EndRowIndex: 1,
+				ForceSendFields: []string{"SheetId", "StartColumnIndex", "StartRowIndex"},
+			},
+		},
+		{
+			name:    "no tab refers to the first tab",
+			a1Range: "AA10",
+			want: &sheets.GridRange{
+				SheetId: 0, StartColumnIndex: 26, EndColumnIndex: 27, StartRowIndex: 9, EndRowIndex: 10,
+				ForceSendFields: []string{"SheetId", "StartColumnIndex", "StartRowIndex"},
+			},
+		},
+		{
+			name:    "whole columns",
+			a1Range: "A:C",
+			want: &sheets.GridRange{
+				SheetId: 0, StartColumnIndex: 0, EndColumnIndex: 3,
+				ForceSendFields: []string{"SheetId", "StartColumnIndex"},
+			},
+		},
+		{
+			name:    "open row range",
+			a1Range: "Sheet1!A2:C",
+			want: &sheets.GridRange{
+				SheetId: 0, StartColumnIndex: 0, EndColumnIndex: 3, StartRowIndex: 1,
+				ForceSendFields: []string{"SheetId", "StartColumnIndex", "StartRowIndex"},
+			},
+		},
+		{
+			name:    "whole rows",
+			a1Range: "Sheet1!3:4",
+			want: &sheets.GridRange{
+				SheetId: 0, StartRowIndex: 2, EndRowIndex: 4,
+				ForceSendFields: []string{"SheetId", "StartRowIndex"},
+			},
+		},
+		{name: "unknown tab", a1Range: "Missing!A1", wantErr: true},
+		{name: "malformed cell", a1Range: "Sheet1!A1B", wantErr: true},
+		{name: "empty end", a1Range: "Sheet1!A1:", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGridRange(tabs, tt.a1Range)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseGridRange(%q) = %+v, want an error", tt.a1Range, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGridRange(%q): %v", tt.a1Range, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseGridRange(%q) = %+v, want %+v", tt.a1Range, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	tests := map[string]int64{"A": 0, "z": 25, "AA": 26, "AZ": 51, "BA": 52, "ZZ": 701, "AAA": 702}
+	for letters, want := range tests {
+		if got := columnIndex(letters); got != want {
+			t.Errorf("columnIndex(%q) = %d, want %d", letters, got, want)
+		}
+	}
+}
@@ -0,0 +1,1153 @@
+package gSlidesHelper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/gnzdotmx/gworkspace-helper/auth"
+	"github.com/google/uuid"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+	"google.golang.org/api/slides/v1"
+)
+
+// SlideInfo describes a slide of a presentation.
+type SlideInfo struct {
+	ObjectID       string
+	Index          int
+	LayoutObjectID string
+	ElementCount   int
+}
+
+// PresentationInfo holds the metadata of a presentation.
+type PresentationInfo struct {
+	ID         string
+	Title      string
+	URL        string
+	Locale     string
+	PageWidth  float64 // in points
+	PageHeight float64 // in points
+	Slides     []SlideInfo
+}
+
+// CreatePresentation creates a new presentation with the given title. When folderID is set, the
+// presentation is created in that folder, which may be in a shared drive; otherwise it is created
+// in My Drive.
+func CreatePresentation(ctx context.Context, config auth.Config, title, folderID string) (*PresentationInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	if folderID == "" {
+		presentation, err := slidesService.Presentations.Create(&slides.Presentation{Title: title}).Do()
+		if err != nil {
+			return nil, fmt.Errorf("gSlidesHelper: unable to create presentation: %w", err)
+		}
+		return toPresentationInfo(presentation), nil
+	}
+
+	// The Slides API cannot choose the parent folder, so the file is created through Drive
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: unable to create drive service: %w", err)
+	}
+	file, err := driveService.Files.Create(&drive.File{
+		Name:     title,
+		MimeType: "application/vnd.google-apps.presentation",
+		Parents:  []string{folderID},
+	}).Fields("id").SupportsAllDrives(true).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: unable to create presentation: %w", err)
+	}
+
+	presentation, err := slidesService.Presentations.Get(file.Id).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: unable to retrieve presentation: %w", err)
+	}
+	return toPresentationInfo(presentation), nil
+}
+
+// GetPresentationInfo returns the metadata of a presentation and its slides.
+func GetPresentationInfo(ctx context.Context, config auth.Config, presentationID string) (*PresentationInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	presentation, err := slidesService.Presentations.Get(presentationID).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: unable to retrieve presentation: %w", err)
+	}
+	return toPresentationInfo(presentation), nil
+}
+
+// GetPresentationURL returns the URL of a presentation given its ID.
+func GetPresentationURL(presentationID string) string {
+	return fmt.Sprintf("https://docs.google.com/presentation/d/%s/edit", presentationID)
+}
+
+// toPresentationInfo converts a presentation into a PresentationInfo.
+func toPresentationInfo(presentation *slides.Presentation) *PresentationInfo {
+	info := &PresentationInfo{
+		ID:     presentation.PresentationId,
+		Title:  presentation.Title,
+		URL:    GetPresentationURL(presentation.PresentationId),
+		Locale: presentation.Locale,
+	}
+	if size := presentation.PageSize; size != nil {
+		info.PageWidth = toPoints(size.Width)
+		info.PageHeight = toPoints(size.Height)
+	}
+	for i, slide := range presentation.Slides {
+		slideInfo := SlideInfo{
+			ObjectID:     slide.ObjectId,
+			Index:        i,
+			ElementCount: len(slide.PageElements),
+		}
+		if slide.SlideProperties != nil {
+			slideInfo.LayoutObjectID = slide.SlideProperties.LayoutObjectId
+		}
+		info.Slides = append(info.Slides, slideInfo)
+	}
+	return info
+}
+
+// toPoints converts a Slides dimension into points.
+func toPoints(dimension *slides.Dimension) float64 {
+	if dimension == nil {
+		return 0
+	}
+	if dimension.Unit == "EMU" {
+		return dimension.Magnitude / 12700
+	}
+	return dimension.Magnitude
+}
+
+// AddSlide appends a slide using a predefined layout, such as "TITLE", "TITLE_AND_BODY",
+// "SECTION_HEADER" or "BLANK", and fills its placeholders. The keys of placeholders are
+// placeholder types, e.g., "TITLE", "SUBTITLE", "CENTERED_TITLE" or "BODY"; the layout must
+// contain them. It returns the object ID of the new slide.
+func AddSlide(ctx context.Context, config auth.Config, presentationID, layout string, placeholders map[string]string) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	slideID := newObjectID()
+	createSlide := &slides.CreateSlideRequest{
+		ObjectId:             slideID,
+		SlideLayoutReference: &slides.LayoutReference{PredefinedLayout: layout},
+	}
+	var insertRequests []*slides.Request
+	for placeholderType, text := range placeholders {
+		objectID := newObjectID()
+		createSlide.PlaceholderIdMappings = append(createSlide.PlaceholderIdMappings, &slides.LayoutPlaceholderIdMapping{
+			LayoutPlaceholder: &slides.Placeholder{Type: placeholderType},
+			ObjectId:          objectID,
+		})
+		if text != "" {
+			insertRequests = append(insertRequests, &slides.Request{
+				InsertText: &slides.InsertTextRequest{ObjectId: objectID, Text: text},
+			})
+		}
+	}
+
+	requests := append([]*slides.Request{{CreateSlide: createSlide}}, insertRequests...)
+	_, err = slidesService.Presentations.BatchUpdate(presentationID, &slides.BatchUpdatePresentationRequest{
+		Requests: requests,
+	}).Do()
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to add slide: %w", err)
+	}
+	return slideID, nil
+}
+
+// newObjectID returns a unique object ID for a new page or page element.
+func newObjectID() string {
+	return "gsh_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
+// Frame is the position and size of a page element, in points from the top-left corner of the
+// slide. A default 16:9 slide is 720 x 405 points.
+type Frame struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+// toElementProperties places a new page element on a slide at frame.
+func toElementProperties(slideID string, frame Frame) *slides.PageElementProperties {
+	return &slides.PageElementProperties{
+		PageObjectId: slideID,
+		Size: &slides.Size{
+			Width:  &slides.Dimension{Magnitude: frame.Width, Unit: "PT"},
+			Height: &slides.Dimension{Magnitude: frame.Height, Unit: "PT"},
+		},
+		Transform: &slides.AffineTransform{
+			ScaleX:          1,
+			ScaleY:          1,
+			TranslateX:      frame.X,
+			TranslateY:      frame.Y,
+			Unit:            "PT",
+			ForceSendFields: []string{"TranslateX", "TranslateY"},
+		},
+	}
+}
+
+// AddTextBox adds a text box holding text to a slide and returns its object ID.
+func AddTextBox(ctx context.Context, config auth.Config, presentationID, slideID, text string, frame Frame) (string, error) {
+	return AddShape(ctx, config, presentationID, slideID, "TEXT_BOX", text, frame)
+}
+
+// AddShape adds a shape, such as "RECTANGLE", "ROUND_RECTANGLE", "ELLIPSE" or "RIGHT_ARROW", to a
+// slide, with optional text inside it. It returns the object ID of the shape.
+func AddShape(ctx context.Context, config auth.Config, presentationID, slideID, shapeType, text string, frame Frame) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	objectID := newObjectID()
+	requests := []*slides.Request{{
+		CreateShape: &slides.CreateShapeRequest{
+			ObjectId:          objectID,
+			ShapeType:         shapeType,
+			ElementProperties: toElementProperties(slideID, frame),
+		},
+	}}
+	if text != "" {
+		requests = append(requests, &slides.Request{
+			InsertText: &slides.InsertTextRequest{ObjectId: objectID, Text: text},
+		})
+	}
+
+	_, err = slidesService.Presentations.BatchUpdate(presentationID, &slides.BatchUpdatePresentationRequest{
+		Requests: requests,
+	}).Do()
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to add %s: %w", strings.ToLower(shapeType), err)
+	}
+	return objectID, nil
+}
+
+// AddImage adds an image to a slide and returns its object ID. imageURL must be publicly
+// reachable; the image is copied into the presentation, so the URL is only needed once. Images
+// must be PNG, JPEG or GIF and smaller than 50 MB.
+func AddImage(ctx context.Context, config auth.Config, presentationID, slideID, imageURL string, frame Frame) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	objectID := newObjectID()
+	_, err = slidesService.Presentations.BatchUpdate(presentationID, &slides.BatchUpdatePresentationRequest{
+		Requests: []*slides.Request{{
+			CreateImage: &slides.CreateImageRequest{
+				ObjectId:          objectID,
+				Url:               imageURL,
+				ElementProperties: toElementProperties(slideID, frame),
+			},
+		}},
+	}).Do()
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to add image: %w", err)
+	}
+	return objectID, nil
+}
+
+// thumbnailSize matches the size suffix of a Drive thumbnail link, e.g., "=s220".
+var thumbnailSize = regexp.MustCompile(`=s\d+$`)
+
+// AddDriveImage adds an image stored in Drive to a slide without sharing the file publicly, and
+// returns its object ID. The image is fetched through its Drive thumbnail at up to 1600 pixels on
+// the longest side, which is enough for a full-slide image.
+func AddDriveImage(ctx context.Context, config auth.Config, presentationID, slideID, fileID string, frame Frame) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to create drive service: %w", err)
+	}
+
+	file, err := driveService.Files.Get(fileID).Fields("thumbnailLink", "mimeType").SupportsAllDrives(true).Do()
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to retrieve image file: %w", err)
+	}
+	if !strings.HasPrefix(file.MimeType, "image/") {
+		return "", fmt.Errorf("gSlidesHelper: file %s is not an image (%s)", fileID, file.MimeType)
+	}
+	if file.ThumbnailLink == "" {
+		return "", fmt.Errorf("gSlidesHelper: no thumbnail is available yet for file %s", fileID)
+	}
+
+	imageURL := thumbnailSize.ReplaceAllString(file.ThumbnailLink, "=s1600")
+	return AddImage(ctx, config, presentationID, slideID, imageURL, frame)
+}
+
+// CreateDeckFromTemplate copies a template presentation and fills it in with a single batch
+// update. Every "{{name}}" in the text of any slide is replaced with texts[name], and every
+// shape whose text contains "{{name}}" for a key of images is replaced with the image at
+// images[name], scaled to fit the shape. Image URLs must be publicly reachable. The copy is named
+// title and created in folderID, or next to the template when folderID is empty. It returns the ID
+// of the new presentation.
+func CreateDeckFromTemplate(ctx context.Context, config auth.Config, templateID, title, folderID string, texts, images map[string]string) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to create drive service: %w", err)
+	}
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	deck := &drive.File{Name: title}
+	if folderID != "" {
+		deck.Parents = []string{folderID}
+	}
+	copied, err := driveService.Files.Copy(templateID, deck).Fields("id").SupportsAllDrives(true).Do()
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to copy template presentation: %w", err)
+	}
+
+	// Images go first so that their placeholder shapes are found before any text is replaced
+	var requests []*slides.Request
+	for _, name := range sortedKeys(images) {
+		requests = append(requests, &slides.Request{
+			ReplaceAllShapesWithImage: &slides.ReplaceAllShapesWithImageRequest{
+				ContainsText:       &slides.SubstringMatchCriteria{Text: "{{" + name + "}}", MatchCase: true},
+				ImageUrl:           images[name],
+				ImageReplaceMethod: "CENTER_INSIDE",
+			},
+		})
+	}
+	for _, name := range sortedKeys(texts) {
+		requests = append(requests, &slides.Request{
+			ReplaceAllText: &slides.ReplaceAllTextRequest{
+				ContainsText: &slides.SubstringMatchCriteria{Text: "{{" + name + "}}", MatchCase: true},
+				ReplaceText:  texts[name],
+			},
+		})
+	}
+	if len(requests) == 0 {
+		return copied.Id, nil
+	}
+
+	_, err = slidesService.Presentations.BatchUpdate(copied.Id, &slides.BatchUpdatePresentationRequest{
+		Requests: requests,
+	}).Do()
+	if err != nil {
+		return copied.Id, fmt.Errorf("gSlidesHelper: unable to fill in template: %w", err)
+	}
+	return copied.Id, nil
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetSpeakerNotes returns the speaker notes of a slide, or an empty string when it has none.
+func GetSpeakerNotes(ctx context.Context, config auth.Config, presentationID, slideID string) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	presentation, err := slidesService.Presentations.Get(presentationID).Do()
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to retrieve presentation: %w", err)
+	}
+
+	_, notesShape, err := findSpeakerNotes(presentation, slideID)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(shapeText(notesShape), "\n"), nil
+}
+
+// SetSpeakerNotes replaces the speaker notes of a slide with notes.
+func SetSpeakerNotes(ctx context.Context, config auth.Config, presentationID, slideID, notes string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	presentation, err := slidesService.Presentations.Get(presentationID).Do()
+	if err != nil {
+		return fmt.Errorf("gSlidesHelper: unable to retrieve presentation: %w", err)
+	}
+
+	notesID, notesShape, err := findSpeakerNotes(presentation, slideID)
+	if err != nil {
+		return err
+	}
+
+	var requests []*slides.Request
+	if shapeText(notesShape) != "" {
+		requests = append(requests, &slides.Request{
+			DeleteText: &slides.DeleteTextRequest{
+				ObjectId:  notesID,
+				TextRange: &slides.Range{Type: "ALL"},
+			},
+		})
+	}
+	if notes != "" {
+		// Inserting into the speaker notes shape creates it if it does not exist yet
+		requests = append(requests, &slides.Request{
+			InsertText: &slides.InsertTextRequest{ObjectId: notesID, Text: notes},
+		})
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	_, err = slidesService.Presentations.BatchUpdate(presentationID, &slides.BatchUpdatePresentationRequest{
+		Requests: requests,
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("gSlidesHelper: unable to set speaker notes: %w", err)
+	}
+	return nil
+}
+
+// findSpeakerNotes returns the object ID of the speaker notes shape of a slide, and the shape
+// itself when it exists.
+func findSpeakerNotes(presentation *slides.Presentation, slideID string) (string, *slides.Shape, error) {
+	for _, slide := range presentation.Slides {
+		if slide.ObjectId != slideID {
+			continue
+		}
+		if slide.SlideProperties == nil || slide.SlideProperties.NotesPage == nil ||
+			slide.SlideProperties.NotesPage.NotesProperties == nil {
+			return "", nil, fmt.Errorf("gSlidesHelper: slide %s has no notes page", slideID)
+		}
+
+		notesPage := slide.SlideProperties.NotesPage
+		notesID := notesPage.NotesProperties.SpeakerNotesObjectId
+		for _, element := range notesPage.PageElements {
+			if element.ObjectId == notesID {
+				return notesID, element.Shape, nil
+			}
+		}
+		return notesID, nil, nil
+	}
+	return "", nil, fmt.Errorf("gSlidesHelper: presentation %s has no slide %s", presentation.PresentationId, slideID)
+}
+
+// shapeText returns the text content of a shape.
+func shapeText(shape *slides.Shape) string {
+	if shape == nil || shape.Text == nil {
+		return ""
+	}
+
+	var builder strings.Builder
+	for _, element := range shape.Text.TextElements {
+		if element.TextRun != nil {
+			builder.WriteString(element.TextRun.Content)
+		}
+	}
+	return builder.String()
+}
+
+// ExportPresentationPDF writes the presentation to w as a PDF. Drive limits exports to 10 MB.
+func ExportPresentationPDF(ctx context.Context, config auth.Config, presentationID string, w io.Writer) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gSlidesHelper: unable to create drive service: %w", err)
+	}
+
+	response, err := driveService.Files.Export(presentationID, "application/pdf").Download()
+	if err != nil {
+		return fmt.Errorf("gSlidesHelper: unable to export presentation: %w", err)
+	}
+	defer response.Body.Close()
+
+	if _, err := io.Copy(w, response.Body); err != nil {
+		return fmt.Errorf("gSlidesHelper: unable to write exported presentation: %w", err)
+	}
+	return nil
+}
+
+// Thumbnail is a rendered PNG image of a slide. URL is valid for about 30 minutes and does not
+// require authentication.
+type Thumbnail struct {
+	SlideID string
+	URL     string
+	Width   int64
+	Height  int64
+}
+
+// GetSlideThumbnails renders every slide as a PNG and returns the thumbnails in slide order. size
+// is "SMALL" (200 pixels wide), "MEDIUM" (800 pixels) or "LARGE" (1600 pixels).
+func GetSlideThumbnails(ctx context.Context, config auth.Config, presentationID, size string) ([]Thumbnail, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	presentation, err := slidesService.Presentations.Get(presentationID).Fields("slides.objectId").Do()
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: unable to retrieve presentation: %w", err)
+	}
+
+	thumbnails := make([]Thumbnail, 0, len(presentation.Slides))
+	for _, slide := range presentation.Slides {
+		thumbnail, err := slidesService.Presentations.Pages.GetThumbnail(presentationID, slide.ObjectId).
+			ThumbnailPropertiesMimeType("PNG").
+			ThumbnailPropertiesThumbnailSize(size).
+			Do()
+		if err != nil {
+			return thumbnails, fmt.Errorf("gSlidesHelper: unable to render slide %s: %w", slide.ObjectId, err)
+		}
+		thumbnails = append(thumbnails, Thumbnail{
+			SlideID: slide.ObjectId,
+			URL:     thumbnail.ContentUrl,
+			Width:   thumbnail.Width,
+			Height:  thumbnail.Height,
+		})
+	}
+	return thumbnails, nil
+}
+
+// SaveSlideThumbnails renders every slide as a PNG of the given size (see GetSlideThumbnails) and
+// saves them in localDir as slide-001.png, slide-002.png and so on. It returns the paths written.
+func SaveSlideThumbnails(ctx context.Context, config auth.Config, presentationID, size, localDir string) ([]string, error) {
+	thumbnails, err := GetSlideThumbnails(ctx, config, presentationID, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(localDir, 0o755); err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: unable to create directory %s: %w", localDir, err)
+	}
+
+	var paths []string
+	for i, thumbnail := range thumbnails {
+		path := filepath.Join(localDir, fmt.Sprintf("slide-%03d.png", i+1))
+		if err := downloadURL(ctx, thumbnail.URL, path); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// downloadURL saves the content at url, which must not require authentication, to path.
+func downloadURL(ctx context.Context, url, path string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("gSlidesHelper: unable to create request: %w", err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("gSlidesHelper: unable to download %s: %w", path, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("gSlidesHelper: unable to download %s: %s", path, response.Status)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("gSlidesHelper: unable to create file %s: %w", path, err)
+	}
+	if _, err := io.Copy(file, response.Body); err != nil {
+		file.Close()
+		return fmt.Errorf("gSlidesHelper: unable to write file %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("gSlidesHelper: unable to write file %s: %w", path, err)
+	}
+	return nil
+}
+
+// CopySlidesReport describes the result of CopySlides.
+type CopySlidesReport struct {
+	SlideIDs []string // object IDs of the new slides in the destination, in order
+	Skipped  []string // page elements that could not be copied, e.g., "slide p3: video g12a"
+}
+
+// CopySlides copies slides, given by object ID, from one presentation into another, inserting
+// them at insertionIndex (a negative index appends them). The Slides API cannot move slides
+// between presentations, so each slide is rebuilt on a blank slide: shapes and text boxes with
+// their fill, outline, text and paragraph styles, images, tables with their text and the page
+// background are copied. Videos, lines, charts, groups and word art are skipped and listed in the
+// report. Theme colors follow the destination's theme.
+func CopySlides(ctx context.Context, config auth.Config, sourceID string, slideIDs []string, destID string, insertionIndex int) (*CopySlidesReport, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	source, err := slidesService.Presentations.Get(sourceID).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: unable to retrieve source presentation: %w", err)
+	}
+	bySlideID := make(map[string]*slides.Page)
+	for _, slide := range source.Slides {
+		bySlideID[slide.ObjectId] = slide
+	}
+
+	if insertionIndex < 0 {
+		dest, err := slidesService.Presentations.Get(destID).Fields("slides.objectId").Do()
+		if err != nil {
+			return nil, fmt.Errorf("gSlidesHelper: unable to retrieve destination presentation: %w", err)
+		}
+		insertionIndex = len(dest.Slides)
+	}
+
+	report := &CopySlidesReport{}
+	var requests []*slides.Request
+	for i, slideID := range slideIDs {
+		slide, ok := bySlideID[slideID]
+		if !ok {
+			return nil, fmt.Errorf("gSlidesHelper: presentation %s has no slide %s", sourceID, slideID)
+		}
+
+		newSlideID := newObjectID()
+		report.SlideIDs = append(report.SlideIDs, newSlideID)
+		requests = append(requests, &slides.Request{
+			CreateSlide: &slides.CreateSlideRequest{
+				ObjectId:             newSlideID,
+				InsertionIndex:       int64(insertionIndex + i),
+				SlideLayoutReference: &slides.LayoutReference{PredefinedLayout: "BLANK"},
+				ForceSendFields:      []string{"InsertionIndex"},
+			},
+		})
+
+		if slide.PageProperties != nil && slide.PageProperties.PageBackgroundFill != nil &&
+			slide.PageProperties.PageBackgroundFill.SolidFill != nil {
+			requests = append(requests, &slides.Request{
+				UpdatePageProperties: &slides.UpdatePagePropertiesRequest{
+					ObjectId: newSlideID,
+					PageProperties: &slides.PageProperties{
+						PageBackgroundFill: &slides.PageBackgroundFill{SolidFill: slide.PageProperties.PageBackgroundFill.SolidFill},
+					},
+					Fields: "pageBackgroundFill.solidFill",
+				},
+			})
+		}
+
+		for _, element := range slide.PageElements {
+			elementRequests, ok := copyPageElement(element, newSlideID)
+			if !ok {
+				report.Skipped = append(report.Skipped, fmt.Sprintf("slide %s: %s %s", slideID, pageElementKind(element), element.ObjectId))
+				continue
+			}
+			requests = append(requests, elementRequests...)
+		}
+	}
+	if len(requests) == 0 {
+		return report, nil
+	}
+
+	_, err = slidesService.Presentations.BatchUpdate(destID, &slides.BatchUpdatePresentationRequest{
+		Requests: requests,
+	}).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gSlidesHelper: unable to copy slides: %w", err)
+	}
+	return report, nil
+}
+
+// CopySlidesByIndex is like CopySlides but selects the slides by their zero-based position in the
+// source presentation.
+func CopySlidesByIndex(ctx context.Context, config auth.Config, sourceID string, indexes []int, destID string, insertionIndex int) (*CopySlidesReport, error) {
+	info, err := GetPresentationInfo(ctx, config, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	slideIDs := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		if index < 0 || index >= len(info.Slides) {
+			return nil, fmt.Errorf("gSlidesHelper: presentation %s has no slide at index %d", sourceID, index)
+		}
+		slideIDs = append(slideIDs, info.Slides[index].ObjectID)
+	}
+	return CopySlides(ctx, config, sourceID, slideIDs, destID, insertionIndex)
+}
+
+// copyPageElement returns the requests that recreate element on the slide newSlideID, or false if
+// the kind of element is not supported.
+func copyPageElement(element *slides.PageElement, newSlideID string) ([]*slides.Request, bool) {
+	objectID := newObjectID()
+	properties := &slides.PageElementProperties{
+		PageObjectId: newSlideID,
+		Size:         element.Size,
+		Transform:    element.Transform,
+	}
+
+	switch {
+	case element.Shape != nil:
+		shape := element.Shape
+		shapeType := shape.ShapeType
+		if shapeType == "" || shapeType == "TYPE_UNSPECIFIED" {
+			shapeType = "TEXT_BOX"
+		}
+		requests := []*slides.Request{{
+			CreateShape: &slides.CreateShapeRequest{ObjectId: objectID, ShapeType: shapeType, ElementProperties: properties},
+		}}
+		if props := shape.ShapeProperties; props != nil && shape.Placeholder == nil {
+			requests = append(requests, &slides.Request{
+				UpdateShapeProperties: &slides.UpdateShapePropertiesRequest{
+					ObjectId: objectID,
+					ShapeProperties: &slides.ShapeProperties{
+						ShapeBackgroundFill: props.ShapeBackgroundFill,
+						Outline:             props.Outline,
+						ContentAlignment:    props.ContentAlignment,
+					},
+					Fields: "shapeBackgroundFill,outline,contentAlignment",
+				},
+			})
+		}
+		return append(requests, copyText(shape.Text, objectID, nil)...), true
+
+	case element.Image != nil:
+		return []*slides.Request{{
+			CreateImage: &slides.CreateImageRequest{ObjectId: objectID, Url: element.Image.ContentUrl, ElementProperties: properties},
+		}}, true
+
+	case element.Table != nil:
+		table := element.Table
+		requests := []*slides.Request{{
+			CreateTable: &slides.CreateTableRequest{ObjectId: objectID, Rows: table.Rows, Columns: table.Columns, ElementProperties: properties},
+		}}
+		for rowIndex, row := range table.TableRows {
+			for columnIndex, cell := range row.TableCells {
+				location := &slides.TableCellLocation{
+					RowIndex:        int64(rowIndex),
+					ColumnIndex:     int64(columnIndex),
+					ForceSendFields: []string{"RowIndex", "ColumnIndex"},
+				}
+				requests = append(requests, copyText(cell.Text, objectID, location)...)
+			}
+		}
+		return requests, true
+	}
+	return nil, false
+}
+
+// copyText returns the requests that insert the text of a shape or table cell, with its text and
+// paragraph styles, into objectID.
+func copyText(text *slides.TextContent, objectID string, cell *slides.TableCellLocation) []*slides.Request {
+	content := strings.TrimSuffix(textContent(text), "\n")
+	if content == "" {
+		return nil
+	}
+	// Indexes are in UTF-16 code units; the final newline is implicit in every shape
+	length := int64(len(utf16.Encode([]rune(content))))
+
+	requests := []*slides.Request{{
+		InsertText: &slides.InsertTextRequest{ObjectId: objectID, CellLocation: cell, Text: content},
+	}}
+	for _, element := range text.TextElements {
+		end := min(element.EndIndex, length)
+		if element.StartIndex >= end {
+			continue
+		}
+		textRange := &slides.Range{
+			Type:            "FIXED_RANGE",
+			StartIndex:      &element.StartIndex,
+			EndIndex:        &end,
+			ForceSendFields: []string{"StartIndex", "EndIndex"},
+		}
+
+		if element.TextRun != nil && element.TextRun.Style != nil {
+			requests = append(requests, &slides.Request{
+				UpdateTextStyle: &slides.UpdateTextStyleRequest{
+					ObjectId:     objectID,
+					CellLocation: cell,
+					TextRange:    textRange,
+					Style:        element.TextRun.Style,
+					Fields:       "bold,italic,underline,strikethrough,smallCaps,baselineOffset,fontFamily,fontSize,foregroundColor,backgroundColor,link",
+				},
+			})
+		}
+		if element.ParagraphMarker != nil && element.ParagraphMarker.Style != nil {
+			style := element.ParagraphMarker.Style
+			requests = append(requests, &slides.Request{
+				UpdateParagraphStyle: &slides.UpdateParagraphStyleRequest{
+					ObjectId:     objectID,
+					CellLocation: cell,
+					TextRange:    textRange,
+					Style: &slides.ParagraphStyle{
+						Alignment:   style.Alignment,
+						LineSpacing: style.LineSpacing,
+						SpaceAbove:  style.SpaceAbove,
+						SpaceBelow:  style.SpaceBelow,
+					},
+					Fields: "alignment,lineSpacing,spaceAbove,spaceBelow",
+				},
+			})
+		}
+	}
+	return requests
+}
+
+// textContent returns the text of a shape or table cell.
+func textContent(text *slides.TextContent) string {
+	if text == nil {
+		return ""
+	}
+	return shapeText(&slides.Shape{Text: text})
+}
+
+// pageElementKind names the kind of a page element for reports.
+func pageElementKind(element *slides.PageElement) string {
+	switch {
+	case element.Video != nil:
+		return "video"
+	case element.Line != nil:
+		return "line"
+	case element.SheetsChart != nil:
+		return "chart"
+	case element.ElementGroup != nil:
+		return "group"
+	case element.WordArt != nil:
+		return "word art"
+	case element.SpeakerSpotlight != nil:
+		return "speaker spotlight"
+	}
+	return "element"
+}
+
+// outlineSlide is a slide generated from a heading of a document.
+type outlineSlide struct {
+	title string
+	lines []string // body paragraphs, prefixed with one tab per list nesting level
+}
+
+// CreateDeckFromDoc turns the outline of a Google Doc into a new presentation in folderID (or My
+// Drive when empty). The deck opens with a title slide named after the document, followed by one
+// slide per Heading 1 and Heading 2 whose body lists, as bullets, the paragraphs under that
+// heading; nested list items keep their nesting. Content before the first heading is left out.
+// It returns the ID of the presentation.
+func CreateDeckFromDoc(ctx context.Context, config auth.Config, docID, folderID string) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	docsService, err := docs.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to create docs service: %w", err)
+	}
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	doc, err := docsService.Documents.Get(docID).Do()
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to retrieve document: %w", err)
+	}
+	outline := docOutline(doc)
+
+	info, err := CreatePresentation(ctx, config, doc.Title, folderID)
+	if err != nil {
+		return "", err
+	}
+
+	titleID := newObjectID()
+	requests := []*slides.Request{
+		{CreateSlide: &slides.CreateSlideRequest{
+			SlideLayoutReference: &slides.LayoutReference{PredefinedLayout: "TITLE"},
+			PlaceholderIdMappings: []*slides.LayoutPlaceholderIdMapping{{
+				LayoutPlaceholder: &slides.Placeholder{Type: "CENTERED_TITLE"},
+				ObjectId:          titleID,
+			}},
+		}},
+		{InsertText: &slides.InsertTextRequest{ObjectId: titleID, Text: doc.Title}},
+	}
+
+	for _, slide := range outline {
+		headingID, bodyID := newObjectID(), newObjectID()
+		requests = append(requests, &slides.Request{CreateSlide: &slides.CreateSlideRequest{
+			SlideLayoutReference: &slides.LayoutReference{PredefinedLayout: "TITLE_AND_BODY"},
+			PlaceholderIdMappings: []*slides.LayoutPlaceholderIdMapping{
+				{LayoutPlaceholder: &slides.Placeholder{Type: "TITLE"}, ObjectId: headingID},
+				{LayoutPlaceholder: &slides.Placeholder{Type: "BODY"}, ObjectId: bodyID},
+			},
+		}})
+		if slide.title != "" {
+			requests = append(requests, &slides.Request{InsertText: &slides.InsertTextRequest{ObjectId: headingID, Text: slide.title}})
+		}
+		if len(slide.lines) > 0 {
+			requests = append(requests,
+				&slides.Request{InsertText: &slides.InsertTextRequest{ObjectId: bodyID, Text: strings.Join(slide.lines, "\n")}},
+				// Leading tabs set the nesting level of each bullet and are removed
+				&slides.Request{CreateParagraphBullets: &slides.CreateParagraphBulletsRequest{
+					ObjectId:     bodyID,
+					TextRange:    &slides.Range{Type: "ALL"},
+					BulletPreset: "BULLET_DISC_CIRCLE_SQUARE",
+				}},
+			)
+		}
+	}
+
+	// Remove the slide every new presentation starts with
+	for _, slide := range info.Slides {
+		requests = append(requests, &slides.Request{DeleteObject: &slides.DeleteObjectRequest{ObjectId: slide.ObjectID}})
+	}
+
+	_, err = slidesService.Presentations.BatchUpdate(info.ID, &slides.BatchUpdatePresentationRequest{
+		Requests: requests,
+	}).Do()
+	if err != nil {
+		return info.ID, fmt.Errorf("gSlidesHelper: unable to build slides from document: %w", err)
+	}
+	return info.ID, nil
+}
+
+// docOutline splits the body of a document into slides at every Heading 1 and Heading 2.
+func docOutline(doc *docs.Document) []outlineSlide {
+	var outline []outlineSlide
+	if doc.Body == nil {
+		return outline
+	}
+
+	for _, element := range doc.Body.Content {
+		paragraph := element.Paragraph
+		if paragraph == nil {
+			continue
+		}
+
+		var builder strings.Builder
+		for _, part := range paragraph.Elements {
+			if part.TextRun != nil {
+				builder.WriteString(part.TextRun.Content)
+			}
+		}
+		text := strings.TrimSpace(strings.ReplaceAll(builder.String(), "\v", " "))
+
+		style := ""
+		if paragraph.ParagraphStyle != nil {
+			style = paragraph.ParagraphStyle.NamedStyleType
+		}
+		switch {
+		case style == "HEADING_1" || style == "HEADING_2":
+			outline = append(outline, outlineSlide{title: text})
+		case text == "" || len(outline) == 0:
+			// Blank lines and content before the first heading are left out
+		default:
+			nesting := 0
+			if paragraph.Bullet != nil {
+				nesting = int(paragraph.Bullet.NestingLevel)
+			}
+			last := &outline[len(outline)-1]
+			last.lines = append(last.lines, strings.Repeat("\t", nesting)+text)
+		}
+	}
+	return outline
+}
+
+// AddTable adds a table filled with rows to a slide and returns its object ID. The table has as
+// many columns as the longest row.
+func AddTable(ctx context.Context, config auth.Config, presentationID, slideID string, rows [][]string, frame Frame) (string, error) {
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if len(rows) == 0 || columns == 0 {
+		return "", fmt.Errorf("gSlidesHelper: table needs at least one cell")
+	}
+
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	objectID := newObjectID()
+	requests := []*slides.Request{{
+		CreateTable: &slides.CreateTableRequest{
+			ObjectId:          objectID,
+			Rows:              int64(len(rows)),
+			Columns:           int64(columns),
+			ElementProperties: toElementProperties(slideID, frame),
+		},
+	}}
+	for rowIndex, row := range rows {
+		for columnIndex, text := range row {
+			if text == "" {
+				continue
+			}
+			requests = append(requests, &slides.Request{
+				InsertText: &slides.InsertTextRequest{
+					ObjectId: objectID,
+					CellLocation: &slides.TableCellLocation{
+						RowIndex:        int64(rowIndex),
+						ColumnIndex:     int64(columnIndex),
+						ForceSendFields: []string{"RowIndex", "ColumnIndex"},
+					},
+					Text: text,
+				},
+			})
+		}
+	}
+
+	_, err = slidesService.Presentations.BatchUpdate(presentationID, &slides.BatchUpdatePresentationRequest{
+		Requests: requests,
+	}).Do()
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to add table: %w", err)
+	}
+	return objectID, nil
+}
+
+// AddSheetsChart embeds a chart from a Google Sheets spreadsheet, such as one created with
+// gSheetsHelper.AddChart, on a slide and returns its object ID. A linked chart can be updated
+// from the spreadsheet later with RefreshSheetsChart; otherwise a static image is inserted.
+func AddSheetsChart(ctx context.Context, config auth.Config, presentationID, slideID, spreadsheetID string, chartID int64, frame Frame, linked bool) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	linkingMode := "NOT_LINKED_IMAGE"
+	if linked {
+		linkingMode = "LINKED"
+	}
+
+	objectID := newObjectID()
+	_, err = slidesService.Presentations.BatchUpdate(presentationID, &slides.BatchUpdatePresentationRequest{
+		Requests: []*slides.Request{{
+			CreateSheetsChart: &slides.CreateSheetsChartRequest{
+				ObjectId:          objectID,
+				SpreadsheetId:     spreadsheetID,
+				ChartId:           chartID,
+				LinkingMode:       linkingMode,
+				ElementProperties: toElementProperties(slideID, frame),
+				ForceSendFields:   []string{"ChartId"},
+			},
+		}},
+	}).Do()
+	if err != nil {
+		return "", fmt.Errorf("gSlidesHelper: unable to add chart: %w", err)
+	}
+	return objectID, nil
+}
+
+// RefreshSheetsChart updates a linked chart added by AddSheetsChart with the current data of its
+// spreadsheet.
+func RefreshSheetsChart(ctx context.Context, config auth.Config, presentationID, objectID string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gSlidesHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	slidesService, err := slides.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gSlidesHelper: unable to create slides service: %w", err)
+	}
+
+	_, err = slidesService.Presentations.BatchUpdate(presentationID, &slides.BatchUpdatePresentationRequest{
+		Requests: []*slides.Request{{
+			RefreshSheetsChart: &slides.RefreshSheetsChartRequest{ObjectId: objectID},
+		}},
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("gSlidesHelper: unable to refresh chart: %w", err)
+	}
+	return nil
+}
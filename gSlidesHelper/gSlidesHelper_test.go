@@ -0,0 +1,169 @@
+package gSlidesHelper
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/slides/v1"
+)
+
+// paragraph builds a document paragraph with the given named style, made of one text run per
+// part. A negative nesting level means the paragraph is not a list item.
+func paragraph(style string, nesting int64, parts ...string) *docs.StructuralElement {
+	p := &docs.Paragraph{ParagraphStyle: &docs.ParagraphStyle{NamedStyleType: style}}
+	if nesting >= 0 {
+		p.Bullet = &docs.Bullet{NestingLevel: nesting}
+	}
+	for _, part := range parts {
+		p.Elements = append(p.Elements, &docs.ParagraphElement{TextRun: &docs.TextRun{Content: part}})
+	}
+	return &docs.StructuralElement{Paragraph: p}
+}
+
+func TestDocOutline(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []*docs.StructuralElement
+		want    []outlineSlide
+	}{
+		{name: "empty document", content: nil, want: nil},
+		{
+			name: "content before the first heading is left out",
+			content: []*docs.StructuralElement{
+				paragraph("TITLE", -1, "Deck title\n"),
+				paragraph("NORMAL_TEXT", -1, "Intro\n"),
+				paragraph("HEADING_1", -1, "First\n"),
+				paragraph("NORMAL_TEXT", -1, "Point\n"),
+			},
+			want: []outlineSlide{{title: "First", lines: []string{"Point"}}},
+		},
+		{
+			name: "heading 1 and heading 2 both start slides, heading 3 does not",
+			content: []*docs.StructuralElement{
+				paragraph("HEADING_1", -1, "Chapter\n"),
+				paragraph("HEADING_2", -1, "Section\n"),
+				paragraph("HEADING_3", -1, "Subsection\n"),
+				paragraph("NORMAL_TEXT", -1, "Body\n"),
+			},
+			want: []outlineSlide{
+				{title: "Chapter"},
+				{title: "Section", lines: []string{"Subsection", "Body"}},
+			},
+		},
+		{
+			name: "blank lines are skipped",
+			content: []*docs.StructuralElement{
+				paragraph("HEADING_1", -1, "Agenda\n"),
+				paragraph("NORMAL_TEXT", -1, "\n"),
+				paragraph("NORMAL_TEXT", -1, "  \n"),
+				paragraph("NORMAL_TEXT", -1, "Item\n"),
+				{Table: &docs.Table{}},
+			},
+			want: []outlineSlide{{title: "Agenda", lines: []string{"Item"}}},
+		},
+		{
+			name: "list nesting becomes leading tabs",
+			content: []*docs.StructuralElement{
+				paragraph("HEADING_2", -1, "Plan\n"),
+				paragraph("NORMAL_TEXT", 0, "Top\n"),
+				paragraph("NORMAL_TEXT", 1, "Nested\n"),
+				paragraph("NORMAL_TEXT", 2, "Deeper\n"),
+			},
+			want: []outlineSlide{{title: "Plan", lines: []string{"Top", "\tNested", "\t\tDeeper"}}},
+		},
+		{
+			name: "runs are joined and vertical tabs become spaces",
+			content: []*docs.StructuralElement{
+				paragraph("HEADING_1", -1, "Two\vline ", "heading\n"),
+				paragraph("NORMAL_TEXT", -1, "soft\vbreak\n"),
+			},
+			want: []outlineSlide{{title: "Two line heading", lines: []string{"soft break"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &docs.Document{Body: &docs.Body{Content: tt.content}}
+			if got := docOutline(doc); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("docOutline() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocOutlineWithoutBody(t *testing.T) {
+	if got := docOutline(&docs.Document{}); len(got) != 0 {
+		t.Fatalf("docOutline() = %#v, want no slides", got)
+	}
+}
+
+func TestCopyText(t *testing.T) {
+	bold := &slides.TextStyle{Bold: true}
+	italic := &slides.TextStyle{Italic: true}
+	centered := &slides.ParagraphStyle{Alignment: "CENTER"}
+
+	// "Hi 😀 " is 6 UTF-16 code units long because the emoji is outside the BMP
+	text := &slides.TextContent{TextElements: []*slides.TextElement{
+		{StartIndex: 0, EndIndex: 12, ParagraphMarker: &slides.ParagraphMarker{Style: centered}},
+		{StartIndex: 0, EndIndex: 6, TextRun: &slides.TextRun{Content: "Hi 😀 ", Style: bold}},
+		{StartIndex: 6, EndIndex: 11, TextRun: &slides.TextRun{Content: "there", Style: italic}},
+		{StartIndex: 11, EndIndex: 12, TextRun: &slides.TextRun{Content: "\n", Style: bold}},
+	}}
+	cell := &slides.TableCellLocation{RowIndex: 1, ColumnIndex: 2}
+
+	requests := copyText(text, "box", cell)
+	if len(requests) != 4 {
+		t.Fatalf("copyText() returned %d requests, want 4", len(requests))
+	}
+
+	insert := requests[0].InsertText
+	if insert == nil || insert.ObjectId != "box" || insert.CellLocation != cell || insert.Text != "Hi 😀 there" {
+		t.Fatalf("first request = %+v, want the text inserted into the cell", requests[0])
+	}
+
+	paragraphStyle := requests[1].UpdateParagraphStyle
+	if paragraphStyle == nil || paragraphStyle.Style.Alignment != "CENTER" {
+		t.Fatalf("second request = %+v, want the paragraph style", requests[1])
+	}
+	// The paragraph ends with the implicit newline, which is clamped away
+	if got := [2]int64{*paragraphStyle.TextRange.StartIndex, *paragraphStyle.TextRange.EndIndex}; got != [2]int64{0, 11} {
+		t.Errorf("paragraph style range = %v, want [0 11]", got)
+	}
+
+	wantRuns := []struct {
+		style      *slides.TextStyle
+		start, end int64
+	}{
+		{style: bold, start: 0, end: 6},
+		{style: italic, start: 6, end: 11},
+	}
+	for i, want := range wantRuns {
+		update := requests[i+2].UpdateTextStyle
+		if update == nil {
+			t.Fatalf("request %d = %+v, want a text style update", i+2, requests[i+2])
+		}
+		if update.ObjectId != "box" || update.CellLocation != cell || update.Style != want.style {
+			t.Errorf("text style %d = %+v, want style %+v on the cell", i, update, want.style)
+		}
+		if got := [2]int64{*update.TextRange.StartIndex, *update.TextRange.EndIndex}; got != [2]int64{want.start, want.end} {
+			t.Errorf("text style %d range = %v, want [%d %d]", i, got, want.start, want.end)
+		}
+		if update.TextRange.Type != "FIXED_RANGE" {
+			t.Errorf("text style %d range type = %q, want FIXED_RANGE", i, update.TextRange.Type)
+		}
+	}
+}
+
+func TestCopyTextEmpty(t *testing.T) {
+	tests := map[string]*slides.TextContent{
+		"nil": nil,
+		"newline only": {TextElements: []*slides.TextElement{
+			{StartIndex: 0, EndIndex: 1, TextRun: &slides.TextRun{Content: "\n"}},
+		}},
+	}
+	for name, text := range tests {
+		if got := copyText(text, "box", nil); got != nil {
+			t.Errorf("%s: copyText() = %+v, want no requests", name, got)
+		}
+	}
+}
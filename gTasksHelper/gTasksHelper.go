@@ -0,0 +1,240 @@
+package gTasksHelper
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gnzdotmx/gworkspace-helper/auth"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/option"
+	"google.golang.org/api/tasks/v1"
+)
+
+// ActionItem is an action item found in a document.
+type ActionItem struct {
+	Text     string
+	Assignee string // email of the first person mentioned in the item, if any
+	Checked  bool   // the checklist item is ticked, i.e., its text is struck through
+}
+
+// todoPrefix matches the "TODO:" marker of an action item line.
+var todoPrefix = regexp.MustCompile(`(?i)^\s*TODO\s*:\s*`)
+
+// emailMention matches an email address typed as text, optionally preceded by "@".
+var emailMention = regexp.MustCompile(`@?([A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+// FindActionItems returns the action items of a document: checklist items and paragraphs
+// starting with "TODO:", including those inside tables. The API does not expose checklists
+// directly, so bulleted paragraphs whose list has no glyph are taken as checklist items.
+func FindActionItems(ctx context.Context, config auth.Config, docID string) ([]ActionItem, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gTasksHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	docsService, err := docs.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gTasksHelper: unable to create docs service: %w", err)
+	}
+
+	doc, err := docsService.Documents.Get(docID).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gTasksHelper: unable to retrieve document: %w", err)
+	}
+	return actionItems(doc), nil
+}
+
+// DocTaskOptions controls how CreateTasksFromDoc creates tasks.
+type DocTaskOptions struct {
+	TaskListID     string    // defaults to the default task list of the user
+	Due            time.Time // only the date is kept by Google Tasks
+	Assignee       string    // only create tasks for items mentioning this email
+	IncludeChecked bool      // also create tasks, already completed, for ticked checklist items
+}
+
+// CreateTasksFromDoc creates a task for every action item of a meeting-notes document, see
+// FindActionItems. The notes of each task link back to the document and name the person
+// mentioned in the item; the Tasks API cannot assign tasks to other users, so the tasks are
+// created in the authenticated user's list. Items that already have a task linking to the
+// document, with the same title, are skipped, so the call can be repeated as the notes grow.
+func CreateTasksFromDoc(ctx context.Context, config auth.Config, docID string, opts DocTaskOptions) ([]*tasks.Task, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gTasksHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	docsService, err := docs.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gTasksHelper: unable to create docs service: %w", err)
+	}
+	tasksService, err := tasks.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gTasksHelper: unable to create tasks service: %w", err)
+	}
+
+	doc, err := docsService.Documents.Get(docID).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gTasksHelper: unable to retrieve document: %w", err)
+	}
+
+	taskListID := opts.TaskListID
+	if taskListID == "" {
+		taskListID = "@default"
+	}
+	docURL := fmt.Sprintf("https://docs.google.com/document/d/%s/edit", docID)
+
+	// Tasks created by earlier runs are found by their title and the link in their notes
+	existing := map[string]bool{}
+	err = tasksService.Tasks.List(taskListID).ShowCompleted(true).ShowHidden(true).Pages(ctx, func(page *tasks.Tasks) error {
+		for _, task := range page.Items {
+			if strings.Contains(task.Notes, docURL) {
+				existing[task.Title] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gTasksHelper: unable to list tasks: %w", err)
+	}
+
+	var created []*tasks.Task
+	for _, item := range actionItems(doc) {
+		if item.Checked && !opts.IncludeChecked {
+			continue
+		}
+		if opts.Assignee != "" && !strings.EqualFold(item.Assignee, opts.Assignee) {
+			continue
+		}
+		if existing[item.Text] {
+			continue
+		}
+
+		notes := fmt.Sprintf("From %s: %s", doc.Title, docURL)
+		if item.Assignee != "" {
+			notes += "\nAssigned to: " + item.Assignee
+		}
+		task := &tasks.Task{Title: item.Text, Notes: notes, Status: "needsAction"}
+		if item.Checked {
+			task.Status = "completed"
+		}
+		if !opts.Due.IsZero() {
+			due := opts.Due
+			task.Due = time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC).Format(time.RFC3339)
+		}
+
+		inserted, err := tasksService.Tasks.Insert(taskListID, task).Do()
+		if err != nil {
+			return created, fmt.Errorf("gTasksHelper: unable to create task %q: %w", item.Text, err)
+		}
+		existing[item.Text] = true
+		created = append(created, inserted)
+	}
+	return created, nil
+}
+
+// actionItems returns the action items of a document in document order.
+func actionItems(doc *docs.Document) []ActionItem {
+	var items []ActionItem
+	if doc.Body == nil {
+		return items
+	}
+
+	var walk func(content []*docs.StructuralElement)
+	walk = func(content []*docs.StructuralElement) {
+		for _, element := range content {
+			if element.Table != nil {
+				for _, row := range element.Table.TableRows {
+					for _, cell := range row.TableCells {
+						walk(cell.Content)
+					}
+				}
+				continue
+			}
+			if element.Paragraph == nil {
+				continue
+			}
+			if item, ok := paragraphActionItem(doc, element.Paragraph); ok {
+				items = append(items, item)
+			}
+		}
+	}
+	walk(doc.Body.Content)
+	return items
+}
+
+// paragraphActionItem returns the action item held by a paragraph, if any.
+func paragraphActionItem(doc *docs.Document, paragraph *docs.Paragraph) (ActionItem, bool) {
+	var builder strings.Builder
+	var item ActionItem
+	struck, textRuns := true, 0
+	for _, part := range paragraph.Elements {
+		switch {
+		case part.TextRun != nil:
+			builder.WriteString(part.TextRun.Content)
+			if strings.TrimSpace(part.TextRun.Content) != "" {
+				textRuns++
+				struck = struck && part.TextRun.TextStyle != nil && part.TextRun.TextStyle.Strikethrough
+			}
+		case part.Person != nil && part.Person.PersonProperties != nil:
+			// Person chips are shown by name but identify the person by email
+			person := part.Person.PersonProperties
+			if item.Assignee == "" {
+				item.Assignee = person.Email
+			}
+			name := person.Name
+			if name == "" {
+				name = person.Email
+			}
+			builder.WriteString("@" + name)
+		}
+	}
+	text := strings.TrimSpace(strings.ReplaceAll(builder.String(), "\v", " "))
+	if text == "" {
+		return item, false
+	}
+
+	switch {
+	case todoPrefix.MatchString(text):
+		text = todoPrefix.ReplaceAllString(text, "")
+	case isChecklist(doc, paragraph):
+		item.Checked = textRuns > 0 && struck
+	default:
+		return item, false
+	}
+	if text == "" {
+		return item, false
+	}
+
+	if item.Assignee == "" {
+		if match := emailMention.FindStringSubmatch(text); match != nil {
+			item.Assignee = match[1]
+		}
+	}
+	item.Text = text
+	return item, true
+}
+
+// isChecklist reports whether a paragraph is an item of a checklist, whose nesting levels have
+// neither a glyph type nor a glyph symbol.
+func isChecklist(doc *docs.Document, paragraph *docs.Paragraph) bool {
+	if paragraph.Bullet == nil {
+		return false
+	}
+	list, ok := doc.Lists[paragraph.Bullet.ListId]
+	if !ok || list.ListProperties == nil {
+		return false
+	}
+
+	levels := list.ListProperties.NestingLevels
+	nesting := int(paragraph.Bullet.NestingLevel)
+	if nesting >= len(levels) {
+		return false
+	}
+	level := levels[nesting]
+	return level.GlyphSymbol == "" && (level.GlyphType == "" || level.GlyphType == "GLYPH_TYPE_UNSPECIFIED")
+}
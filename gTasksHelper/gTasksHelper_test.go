@@ -0,0 +1,93 @@
+package gTasksHelper
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// paragraph returns a body element holding a paragraph made of parts.
+func paragraph(bullet *docs.Bullet, parts ...*docs.ParagraphElement) *docs.StructuralElement {
+	return &docs.StructuralElement{Paragraph: &docs.Paragraph{Bullet: bullet, Elements: parts}}
+}
+
+// text returns a text run, struck through when struck is set.
+func text(content string, struck bool) *docs.ParagraphElement {
+	return &docs.ParagraphElement{TextRun: &docs.TextRun{Content: content, TextStyle: &docs.TextStyle{Strikethrough: struck}}}
+}
+
+// person returns a person chip.
+func person(name, email string) *docs.ParagraphElement {
+	return &docs.ParagraphElement{Person: &docs.Person{PersonProperties: &docs.PersonProperties{Name: name, Email: email}}}
+}
+
+func TestActionItems(t *testing.T) {
+	checklist := &docs.Bullet{ListId: "checklist"}
+	bulleted := &docs.Bullet{ListId: "bullets"}
+	lists := map[string]docs.List{
+		"checklist": {ListProperties: &docs.ListProperties{NestingLevels: []*docs.NestingLevel{{GlyphType: "GLYPH_TYPE_UNSPECIFIED"}}}},
+		"bullets":   {ListProperties: &docs.ListProperties{NestingLevels: []*docs.NestingLevel{{GlyphSymbol: "●"}}}},
+	}
+
+	tests := []struct {
+		name    string
+		content []*docs.StructuralElement
+		want    []ActionItem
+	}{
+		{
+			name:    "TODO line",
+			content: []*docs.StructuralElement{paragraph(nil, text("todo: Send the minutes\n", false))},
+			want:    []ActionItem{{Text: "Send the minutes"}},
+		},
+		{
+			name:    "plain paragraphs and bullets are not action items",
+			content: []*docs.StructuralElement{paragraph(nil, text("Notes\n", false)), paragraph(bulleted, text("Point\n", false))},
+		},
+		{
+			name:    "open checklist item",
+			content: []*docs.StructuralElement{paragraph(checklist, text("Book a room\n", false))},
+			want:    []ActionItem{{Text: "Book a room"}},
+		},
+		{
+			name:    "ticked checklist item",
+			content: []*docs.StructuralElement{paragraph(checklist, text("Book a room", true), text("\n", false))},
+			want:    []ActionItem{{Text: "Book a room", Checked: true}},
+		},
+		{
+			name:    "partly struck item is not ticked",
+			content: []*docs.StructuralElement{paragraph(checklist, text("Book ", true), text("a room\n", false))},
+			want:    []ActionItem{{Text: "Book a room"}},
+		},
+		{
+			name:    "person chip assignee",
+			content: []*docs.StructuralElement{paragraph(checklist, text("Review ", false), person("Bob", "bob@example.com"), text("\n", false))},
+			want:    []ActionItem{{Text: "Review @Bob", Assignee: "bob@example.com"}},
+		},
+		{
+			name:    "typed email assignee",
+			content: []*docs.StructuralElement{paragraph(nil, text("TODO: ask @carol@example.com\n", false))},
+			want:    []ActionItem{{Text: "ask @carol@example.com", Assignee: "carol@example.com"}},
+		},
+		{
+			name:    "empty TODO and empty checklist item",
+			content: []*docs.StructuralElement{paragraph(nil, text("TODO:\n", false)), paragraph(checklist, text("\n", false))},
+		},
+		{
+			name: "items inside tables",
+			content: []*docs.StructuralElement{{Table: &docs.Table{TableRows: []*docs.TableRow{{TableCells: []*docs.TableCell{
+				{Content: []*docs.StructuralElement{paragraph(nil, text("TODO: first\n", false))}},
+				{Content: []*docs.StructuralElement{paragraph(checklist, text("second\n", false))}},
+			}}}}}},
+			want: []ActionItem{{Text: "first"}, {Text: "second"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &docs.Document{Body: &docs.Body{Content: tt.content}, Lists: lists}
+			if got := actionItems(doc); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("actionItems = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,2142 @@
+package gmailHelper
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gnzdotmx/gworkspace-helper/auth"
+	"github.com/gnzdotmx/gworkspace-helper/gDriveHelper"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// Email is a message to be sent. Addresses may include a display name, e.g.,
+// "Jane Doe <jane@example.com>".
+type Email struct {
+	From     string // defaults to the authenticated user
+	To       []string
+	Cc       []string
+	Bcc      []string
+	ReplyTo  string
+	Subject  string
+	TextBody string
+	HTMLBody string // when both bodies are set, mail clients choose which one to show
+
+	Attachments []Attachment
+	DriveFiles  []DriveFile
+
+	// ThreadID adds the message to an existing thread. Gmail only threads it when the Subject
+	// matches and InReplyTo or References point at a message of the thread; ReplyToMessage
+	// sets all of them.
+	ThreadID   string
+	InReplyTo  string   // Message-ID header of the message being replied to, in angle brackets
+	References []string // Message-ID headers of the earlier messages of the thread, in angle brackets
+}
+
+// Attachment is a file attached to an email.
+type Attachment struct {
+	Filename string
+	MimeType string // defaults to application/octet-stream
+	Data     []byte
+
+	// ContentID embeds the attachment in the HTML body instead, where it is referenced as
+	// "cid:" followed by the ContentID, e.g., <img src="cid:logo">.
+	ContentID string
+}
+
+// DriveFile is a Drive file sent with an email, either attached or as a link.
+type DriveFile struct {
+	FileID string
+
+	// AsLink sends a link to the file instead of attaching it. Files that would push the email
+	// over MaxAttachmentSize are sent as links anyway. Google Docs, Sheets and Slides are attached
+	// as PDFs.
+	AsLink bool
+
+	// GrantAccess gives recipients who cannot open a linked file read access to it. Without it,
+	// SendEmail fails if any recipient lacks access.
+	GrantAccess bool
+}
+
+// MaxAttachmentSize is the largest total size of attachments Gmail accepts in one email.
+const MaxAttachmentSize = 25 << 20
+
+// ErrMessageTooLarge is returned when the attachments of an email exceed MaxAttachmentSize.
+var ErrMessageTooLarge = errors.New("gmailHelper: attachments exceed the 25 MB Gmail limit")
+
+// AttachLocalFile reads a local file into an Attachment, guessing its MIME type from the file
+// extension or, failing that, from its content.
+func AttachLocalFile(path string) (Attachment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("gmailHelper: unable to read attachment %s: %w", path, err)
+	}
+
+	mimeType := mime.TypeByExtension(filepath.Ext(path))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	return Attachment{Filename: filepath.Base(path), MimeType: mimeType, Data: data}, nil
+}
+
+// SendEmail sends an email from the authenticated user's mailbox and returns the sent message.
+func SendEmail(ctx context.Context, config auth.Config, email Email) (*gmail.Message, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	raw, err := encodeEmail(ctx, config, client, email)
+	if err != nil {
+		return nil, err
+	}
+
+	// A media upload accepts messages of up to 35 MB, well above what a raw field allows
+	sent, err := gmailService.Users.Messages.Send("me", &gmail.Message{ThreadId: email.ThreadID}).
+		Media(bytes.NewReader(raw), googleapi.ContentType("message/rfc822")).
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to send email: %w", err)
+	}
+	return sent, nil
+}
+
+// SendTemplatedEmail renders tmpl with data into the HTML body of email and sends it. Values are
+// escaped by html/template, so data can safely hold user content such as document titles.
+// Images referenced as cid: URLs in the template are not added automatically: add each one to
+// email.Attachments with the matching ContentID.
+func SendTemplatedEmail(ctx context.Context, config auth.Config, email Email, tmpl *template.Template, data interface{}) (*gmail.Message, error) {
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to render email template: %w", err)
+	}
+	email.HTMLBody = body.String()
+	return SendEmail(ctx, config, email)
+}
+
+// DraftInfo summarizes a draft.
+type DraftInfo struct {
+	ID        string
+	MessageID string
+	ThreadID  string
+	To        string
+	Subject   string
+	Snippet   string
+}
+
+// CreateDraft stages email as a draft in the authenticated user's mailbox, so it can be reviewed
+// before it is sent with SendDraft.
+func CreateDraft(ctx context.Context, config auth.Config, email Email) (*gmail.Draft, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	raw, err := encodeEmail(ctx, config, client, email)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := gmailService.Users.Drafts.Create("me", &gmail.Draft{Message: &gmail.Message{ThreadId: email.ThreadID}}).
+		Media(bytes.NewReader(raw), googleapi.ContentType("message/rfc822")).
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create draft: %w", err)
+	}
+	return draft, nil
+}
+
+// UpdateDraft replaces the content of a draft with email.
+func UpdateDraft(ctx context.Context, config auth.Config, draftID string, email Email) (*gmail.Draft, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	raw, err := encodeEmail(ctx, config, client, email)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := gmailService.Users.Drafts.Update("me", draftID, &gmail.Draft{Id: draftID, Message: &gmail.Message{ThreadId: email.ThreadID}}).
+		Media(bytes.NewReader(raw), googleapi.ContentType("message/rfc822")).
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to update draft %s: %w", draftID, err)
+	}
+	return draft, nil
+}
+
+// ListDrafts lists the drafts of the authenticated user, optionally filtered by a Gmail search
+// query such as "subject:report".
+func ListDrafts(ctx context.Context, config auth.Config, query string) ([]DraftInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	var drafts []DraftInfo
+	call := gmailService.Users.Drafts.List("me").Q(query)
+	err = call.Pages(ctx, func(list *gmail.ListDraftsResponse) error {
+		for _, item := range list.Drafts {
+			// The list only holds IDs, so the headers are read from each draft
+			draft, err := gmailService.Users.Drafts.Get("me", item.Id).
+				Format("metadata").
+				Context(ctx).
+				Do()
+			if err != nil {
+				return fmt.Errorf("gmailHelper: unable to retrieve draft %s: %w", item.Id, err)
+			}
+
+			info := DraftInfo{ID: draft.Id}
+			if message := draft.Message; message != nil {
+				info.MessageID = message.Id
+				info.ThreadID = message.ThreadId
+				info.Snippet = message.Snippet
+				info.To = headerValue(message.Payload, "To")
+				info.Subject = headerValue(message.Payload, "Subject")
+			}
+			drafts = append(drafts, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to list drafts: %w", err)
+	}
+	return drafts, nil
+}
+
+// SendDraft sends a draft to the recipients in its headers. The draft is removed from the
+// mailbox once it is sent.
+func SendDraft(ctx context.Context, config auth.Config, draftID string) (*gmail.Message, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	sent, err := gmailService.Users.Drafts.Send("me", &gmail.Draft{Id: draftID}).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to send draft %s: %w", draftID, err)
+	}
+	return sent, nil
+}
+
+// DeleteDraft permanently deletes a draft.
+func DeleteDraft(ctx context.Context, config auth.Config, draftID string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	if err := gmailService.Users.Drafts.Delete("me", draftID).Do(); err != nil {
+		return fmt.Errorf("gmailHelper: unable to delete draft %s: %w", draftID, err)
+	}
+	return nil
+}
+
+// Query builds a Gmail search query. Empty fields are ignored and the remaining criteria must
+// all match.
+type Query struct {
+	From          string
+	To            string
+	Subject       string
+	After         time.Time // messages received at or after this time
+	Before        time.Time // messages received before this time
+	HasAttachment bool
+	Label         string // label name, e.g., "INBOX" or "invoices"
+	Text          string // free text, in Gmail search syntax
+}
+
+// String returns the query in Gmail search syntax.
+func (q Query) String() string {
+	var terms []string
+	if q.From != "" {
+		terms = append(terms, "from:"+queryTerm(q.From))
+	}
+	if q.To != "" {
+		terms = append(terms, "to:"+queryTerm(q.To))
+	}
+	if q.Subject != "" {
+		terms = append(terms, "subject:"+queryTerm(q.Subject))
+	}
+	// Epoch seconds are honoured to the second, whereas dates are interpreted in PST
+	if !q.After.IsZero() {
+		terms = append(terms, "after:"+strconv.FormatInt(q.After.Unix(), 10))
+	}
+	if !q.Before.IsZero() {
+		terms = append(terms, "before:"+strconv.FormatInt(q.Before.Unix(), 10))
+	}
+	if q.HasAttachment {
+		terms = append(terms, "has:attachment")
+	}
+	if q.Label != "" {
+		// Gmail matches spaces in label names as dashes
+		terms = append(terms, "label:"+queryTerm(strings.ReplaceAll(q.Label, " ", "-")))
+	}
+	if q.Text != "" {
+		terms = append(terms, q.Text)
+	}
+	return strings.Join(terms, " ")
+}
+
+// queryTerm quotes value when it contains characters that would otherwise split it into
+// several search terms.
+func queryTerm(value string) string {
+	if !strings.ContainsAny(value, " \t()\"{}") {
+		return value
+	}
+	return `"` + strings.ReplaceAll(value, `"`, "") + `"`
+}
+
+// MessageSummary holds the headers of a message, without its body.
+type MessageSummary struct {
+	ID        string
+	ThreadID  string
+	MessageID string // the Message-ID header
+	From      string
+	To        string
+	Cc        string
+	Subject   string
+	Date      time.Time
+	Snippet   string
+	LabelIDs  []string
+}
+
+// SearchOptions holds optional settings for SearchMessages.
+type SearchOptions struct {
+	PageSize         int64 // messages fetched per request, defaults to the API default
+	IncludeSpamTrash bool
+}
+
+// MessageIterator iterates over the messages returned by SearchMessages, fetching pages on
+// demand.
+type MessageIterator struct {
+	ctx          context.Context
+	gmailService *gmail.Service
+	call         *gmail.UsersMessagesListCall
+	buffer       []*gmail.Message
+	pageToken    string
+	done         bool
+}
+
+// Next returns the next message, or iterator.Done when there are no more messages.
+func (it *MessageIterator) Next() (MessageSummary, error) {
+	for len(it.buffer) == 0 {
+		if it.done {
+			return MessageSummary{}, iterator.Done
+		}
+
+		messages, err := it.call.PageToken(it.pageToken).Context(it.ctx).Do()
+		if err != nil {
+			return MessageSummary{}, fmt.Errorf("gmailHelper: unable to search messages: %w", err)
+		}
+		it.buffer = messages.Messages
+		it.pageToken = messages.NextPageToken
+		it.done = it.pageToken == ""
+	}
+
+	// The list only holds IDs, so the headers are read from each message
+	message, err := it.gmailService.Users.Messages.Get("me", it.buffer[0].Id).
+		Format("metadata").
+		MetadataHeaders(summaryHeaders...).
+		Context(it.ctx).
+		Do()
+	if err != nil {
+		return MessageSummary{}, fmt.Errorf("gmailHelper: unable to retrieve message %s: %w", it.buffer[0].Id, err)
+	}
+	it.buffer = it.buffer[1:]
+	return toMessageSummary(message), nil
+}
+
+// All drains the iterator and returns the remaining messages.
+func (it *MessageIterator) All() ([]MessageSummary, error) {
+	var messages []MessageSummary
+	for {
+		message, err := it.Next()
+		if err == iterator.Done {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, message)
+	}
+}
+
+// SearchMessages searches the mailbox of the authenticated user, newest messages first.
+func SearchMessages(ctx context.Context, config auth.Config, query Query, opts SearchOptions) (*MessageIterator, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	call := gmailService.Users.Messages.List("me").Q(query.String()).IncludeSpamTrash(opts.IncludeSpamTrash)
+	if opts.PageSize > 0 {
+		call = call.MaxResults(opts.PageSize)
+	}
+
+	return &MessageIterator{ctx: ctx, gmailService: gmailService, call: call}, nil
+}
+
+// summaryHeaders are the headers read into a MessageSummary.
+var summaryHeaders = []string{"Message-ID", "From", "To", "Cc", "Subject", "Date"}
+
+// Thread is a conversation of messages, oldest first.
+type Thread struct {
+	ID       string
+	Messages []MessageSummary
+}
+
+// GetThread retrieves the messages of a thread.
+func GetThread(ctx context.Context, config auth.Config, threadID string) (*Thread, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	thread, err := gmailService.Users.Threads.Get("me", threadID).
+		Format("metadata").
+		MetadataHeaders(summaryHeaders...).
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to retrieve thread %s: %w", threadID, err)
+	}
+
+	result := &Thread{ID: thread.Id}
+	for _, message := range thread.Messages {
+		result.Messages = append(result.Messages, toMessageSummary(message))
+	}
+	return result, nil
+}
+
+// ReplyToMessage sends email as a reply to a message, in the same thread. When email has no
+// recipients, the reply goes to the sender of the message (its Reply-To header, if set), and
+// with replyAll also to the other recipients except the authenticated user. An empty Subject
+// becomes the subject of the message prefixed with "Re: ".
+func ReplyToMessage(ctx context.Context, config auth.Config, messageID string, email Email, replyAll bool) (*gmail.Message, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	original, err := gmailService.Users.Messages.Get("me", messageID).
+		Format("metadata").
+		MetadataHeaders("Message-ID", "References", "From", "Reply-To", "To", "Cc", "Subject").
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to retrieve message %s: %w", messageID, err)
+	}
+
+	originalID := headerValue(original.Payload, "Message-ID")
+	email.ThreadID = original.ThreadId
+	email.InReplyTo = originalID
+	email.References = strings.Fields(headerValue(original.Payload, "References"))
+	if originalID != "" {
+		email.References = append(email.References, originalID)
+	}
+
+	if email.Subject == "" {
+		email.Subject = headerValue(original.Payload, "Subject")
+		if !strings.HasPrefix(strings.ToLower(email.Subject), "re:") {
+			email.Subject = "Re: " + email.Subject
+		}
+	}
+
+	if len(email.To)+len(email.Cc)+len(email.Bcc) == 0 {
+		sender := headerValue(original.Payload, "Reply-To")
+		if sender == "" {
+			sender = headerValue(original.Payload, "From")
+		}
+		email.To = []string{sender}
+
+		if replyAll {
+			profile, err := gmailService.Users.GetProfile("me").Do()
+			if err != nil {
+				return nil, fmt.Errorf("gmailHelper: unable to retrieve user profile: %w", err)
+			}
+
+			skip := map[string]bool{strings.ToLower(profile.EmailAddress): true}
+			if addresses, err := mail.ParseAddressList(sender); err == nil {
+				for _, address := range addresses {
+					skip[strings.ToLower(address.Address)] = true
+				}
+			}
+			for _, name := range []string{"To", "Cc"} {
+				addresses, err := mail.ParseAddressList(headerValue(original.Payload, name))
+				if err != nil {
+					continue
+				}
+				for _, address := range addresses {
+					if skip[strings.ToLower(address.Address)] {
+						continue
+					}
+					skip[strings.ToLower(address.Address)] = true
+					email.Cc = append(email.Cc, address.String())
+				}
+			}
+		}
+	}
+
+	raw, err := encodeEmail(ctx, config, client, email)
+	if err != nil {
+		return nil, err
+	}
+
+	sent, err := gmailService.Users.Messages.Send("me", &gmail.Message{ThreadId: email.ThreadID}).
+		Media(bytes.NewReader(raw), googleapi.ContentType("message/rfc822")).
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to send reply: %w", err)
+	}
+	return sent, nil
+}
+
+// MessageAttachment describes a file attached to a received message.
+type MessageAttachment struct {
+	PartID       string
+	AttachmentID string // empty when the content is small enough to be inlined in the message
+	Filename     string
+	MimeType     string
+	Size         int64
+}
+
+// AttachmentFilter selects attachments of a message. Empty fields match every attachment.
+type AttachmentFilter struct {
+	Filename string // shell pattern matched case-insensitively, e.g., "*.pdf"
+	MimeType string // exact type, or a type with a wildcard subtype, e.g., "image/*"
+}
+
+// matches reports whether attachment is selected by the filter.
+func (f AttachmentFilter) matches(attachment MessageAttachment) bool {
+	if f.Filename != "" {
+		matched, err := path.Match(strings.ToLower(f.Filename), strings.ToLower(attachment.Filename))
+		if err != nil || !matched {
+			return false
+		}
+	}
+	if f.MimeType != "" {
+		mimeType := strings.ToLower(attachment.MimeType)
+		pattern := strings.ToLower(f.MimeType)
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			return strings.HasPrefix(mimeType, prefix+"/")
+		}
+		return mimeType == pattern
+	}
+	return true
+}
+
+// ListMessageAttachments lists the attachments of a message selected by filter.
+func ListMessageAttachments(ctx context.Context, config auth.Config, messageID string, filter AttachmentFilter) ([]MessageAttachment, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	attachments, _, err := findAttachments(gmailService, messageID, filter)
+	return attachments, err
+}
+
+// SaveAttachments downloads the attachments of a message selected by filter into dir and
+// returns the paths of the saved files. Attachments with the same filename are saved with a
+// numeric suffix instead of overwriting each other.
+func SaveAttachments(ctx context.Context, config auth.Config, messageID string, filter AttachmentFilter, dir string) ([]string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	attachments, parts, err := findAttachments(gmailService, messageID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	used := map[string]bool{}
+	for i, attachment := range attachments {
+		data, err := attachmentData(gmailService, messageID, parts[i])
+		if err != nil {
+			return paths, err
+		}
+
+		localPath := filepath.Join(dir, attachmentFileName(attachment.Filename, used))
+		if err := os.WriteFile(localPath, data, 0o644); err != nil {
+			return paths, fmt.Errorf("gmailHelper: unable to save attachment %s: %w", attachment.Filename, err)
+		}
+		paths = append(paths, localPath)
+	}
+	return paths, nil
+}
+
+// attachmentFileName returns a local file name for an attachment that cannot escape the
+// target directory and is not already in used, and records it in used.
+func attachmentFileName(filename string, used map[string]bool) string {
+	// Filenames come from the sender, so they must not escape dir
+	base := filepath.Base(filepath.Clean("/" + filename))
+	if base == "/" || base == "." {
+		base = "attachment"
+	}
+	ext := filepath.Ext(base)
+	name := base
+	for n := 1; used[name]; n++ {
+		name = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(base, ext), n, ext)
+	}
+	used[name] = true
+	return name
+}
+
+// UploadAttachmentsToDrive uploads the attachments of a message selected by filter into a
+// Drive folder, without going through the local disk.
+func UploadAttachmentsToDrive(ctx context.Context, config auth.Config, messageID string, filter AttachmentFilter, folderID string) ([]*drive.File, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create drive service: %w", err)
+	}
+
+	attachments, parts, err := findAttachments(gmailService, messageID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*drive.File
+	for i, attachment := range attachments {
+		data, err := attachmentData(gmailService, messageID, parts[i])
+		if err != nil {
+			return files, err
+		}
+
+		file := &drive.File{Name: attachment.Filename, MimeType: attachment.MimeType}
+		if folderID != "" {
+			file.Parents = []string{folderID}
+		}
+		uploaded, err := driveService.Files.Create(file).
+			Media(bytes.NewReader(data), googleapi.ContentType(attachment.MimeType)).
+			Fields("id", "name", "mimeType", "parents", "size", "webViewLink").
+			SupportsAllDrives(true).
+			Context(ctx).
+			Do()
+		if err != nil {
+			return files, fmt.Errorf("gmailHelper: unable to upload attachment %s: %w", attachment.Filename, err)
+		}
+		files = append(files, uploaded)
+	}
+	return files, nil
+}
+
+// findAttachments returns the attachments of a message selected by filter, along with the
+// message parts that hold them.
+func findAttachments(gmailService *gmail.Service, messageID string, filter AttachmentFilter) ([]MessageAttachment, []*gmail.MessagePart, error) {
+	message, err := gmailService.Users.Messages.Get("me", messageID).Format("full").Do()
+	if err != nil {
+		return nil, nil, fmt.Errorf("gmailHelper: unable to retrieve message %s: %w", messageID, err)
+	}
+
+	var attachments []MessageAttachment
+	var parts []*gmail.MessagePart
+	var walk func(part *gmail.MessagePart)
+	walk = func(part *gmail.MessagePart) {
+		if part == nil {
+			return
+		}
+		if part.Filename != "" && part.Body != nil {
+			attachment := toMessageAttachment(part)
+			if filter.matches(attachment) {
+				attachments = append(attachments, attachment)
+				parts = append(parts, part)
+			}
+		}
+		for _, child := range part.Parts {
+			walk(child)
+		}
+	}
+	walk(message.Payload)
+	return attachments, parts, nil
+}
+
+// toMessageAttachment describes the attachment held by a message part.
+func toMessageAttachment(part *gmail.MessagePart) MessageAttachment {
+	return MessageAttachment{
+		PartID:       part.PartId,
+		AttachmentID: part.Body.AttachmentId,
+		Filename:     part.Filename,
+		MimeType:     part.MimeType,
+		Size:         part.Body.Size,
+	}
+}
+
+// attachmentData returns the decoded content of a message part, fetching it separately when it
+// is not inlined in the message.
+func attachmentData(gmailService *gmail.Service, messageID string, part *gmail.MessagePart) ([]byte, error) {
+	encoded := part.Body.Data
+	if part.Body.AttachmentId != "" {
+		body, err := gmailService.Users.Messages.Attachments.Get("me", messageID, part.Body.AttachmentId).Do()
+		if err != nil {
+			return nil, fmt.Errorf("gmailHelper: unable to download attachment %s: %w", part.Filename, err)
+		}
+		encoded = body.Data
+	}
+
+	data, err := decodeBase64URL(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to decode attachment %s: %w", part.Filename, err)
+	}
+	return data, nil
+}
+
+// decodeBase64URL decodes the URL-safe base64 used by the Gmail API, with or without padding.
+func decodeBase64URL(encoded string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+}
+
+// MailboxWatch describes an active push notification watch on the mailbox.
+type MailboxWatch struct {
+	HistoryID  uint64    // pass to ListHistorySince to fetch the changes after the watch started
+	Expiration time.Time // call WatchMailbox again before this time to keep receiving notifications
+}
+
+// MailboxWatchOptions holds optional filters for WatchMailbox.
+type MailboxWatchOptions struct {
+	LabelIDs []string // only notify about changes to messages with these labels, e.g., "INBOX"
+	Exclude  bool     // notify about every change except those to messages with LabelIDs
+}
+
+// WatchMailbox publishes a notification to a Cloud Pub/Sub topic, e.g.,
+// "projects/my-project/topics/gmail", whenever the mailbox changes. The topic must grant
+// gmail-api-push@system.gserviceaccount.com the publisher role. Watches expire after 7 days;
+// calling WatchMailbox again renews the existing watch.
+func WatchMailbox(ctx context.Context, config auth.Config, topic string, opts MailboxWatchOptions) (*MailboxWatch, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	request := &gmail.WatchRequest{
+		TopicName: topic,
+		LabelIds:  opts.LabelIDs,
+	}
+	if len(opts.LabelIDs) > 0 {
+		request.LabelFilterBehavior = "include"
+		if opts.Exclude {
+			request.LabelFilterBehavior = "exclude"
+		}
+	}
+
+	response, err := gmailService.Users.Watch("me", request).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to watch mailbox: %w", err)
+	}
+	return &MailboxWatch{
+		HistoryID:  response.HistoryId,
+		Expiration: time.UnixMilli(response.Expiration),
+	}, nil
+}
+
+// StopMailboxWatch stops the push notifications of the mailbox.
+func StopMailboxWatch(ctx context.Context, config auth.Config) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	if err := gmailService.Users.Stop("me").Do(); err != nil {
+		return fmt.Errorf("gmailHelper: unable to stop mailbox watch: %w", err)
+	}
+	return nil
+}
+
+// MailboxNotification is the payload of a Pub/Sub message published by WatchMailbox.
+type MailboxNotification struct {
+	EmailAddress string `json:"emailAddress"`
+	HistoryID    uint64 `json:"historyId"`
+}
+
+// ParseMailboxNotification decodes the data of a Pub/Sub message published by WatchMailbox.
+// The notification only says that the mailbox changed; use ListHistorySince with the last
+// history ID processed to fetch the changes.
+func ParseMailboxNotification(data []byte) (MailboxNotification, error) {
+	var notification MailboxNotification
+	if err := json.Unmarshal(data, &notification); err != nil {
+		return notification, fmt.Errorf("gmailHelper: unable to parse mailbox notification: %w", err)
+	}
+	return notification, nil
+}
+
+// Mailbox history change types.
+const (
+	HistoryMessageAdded   = "messageAdded"
+	HistoryMessageDeleted = "messageDeleted"
+	HistoryLabelAdded     = "labelAdded"
+	HistoryLabelRemoved   = "labelRemoved"
+)
+
+// HistoryChange is a single change to the mailbox.
+type HistoryChange struct {
+	HistoryID uint64
+	Type      string // one of the History constants
+	MessageID string
+	ThreadID  string
+	LabelIDs  []string // labels added or removed, or the labels of an added message
+}
+
+// ErrHistoryExpired is returned by ListHistorySince when the start history ID is too old to be
+// used, typically after a week. The mailbox must then be synchronized in full, e.g., with
+// SearchMessages, before starting again from a fresh history ID.
+var ErrHistoryExpired = errors.New("gmailHelper: history ID is no longer valid, a full sync is required")
+
+// ListHistorySince returns the changes to the mailbox after startHistoryID, oldest first,
+// together with the history ID to pass on the next call. When types is empty, every type of
+// change is returned.
+func ListHistorySince(ctx context.Context, config auth.Config, startHistoryID uint64, types ...string) ([]HistoryChange, uint64, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, 0, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, 0, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	var changes []HistoryChange
+	nextHistoryID := startHistoryID
+	call := gmailService.Users.History.List("me").StartHistoryId(startHistoryID)
+	if len(types) > 0 {
+		call = call.HistoryTypes(types...)
+	}
+	err = call.Pages(ctx, func(page *gmail.ListHistoryResponse) error {
+		for _, history := range page.History {
+			for _, added := range history.MessagesAdded {
+				changes = append(changes, historyChange(history.Id, HistoryMessageAdded, added.Message, nil))
+			}
+			for _, deleted := range history.MessagesDeleted {
+				changes = append(changes, historyChange(history.Id, HistoryMessageDeleted, deleted.Message, nil))
+			}
+			for _, added := range history.LabelsAdded {
+				changes = append(changes, historyChange(history.Id, HistoryLabelAdded, added.Message, added.LabelIds))
+			}
+			for _, removed := range history.LabelsRemoved {
+				changes = append(changes, historyChange(history.Id, HistoryLabelRemoved, removed.Message, removed.LabelIds))
+			}
+		}
+		nextHistoryID = max(nextHistoryID, page.HistoryId)
+		return nil
+	})
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == 404 {
+			return nil, 0, ErrHistoryExpired
+		}
+		return nil, 0, fmt.Errorf("gmailHelper: unable to list history: %w", err)
+	}
+	return changes, nextHistoryID, nil
+}
+
+// historyChange builds a HistoryChange for a message of a history record.
+func historyChange(historyID uint64, changeType string, message *gmail.Message, labelIDs []string) HistoryChange {
+	change := HistoryChange{HistoryID: historyID, Type: changeType, LabelIDs: labelIDs}
+	if message != nil {
+		change.MessageID = message.Id
+		change.ThreadID = message.ThreadId
+		if change.LabelIDs == nil && changeType == HistoryMessageAdded {
+			change.LabelIDs = message.LabelIds
+		}
+	}
+	return change
+}
+
+// FilterCriteria selects the incoming messages a filter applies to. Empty fields are ignored
+// and the remaining criteria must all match.
+type FilterCriteria struct {
+	From          string
+	To            string
+	Subject       string
+	Query         string // Gmail search query, e.g., Query{Label: "invoices"}.String()
+	NegatedQuery  string // messages matching this query are skipped
+	HasAttachment bool
+	ExcludeChats  bool
+}
+
+// FilterAction is what a filter does to the messages it matches.
+type FilterAction struct {
+	AddLabelIDs    []string
+	RemoveLabelIDs []string
+	Forward        string // a verified forwarding address, see AddForwardingAddress
+	Archive        bool   // skip the inbox
+	MarkAsRead     bool
+	Star           bool
+	Delete         bool // move to the trash
+	NeverSpam      bool
+}
+
+// FilterInfo is an existing filter.
+type FilterInfo struct {
+	ID       string
+	Criteria FilterCriteria
+	Action   FilterAction
+}
+
+// CreateFilter creates a filter for the incoming mail of the authenticated user and returns its
+// ID. Filters cannot be edited; replace one by deleting it and creating a new one.
+func CreateFilter(ctx context.Context, config auth.Config, criteria FilterCriteria, action FilterAction) (string, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return "", fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	filterAction := &gmail.FilterAction{
+		AddLabelIds:    append([]string{}, action.AddLabelIDs...),
+		RemoveLabelIds: append([]string{}, action.RemoveLabelIDs...),
+		Forward:        action.Forward,
+	}
+	if action.Star {
+		filterAction.AddLabelIds = append(filterAction.AddLabelIds, "STARRED")
+	}
+	if action.Delete {
+		filterAction.AddLabelIds = append(filterAction.AddLabelIds, "TRASH")
+	}
+	if action.Archive {
+		filterAction.RemoveLabelIds = append(filterAction.RemoveLabelIds, "INBOX")
+	}
+	if action.MarkAsRead {
+		filterAction.RemoveLabelIds = append(filterAction.RemoveLabelIds, "UNREAD")
+	}
+	if action.NeverSpam {
+		filterAction.RemoveLabelIds = append(filterAction.RemoveLabelIds, "SPAM")
+	}
+
+	filter := &gmail.Filter{
+		Criteria: &gmail.FilterCriteria{
+			From:          criteria.From,
+			To:            criteria.To,
+			Subject:       criteria.Subject,
+			Query:         criteria.Query,
+			NegatedQuery:  criteria.NegatedQuery,
+			HasAttachment: criteria.HasAttachment,
+			ExcludeChats:  criteria.ExcludeChats,
+		},
+		Action: filterAction,
+	}
+
+	created, err := gmailService.Users.Settings.Filters.Create("me", filter).Do()
+	if err != nil {
+		return "", fmt.Errorf("gmailHelper: unable to create filter: %w", err)
+	}
+	return created.Id, nil
+}
+
+// ListFilters lists the filters of the authenticated user.
+func ListFilters(ctx context.Context, config auth.Config) ([]FilterInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	response, err := gmailService.Users.Settings.Filters.List("me").Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to list filters: %w", err)
+	}
+
+	var filters []FilterInfo
+	for _, filter := range response.Filter {
+		info := FilterInfo{ID: filter.Id}
+		if criteria := filter.Criteria; criteria != nil {
+			info.Criteria = FilterCriteria{
+				From:          criteria.From,
+				To:            criteria.To,
+				Subject:       criteria.Subject,
+				Query:         criteria.Query,
+				NegatedQuery:  criteria.NegatedQuery,
+				HasAttachment: criteria.HasAttachment,
+				ExcludeChats:  criteria.ExcludeChats,
+			}
+		}
+		if action := filter.Action; action != nil {
+			info.Action.Forward = action.Forward
+			for _, label := range action.AddLabelIds {
+				switch label {
+				case "STARRED":
+					info.Action.Star = true
+				case "TRASH":
+					info.Action.Delete = true
+				default:
+					info.Action.AddLabelIDs = append(info.Action.AddLabelIDs, label)
+				}
+			}
+			for _, label := range action.RemoveLabelIds {
+				switch label {
+				case "INBOX":
+					info.Action.Archive = true
+				case "UNREAD":
+					info.Action.MarkAsRead = true
+				case "SPAM":
+					info.Action.NeverSpam = true
+				default:
+					info.Action.RemoveLabelIDs = append(info.Action.RemoveLabelIDs, label)
+				}
+			}
+		}
+		filters = append(filters, info)
+	}
+	return filters, nil
+}
+
+// DeleteFilter deletes a filter.
+func DeleteFilter(ctx context.Context, config auth.Config, filterID string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	if err := gmailService.Users.Settings.Filters.Delete("me", filterID).Do(); err != nil {
+		return fmt.Errorf("gmailHelper: unable to delete filter %s: %w", filterID, err)
+	}
+	return nil
+}
+
+// ForwardingAddress is an address mail can be forwarded to.
+type ForwardingAddress struct {
+	Email              string
+	VerificationStatus string // "accepted" once the owner of the address confirmed it, or "pending"
+}
+
+// ListForwardingAddresses lists the forwarding addresses of the authenticated user.
+func ListForwardingAddresses(ctx context.Context, config auth.Config) ([]ForwardingAddress, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	response, err := gmailService.Users.Settings.ForwardingAddresses.List("me").Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to list forwarding addresses: %w", err)
+	}
+
+	var addresses []ForwardingAddress
+	for _, address := range response.ForwardingAddresses {
+		addresses = append(addresses, ForwardingAddress{
+			Email:              address.ForwardingEmail,
+			VerificationStatus: address.VerificationStatus,
+		})
+	}
+	return addresses, nil
+}
+
+// AddForwardingAddress adds a forwarding address. Unless the address belongs to the same domain,
+// Gmail sends it a verification message and it stays pending until confirmed. This call requires
+// a service account with domain-wide delegation, with config.Subject set to the mailbox owner.
+func AddForwardingAddress(ctx context.Context, config auth.Config, email string) (*ForwardingAddress, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	address, err := gmailService.Users.Settings.ForwardingAddresses.Create("me", &gmail.ForwardingAddress{ForwardingEmail: email}).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to add forwarding address %s: %w", email, err)
+	}
+	return &ForwardingAddress{Email: address.ForwardingEmail, VerificationStatus: address.VerificationStatus}, nil
+}
+
+// DeleteForwardingAddress removes a forwarding address, disabling auto-forwarding to it.
+// This call requires a service account with domain-wide delegation, with config.Subject set to
+// the mailbox owner.
+func DeleteForwardingAddress(ctx context.Context, config auth.Config, email string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	if err := gmailService.Users.Settings.ForwardingAddresses.Delete("me", email).Do(); err != nil {
+		return fmt.Errorf("gmailHelper: unable to delete forwarding address %s: %w", email, err)
+	}
+	return nil
+}
+
+// Dispositions of forwarded messages in the forwarding mailbox.
+const (
+	DispositionLeaveInInbox = "leaveInInbox"
+	DispositionArchive      = "archive"
+	DispositionTrash        = "trash"
+	DispositionMarkRead     = "markRead"
+)
+
+// AutoForwarding holds the auto-forwarding settings of a mailbox.
+type AutoForwarding struct {
+	Enabled     bool
+	Email       string // must be a verified forwarding address
+	Disposition string // one of the Disposition constants, what happens to the original message
+}
+
+// GetAutoForwarding returns the auto-forwarding settings of the authenticated user.
+func GetAutoForwarding(ctx context.Context, config auth.Config) (*AutoForwarding, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	settings, err := gmailService.Users.Settings.GetAutoForwarding("me").Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to get auto-forwarding settings: %w", err)
+	}
+	return &AutoForwarding{
+		Enabled:     settings.Enabled,
+		Email:       settings.EmailAddress,
+		Disposition: settings.Disposition,
+	}, nil
+}
+
+// SetAutoForwarding updates the auto-forwarding settings of the authenticated user. This call
+// requires a service account with domain-wide delegation, with config.Subject set to the mailbox
+// owner.
+func SetAutoForwarding(ctx context.Context, config auth.Config, settings AutoForwarding) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	if settings.Enabled && settings.Email == "" {
+		return fmt.Errorf("gmailHelper: auto-forwarding requires a forwarding address")
+	}
+	if settings.Enabled && settings.Disposition == "" {
+		settings.Disposition = DispositionLeaveInInbox
+	}
+
+	_, err = gmailService.Users.Settings.UpdateAutoForwarding("me", &gmail.AutoForwarding{
+		Enabled:         settings.Enabled,
+		EmailAddress:    settings.Email,
+		Disposition:     settings.Disposition,
+		ForceSendFields: []string{"Enabled"},
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("gmailHelper: unable to update auto-forwarding settings: %w", err)
+	}
+	return nil
+}
+
+// VacationResponder holds the auto-reply settings of a mailbox.
+type VacationResponder struct {
+	Enabled      bool
+	Subject      string
+	TextBody     string
+	HTMLBody     string    // takes precedence over TextBody when set
+	Start        time.Time // zero to start right away
+	End          time.Time // zero to reply until disabled
+	ContactsOnly bool      // only reply to senders in the user's contacts
+	DomainOnly   bool      // only reply to senders in the user's domain
+}
+
+// GetVacationResponder returns the vacation responder settings of the authenticated user.
+func GetVacationResponder(ctx context.Context, config auth.Config) (*VacationResponder, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	settings, err := gmailService.Users.Settings.GetVacation("me").Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to get vacation responder: %w", err)
+	}
+
+	responder := &VacationResponder{
+		Enabled:      settings.EnableAutoReply,
+		Subject:      settings.ResponseSubject,
+		TextBody:     settings.ResponseBodyPlainText,
+		HTMLBody:     settings.ResponseBodyHtml,
+		ContactsOnly: settings.RestrictToContacts,
+		DomainOnly:   settings.RestrictToDomain,
+	}
+	if settings.StartTime > 0 {
+		responder.Start = time.UnixMilli(settings.StartTime)
+	}
+	if settings.EndTime > 0 {
+		responder.End = time.UnixMilli(settings.EndTime)
+	}
+	return responder, nil
+}
+
+// SetVacationResponder replaces the vacation responder settings of the authenticated user.
+func SetVacationResponder(ctx context.Context, config auth.Config, responder VacationResponder) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	if !responder.Start.IsZero() && !responder.End.IsZero() && !responder.End.After(responder.Start) {
+		return fmt.Errorf("gmailHelper: vacation responder must end after it starts")
+	}
+
+	settings := &gmail.VacationSettings{
+		EnableAutoReply:       responder.Enabled,
+		ResponseSubject:       responder.Subject,
+		ResponseBodyPlainText: responder.TextBody,
+		ResponseBodyHtml:      responder.HTMLBody,
+		RestrictToContacts:    responder.ContactsOnly,
+		RestrictToDomain:      responder.DomainOnly,
+		ForceSendFields:       []string{"EnableAutoReply", "RestrictToContacts", "RestrictToDomain"},
+	}
+	if !responder.Start.IsZero() {
+		settings.StartTime = responder.Start.UnixMilli()
+	}
+	if !responder.End.IsZero() {
+		settings.EndTime = responder.End.UnixMilli()
+	}
+
+	if _, err := gmailService.Users.Settings.UpdateVacation("me", settings).Do(); err != nil {
+		return fmt.Errorf("gmailHelper: unable to update vacation responder: %w", err)
+	}
+	return nil
+}
+
+// SendAsInfo describes an address the authenticated user can send mail from.
+type SendAsInfo struct {
+	Email       string
+	DisplayName string
+	Signature   string // HTML
+	IsPrimary   bool
+	IsDefault   bool
+}
+
+// ListSendAs lists the addresses the authenticated user can send mail from, with their signatures.
+func ListSendAs(ctx context.Context, config auth.Config) ([]SendAsInfo, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	response, err := gmailService.Users.Settings.SendAs.List("me").Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to list send-as addresses: %w", err)
+	}
+
+	var addresses []SendAsInfo
+	for _, sendAs := range response.SendAs {
+		addresses = append(addresses, SendAsInfo{
+			Email:       sendAs.SendAsEmail,
+			DisplayName: sendAs.DisplayName,
+			Signature:   sendAs.Signature,
+			IsPrimary:   sendAs.IsPrimary,
+			IsDefault:   sendAs.IsDefault,
+		})
+	}
+	return addresses, nil
+}
+
+// SetSignature sets the HTML signature of a send-as address. An empty sendAsEmail selects the
+// primary address of the authenticated user, and an empty signature removes it.
+func SetSignature(ctx context.Context, config auth.Config, sendAsEmail, signature string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	if sendAsEmail == "" {
+		profile, err := gmailService.Users.GetProfile("me").Do()
+		if err != nil {
+			return fmt.Errorf("gmailHelper: unable to retrieve user profile: %w", err)
+		}
+		sendAsEmail = profile.EmailAddress
+	}
+
+	_, err = gmailService.Users.Settings.SendAs.Patch("me", sendAsEmail, &gmail.SendAs{
+		Signature:       signature,
+		ForceSendFields: []string{"Signature"},
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("gmailHelper: unable to set signature of %s: %w", sendAsEmail, err)
+	}
+	return nil
+}
+
+// SetSignatureFromTemplate renders tmpl with data, e.g., the name and title of the user, and sets
+// the result as the signature of a send-as address, so every user gets the same layout.
+func SetSignatureFromTemplate(ctx context.Context, config auth.Config, sendAsEmail string, tmpl *template.Template, data interface{}) error {
+	var signature bytes.Buffer
+	if err := tmpl.Execute(&signature, data); err != nil {
+		return fmt.Errorf("gmailHelper: unable to render signature template: %w", err)
+	}
+	return SetSignature(ctx, config, sendAsEmail, signature.String())
+}
+
+// Delegate is a user allowed to read, send, and delete mail on behalf of the mailbox owner.
+type Delegate struct {
+	Email              string
+	VerificationStatus string // "accepted", "pending", "rejected" or "expired"
+}
+
+// ListDelegates lists the delegates of the authenticated user's mailbox. Delegate calls require a
+// service account with domain-wide delegation, with config.Subject set to the mailbox owner.
+func ListDelegates(ctx context.Context, config auth.Config) ([]Delegate, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	response, err := gmailService.Users.Settings.Delegates.List("me").Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to list delegates: %w", err)
+	}
+
+	var delegates []Delegate
+	for _, delegate := range response.Delegates {
+		delegates = append(delegates, Delegate{
+			Email:              delegate.DelegateEmail,
+			VerificationStatus: delegate.VerificationStatus,
+		})
+	}
+	return delegates, nil
+}
+
+// AddDelegate grants a user of the same organization access to the authenticated user's
+// mailbox. The delegate is accepted right away, without a verification message.
+func AddDelegate(ctx context.Context, config auth.Config, email string) (*Delegate, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	delegate, err := gmailService.Users.Settings.Delegates.Create("me", &gmail.Delegate{DelegateEmail: email}).Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to add delegate %s: %w", email, err)
+	}
+	return &Delegate{Email: delegate.DelegateEmail, VerificationStatus: delegate.VerificationStatus}, nil
+}
+
+// RemoveDelegate revokes the access of a delegate to the authenticated user's mailbox.
+func RemoveDelegate(ctx context.Context, config auth.Config, email string) error {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	if err := gmailService.Users.Settings.Delegates.Delete("me", email).Do(); err != nil {
+		return fmt.Errorf("gmailHelper: unable to remove delegate %s: %w", email, err)
+	}
+	return nil
+}
+
+// ParsedMessage is a received message with its headers decoded and its bodies extracted.
+type ParsedMessage struct {
+	ID        string
+	ThreadID  string
+	LabelIDs  []string
+	MessageID string // the Message-ID header
+	From      string
+	ReplyTo   string
+	To        []string
+	Cc        []string
+	Subject   string
+	Date      time.Time
+	TextBody  string
+	HTMLBody  string
+
+	// Attachments lists the attached files without their content; download them with
+	// SaveAttachments or UploadAttachmentsToDrive. Attachments of raw messages have no
+	// AttachmentID.
+	Attachments []MessageAttachment
+}
+
+// GetMessage retrieves a message and parses it.
+func GetMessage(ctx context.Context, config auth.Config, messageID string) (*ParsedMessage, error) {
+	conf, token, err := auth.GetClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: failed to get authenticated client: %w", err)
+	}
+
+	client := conf.Client(ctx, token)
+	gmailService, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to create gmail service: %w", err)
+	}
+
+	message, err := gmailService.Users.Messages.Get("me", messageID).Format("full").Do()
+	if err != nil {
+		return nil, fmt.Errorf("gmailHelper: unable to retrieve message %s: %w", messageID, err)
+	}
+	return ParseMessage(message)
+}
+
+// ParseMessage parses a message retrieved in "full" or "raw" format. Only the first plain-text
+// and HTML bodies are kept; bodies are returned in the charset of the message.
+func ParseMessage(message *gmail.Message) (*ParsedMessage, error) {
+	parsed := &ParsedMessage{
+		ID:       message.Id,
+		ThreadID: message.ThreadId,
+		LabelIDs: message.LabelIds,
+	}
+
+	var header textproto.MIMEHeader
+	switch {
+	case message.Raw != "":
+		data, err := decodeBase64URL(message.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("gmailHelper: unable to decode message %s: %w", message.Id, err)
+		}
+		rawMessage, err := mail.ReadMessage(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("gmailHelper: unable to parse message %s: %w", message.Id, err)
+		}
+		header = textproto.MIMEHeader(rawMessage.Header)
+		if err := parsed.addMIMEPart(header, rawMessage.Body); err != nil {
+			return nil, fmt.Errorf("gmailHelper: unable to parse message %s: %w", message.Id, err)
+		}
+	case message.Payload != nil:
+		header = textproto.MIMEHeader{}
+		for _, h := range message.Payload.Headers {
+			header.Add(h.Name, h.Value)
+		}
+		if err := parsed.addPayloadPart(message.Payload); err != nil {
+			return nil, fmt.Errorf("gmailHelper: unable to parse message %s: %w", message.Id, err)
+		}
+	default:
+		return nil, fmt.Errorf("gmailHelper: message %s was not retrieved in full or raw format", message.Id)
+	}
+
+	parsed.MessageID = header.Get("Message-ID")
+	parsed.From = decodeHeader(header.Get("From"))
+	parsed.ReplyTo = decodeHeader(header.Get("Reply-To"))
+	parsed.To = splitAddresses(header.Get("To"))
+	parsed.Cc = splitAddresses(header.Get("Cc"))
+	parsed.Subject = decodeHeader(header.Get("Subject"))
+	if date, err := mail.ParseDate(header.Get("Date")); err == nil {
+		parsed.Date = date
+	} else if message.InternalDate > 0 {
+		parsed.Date = time.UnixMilli(message.InternalDate)
+	}
+	return parsed, nil
+}
+
+// addPayloadPart adds the bodies and attachments of a message part decoded by the Gmail API.
+func (m *ParsedMessage) addPayloadPart(part *gmail.MessagePart) error {
+	if part.Body != nil && part.Filename != "" {
+		m.Attachments = append(m.Attachments, toMessageAttachment(part))
+		return nil
+	}
+	if part.Body != nil && part.Body.Data != "" {
+		data, err := decodeBase64URL(part.Body.Data)
+		if err != nil {
+			return err
+		}
+		m.addBody(strings.ToLower(part.MimeType), string(data))
+	}
+	for _, child := range part.Parts {
+		if err := m.addPayloadPart(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addMIMEPart adds the bodies and attachments of a MIME entity of a raw message.
+func (m *ParsedMessage) addMIMEPart(header textproto.MIMEHeader, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			// Raw parts keep their transfer encoding, which is decoded below for every part alike
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := m.addMIMEPart(part.Header, part); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if filename != "" || disposition == "attachment" {
+		m.Attachments = append(m.Attachments, MessageAttachment{
+			Filename: decodeHeader(filename),
+			MimeType: mediaType,
+			Size:     int64(len(content)),
+		})
+		return nil
+	}
+	m.addBody(mediaType, string(content))
+	return nil
+}
+
+// addBody keeps content as the plain-text or HTML body, unless one was already found.
+func (m *ParsedMessage) addBody(mediaType, content string) {
+	switch {
+	case mediaType == "text/plain" && m.TextBody == "":
+		m.TextBody = content
+	case mediaType == "text/html" && m.HTMLBody == "":
+		m.HTMLBody = content
+	}
+}
+
+// decodeHeader decodes the RFC 2047 encoded words of a header value, returning the value as is
+// when it uses an unsupported charset.
+func decodeHeader(value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// splitAddresses splits an address list header into its addresses.
+func splitAddresses(value string) []string {
+	if value == "" {
+		return nil
+	}
+	addresses, err := mail.ParseAddressList(value)
+	if err != nil {
+		return []string{decodeHeader(value)}
+	}
+
+	var result []string
+	for _, address := range addresses {
+		if address.Name == "" {
+			result = append(result, address.Address)
+		} else {
+			result = append(result, fmt.Sprintf("%s <%s>", address.Name, address.Address))
+		}
+	}
+	return result
+}
+
+// toMessageSummary converts a message fetched in metadata or full format into a MessageSummary.
+func toMessageSummary(message *gmail.Message) MessageSummary {
+	summary := MessageSummary{
+		ID:        message.Id,
+		ThreadID:  message.ThreadId,
+		MessageID: headerValue(message.Payload, "Message-ID"),
+		From:      headerValue(message.Payload, "From"),
+		To:        headerValue(message.Payload, "To"),
+		Cc:        headerValue(message.Payload, "Cc"),
+		Subject:   headerValue(message.Payload, "Subject"),
+		Snippet:   message.Snippet,
+		LabelIDs:  message.LabelIds,
+	}
+	if date, err := mail.ParseDate(headerValue(message.Payload, "Date")); err == nil {
+		summary.Date = date
+	} else if message.InternalDate > 0 {
+		summary.Date = time.UnixMilli(message.InternalDate)
+	}
+	return summary
+}
+
+// headerValue returns the first header of part with the given name, compared case-insensitively.
+func headerValue(part *gmail.MessagePart, name string) string {
+	if part == nil {
+		return ""
+	}
+	for _, header := range part.Headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+// encodeEmail resolves the Drive files of email into attachments or links and returns the
+// encoded RFC 2822 message.
+func encodeEmail(ctx context.Context, config auth.Config, client *http.Client, email Email) ([]byte, error) {
+	if len(email.DriveFiles) > 0 {
+		driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+		if err != nil {
+			return nil, fmt.Errorf("gmailHelper: unable to create drive service: %w", err)
+		}
+		email, err = addDriveFiles(ctx, config, driveService, email)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buildMIME(email)
+}
+
+// mimePart is an encoded MIME entity: its headers and its body, ready to be written.
+type mimePart struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// buildMIME renders email as an RFC 2822 message.
+func buildMIME(email Email) ([]byte, error) {
+	if len(email.To)+len(email.Cc)+len(email.Bcc) == 0 {
+		return nil, fmt.Errorf("gmailHelper: email has no recipients")
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("MIME-Version", "1.0")
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", email.Subject))
+	for name, addresses := range map[string][]string{"To": email.To, "Cc": email.Cc, "Bcc": email.Bcc} {
+		if len(addresses) == 0 {
+			continue
+		}
+		formatted, err := formatAddresses(addresses)
+		if err != nil {
+			return nil, err
+		}
+		header.Set(name, formatted)
+	}
+	for name, address := range map[string]string{"From": email.From, "Reply-To": email.ReplyTo} {
+		if address == "" {
+			continue
+		}
+		formatted, err := formatAddresses([]string{address})
+		if err != nil {
+			return nil, err
+		}
+		header.Set(name, formatted)
+	}
+	if email.InReplyTo != "" {
+		if !validMessageID(email.InReplyTo) {
+			return nil, fmt.Errorf("gmailHelper: invalid In-Reply-To message ID %q", email.InReplyTo)
+		}
+		header.Set("In-Reply-To", email.InReplyTo)
+	}
+	if len(email.References) > 0 {
+		for _, reference := range email.References {
+			if !validMessageID(reference) {
+				return nil, fmt.Errorf("gmailHelper: invalid References message ID %q", reference)
+			}
+		}
+		header.Set("References", strings.Join(email.References, " "))
+	}
+
+	total := 0
+	var inline, attached []mimePart
+	for _, attachment := range email.Attachments {
+		total += len(attachment.Data)
+		if attachment.ContentID != "" {
+			if !validContentID(attachment.ContentID) {
+				return nil, fmt.Errorf("gmailHelper: invalid content ID %q for attachment %s", attachment.ContentID, attachment.Filename)
+			}
+			inline = append(inline, attachmentPart(attachment))
+		} else {
+			attached = append(attached, attachmentPart(attachment))
+		}
+	}
+	if total > MaxAttachmentSize {
+		return nil, ErrMessageTooLarge
+	}
+	if len(inline) > 0 && email.HTMLBody == "" {
+		return nil, fmt.Errorf("gmailHelper: inline attachments require an HTML body")
+	}
+
+	body, err := bodyPart(email, inline)
+	if err != nil {
+		return nil, err
+	}
+	if len(attached) > 0 {
+		body, err = multipartPart("mixed", append([]mimePart{body}, attached...)...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for name, values := range body.header {
+		header[name] = values
+	}
+
+	var buffer bytes.Buffer
+	writeHeader(&buffer, header)
+	buffer.Write(body.body)
+	return buffer.Bytes(), nil
+}
+
+// validMessageID reports whether id is a single message ID in angle brackets, e.g.,
+// "<abc@mail.gmail.com>". Rejecting anything else keeps caller values from adding header fields.
+func validMessageID(id string) bool {
+	return len(id) > 2 && id[0] == '<' && id[len(id)-1] == '>' && validContentID(id[1:len(id)-1])
+}
+
+// validContentID reports whether id can be written between the angle brackets of a Content-ID
+// header: it must not be empty nor contain spaces, control characters, or angle brackets.
+func validContentID(id string) bool {
+	return id != "" && !strings.ContainsFunc(id, func(r rune) bool {
+		return r <= ' ' || r == 0x7f || r == '<' || r == '>'
+	})
+}
+
+// bodyPart returns the text and HTML bodies of email as a single part, or as a
+// multipart/alternative part when both are set. The HTML body is wrapped in a multipart/related
+// part together with the inline attachments, if any.
+func bodyPart(email Email, inline []mimePart) (mimePart, error) {
+	if email.HTMLBody == "" {
+		return textPart("text/plain", email.TextBody)
+	}
+
+	html, err := textPart("text/html", email.HTMLBody)
+	if err != nil {
+		return mimePart{}, err
+	}
+	if len(inline) > 0 {
+		html, err = multipartPart("related", append([]mimePart{html}, inline...)...)
+		if err != nil {
+			return mimePart{}, err
+		}
+	}
+	if email.TextBody == "" {
+		return html, nil
+	}
+
+	text, err := textPart("text/plain", email.TextBody)
+	if err != nil {
+		return mimePart{}, err
+	}
+	return multipartPart("alternative", text, html)
+}
+
+// textPart encodes content as a quoted-printable UTF-8 part of the given media type.
+func textPart(mediaType, content string) (mimePart, error) {
+	var body bytes.Buffer
+	writer := quotedprintable.NewWriter(&body)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		return mimePart{}, fmt.Errorf("gmailHelper: unable to encode %s body: %w", mediaType, err)
+	}
+	if err := writer.Close(); err != nil {
+		return mimePart{}, fmt.Errorf("gmailHelper: unable to encode %s body: %w", mediaType, err)
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mediaType+"; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return mimePart{header: header, body: body.Bytes()}, nil
+}
+
+// attachmentPart encodes an attachment as a base64 part.
+func attachmentPart(attachment Attachment) mimePart {
+	mimeType := attachment.MimeType
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	disposition := "attachment"
+	header := textproto.MIMEHeader{}
+	if attachment.ContentID != "" {
+		disposition = "inline"
+		header.Set("Content-ID", "<"+attachment.ContentID+">")
+	}
+	header.Set("Content-Type", mime.FormatMediaType(mimeType, map[string]string{"name": attachment.Filename}))
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	header.Set("Content-Transfer-Encoding", "base64")
+	return mimePart{header: header, body: base64Lines(attachment.Data)}
+}
+
+// base64Lines encodes data as base64 wrapped at 76 characters per line, as MIME requires.
+func base64Lines(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var body bytes.Buffer
+	for len(encoded) > 76 {
+		body.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	body.WriteString(encoded + "\r\n")
+	return body.Bytes()
+}
+
+// multipartPart combines parts into a multipart part of the given subtype, e.g., "mixed".
+func multipartPart(subtype string, parts ...mimePart) (mimePart, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range parts {
+		partWriter, err := writer.CreatePart(part.header)
+		if err != nil {
+			return mimePart{}, fmt.Errorf("gmailHelper: unable to build MIME message: %w", err)
+		}
+		if _, err := partWriter.Write(part.body); err != nil {
+			return mimePart{}, fmt.Errorf("gmailHelper: unable to build MIME message: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return mimePart{}, fmt.Errorf("gmailHelper: unable to build MIME message: %w", err)
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", fmt.Sprintf("multipart/%s; boundary=%q", subtype, writer.Boundary()))
+	return mimePart{header: header, body: body.Bytes()}, nil
+}
+
+// writeHeader writes header fields in a stable order, followed by the blank line that ends them.
+func writeHeader(buffer *bytes.Buffer, header textproto.MIMEHeader) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			fmt.Fprintf(buffer, "%s: %s\r\n", name, value)
+		}
+	}
+	buffer.WriteString("\r\n")
+}
+
+// formatAddresses validates addresses and joins them into a header value, encoding display names
+// as needed.
+func formatAddresses(addresses []string) (string, error) {
+	formatted := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return "", fmt.Errorf("gmailHelper: invalid email address %q: %w", address, err)
+		}
+		formatted = append(formatted, parsed.String())
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+// addDriveFiles returns a copy of email with its Drive files attached, or linked from the bodies
+// when requested or when attaching them would exceed MaxAttachmentSize.
+func addDriveFiles(ctx context.Context, config auth.Config, driveService *drive.Service, email Email) (Email, error) {
+	email.Attachments = append([]Attachment(nil), email.Attachments...)
+	total := 0
+	for _, attachment := range email.Attachments {
+		total += len(attachment.Data)
+	}
+
+	var linkedFiles []*drive.File
+	for _, driveFile := range email.DriveFiles {
+		file, err := driveService.Files.Get(driveFile.FileID).Fields("id", "name", "mimeType", "size", "webViewLink").SupportsAllDrives(true).Do()
+		if err != nil {
+			return email, fmt.Errorf("gmailHelper: unable to retrieve Drive file %s: %w", driveFile.FileID, err)
+		}
+
+		if !driveFile.AsLink && (file.Size == 0 || total+int(file.Size) <= MaxAttachmentSize) {
+			attachment, err := downloadAttachment(ctx, config, driveService, file)
+			if err != nil {
+				return email, err
+			}
+			if total+len(attachment.Data) <= MaxAttachmentSize {
+				total += len(attachment.Data)
+				email.Attachments = append(email.Attachments, attachment)
+				continue
+			}
+		}
+
+		if err := ensureRecipientAccess(ctx, config, email, file, driveFile.GrantAccess); err != nil {
+			return email, err
+		}
+		linkedFiles = append(linkedFiles, file)
+	}
+
+	if len(linkedFiles) > 0 {
+		if email.TextBody != "" || email.HTMLBody == "" {
+			var builder strings.Builder
+			builder.WriteString(email.TextBody)
+			builder.WriteString("\n")
+			for _, file := range linkedFiles {
+				fmt.Fprintf(&builder, "\n%s: %s", file.Name, file.WebViewLink)
+			}
+			email.TextBody = builder.String()
+		}
+		if email.HTMLBody != "" {
+			var builder strings.Builder
+			builder.WriteString(email.HTMLBody)
+			for _, file := range linkedFiles {
+				fmt.Fprintf(&builder, "<p><a href=\"%s\">%s</a></p>", html.EscapeString(file.WebViewLink), html.EscapeString(file.Name))
+			}
+			email.HTMLBody = builder.String()
+		}
+	}
+	return email, nil
+}
+
+// downloadAttachment downloads a Drive file as an attachment, exporting Google Docs, Sheets and
+// Slides as PDFs.
+func downloadAttachment(ctx context.Context, config auth.Config, driveService *drive.Service, file *drive.File) (Attachment, error) {
+	var buffer bytes.Buffer
+	if strings.HasPrefix(file.MimeType, "application/vnd.google-apps.") {
+		response, err := driveService.Files.Export(file.Id, "application/pdf").Context(ctx).Download()
+		if err != nil {
+			return Attachment{}, fmt.Errorf("gmailHelper: unable to export Drive file %s: %w", file.Id, err)
+		}
+		defer response.Body.Close()
+		if _, err := io.Copy(&buffer, response.Body); err != nil {
+			return Attachment{}, fmt.Errorf("gmailHelper: unable to export Drive file %s: %w", file.Id, err)
+		}
+		return Attachment{Filename: file.Name + ".pdf", MimeType: "application/pdf", Data: buffer.Bytes()}, nil
+	}
+
+	if err := gDriveHelper.DownloadFile(ctx, config, file.Id, &buffer, true); err != nil {
+		return Attachment{}, err
+	}
+	return Attachment{Filename: file.Name, MimeType: file.MimeType, Data: buffer.Bytes()}, nil
+}
+
+// ensureRecipientAccess checks that every recipient of email can open file, granting read access
+// to those who cannot when grant is set.
+func ensureRecipientAccess(ctx context.Context, config auth.Config, email Email, file *drive.File, grant bool) error {
+	permissions, err := gDriveHelper.ListPermissions(ctx, config, file.Id)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, recipient := range append(append(append([]string(nil), email.To...), email.Cc...), email.Bcc...) {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return fmt.Errorf("gmailHelper: invalid email address %q: %w", recipient, err)
+		}
+		if !hasAccess(permissions, address.Address) {
+			missing = append(missing, address.Address)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if !grant {
+		return fmt.Errorf("gmailHelper: %s cannot open %s", strings.Join(missing, ", "), file.Name)
+	}
+
+	for _, address := range missing {
+		_, err := gDriveHelper.AddPermission(ctx, config, file.Id, gDriveHelper.PermissionOptions{
+			Type:         "user",
+			Role:         "reader",
+			EmailAddress: address,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasAccess reports whether any of permissions lets address open the file. A group permission
+// only matches the address of the group itself, as group membership is not expanded, so members
+// mailed individually are reported as missing access.
+func hasAccess(permissions []gDriveHelper.PermissionInfo, address string) bool {
+	_, domain, _ := strings.Cut(address, "@")
+	for _, permission := range permissions {
+		switch permission.Type {
+		case "anyone":
+			return true
+		case "domain":
+			if strings.EqualFold(permission.Domain, domain) {
+				return true
+			}
+		case "user", "group":
+			if strings.EqualFold(permission.Email, address) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,415 @@
+package gmailHelper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// mimeStructure describes the MIME tree of a message, e.g., "multipart/mixed[text/plain,application/pdf]".
+func mimeStructure(t *testing.T, contentType string, body io.Reader) string {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("invalid Content-Type %q: %v", contentType, err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return mediaType
+	}
+
+	var parts []string
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unable to read part of %s: %v", mediaType, err)
+		}
+		parts = append(parts, mimeStructure(t, part.Header.Get("Content-Type"), part))
+	}
+	return mediaType + "[" + strings.Join(parts, ",") + "]"
+}
+
+func TestBuildMIMEStructure(t *testing.T) {
+	logo := Attachment{Filename: "logo.png", MimeType: "image/png", Data: []byte{0x89, 'P', 'N', 'G'}, ContentID: "logo"}
+	report := Attachment{Filename: "report.pdf", MimeType: "application/pdf", Data: []byte("%PDF-1.4")}
+
+	tests := []struct {
+		name  string
+		email Email
+		want  string
+	}{
+		{name: "text only", email: Email{TextBody: "Hi"}, want: "text/plain"},
+		{name: "HTML only", email: Email{HTMLBody: "<p>Hi</p>"}, want: "text/html"},
+		{name: "text and HTML", email: Email{TextBody: "Hi", HTMLBody: "<p>Hi</p>"}, want: "multipart/alternative[text/plain,text/html]"},
+		{
+			name:  "inline image",
+			email: Email{HTMLBody: `<img src="cid:logo">`, Attachments: []Attachment{logo}},
+			want:  "multipart/related[text/html,image/png]",
+		},
+		{
+			name:  "attachment",
+			email: Email{TextBody: "Hi", Attachments: []Attachment{report}},
+			want:  "multipart/mixed[text/plain,application/pdf]",
+		},
+		{
+			name:  "everything",
+			email: Email{TextBody: "Hi", HTMLBody: `<img src="cid:logo">`, Attachments: []Attachment{report, logo}},
+			want:  "multipart/mixed[multipart/alternative[text/plain,multipart/related[text/html,image/png]],application/pdf]",
+		},
+		{
+			name:  "attachment without a type",
+			email: Email{TextBody: "Hi", Attachments: []Attachment{{Filename: "data.bin", Data: []byte{1}}}},
+			want:  "multipart/mixed[text/plain,application/octet-stream]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.email.To = []string{"jane@example.com"}
+			raw, err := buildMIME(tt.email)
+			if err != nil {
+				t.Fatalf("buildMIME: %v", err)
+			}
+			message, err := mail.ReadMessage(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatalf("unable to parse message: %v", err)
+			}
+			if got := mimeStructure(t, message.Header.Get("Content-Type"), message.Body); got != tt.want {
+				t.Fatalf("structure = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildMIMEHeaders(t *testing.T) {
+	email := Email{
+		From:       "Jane Doe <jane@example.com>",
+		To:         []string{"bob@example.com", "Carol <carol@example.com>"},
+		ReplyTo:    "replies@example.com",
+		Subject:    "Café meeting",
+		TextBody:   "Hi",
+		InReplyTo:  "<b@mail.example.com>",
+		References: []string{"<a@mail.example.com>", "<b@mail.example.com>"},
+	}
+	raw, err := buildMIME(email)
+	if err != nil {
+		t.Fatalf("buildMIME: %v", err)
+	}
+	message, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("unable to parse message: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("unable to decode subject: %v", err)
+	}
+	want := map[string]string{
+		"Subject":     email.Subject,
+		"From":        `"Jane Doe" <jane@example.com>`,
+		"To":          "<bob@example.com>, \"Carol\" <carol@example.com>",
+		"Reply-To":    "<replies@example.com>",
+		"In-Reply-To": "<b@mail.example.com>",
+		"References":  "<a@mail.example.com> <b@mail.example.com>",
+	}
+	got := map[string]string{"Subject": subject}
+	for name := range want {
+		if name != "Subject" {
+			got[name] = message.Header.Get(name)
+		}
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %q, want %q", name, got[name], value)
+		}
+	}
+}
+
+func TestBuildMIMEErrors(t *testing.T) {
+	to := []string{"jane@example.com"}
+	tests := []struct {
+		name  string
+		email Email
+	}{
+		{name: "no recipients", email: Email{TextBody: "Hi"}},
+		{name: "invalid address", email: Email{To: []string{"not an address"}}},
+		{name: "inline attachment without HTML", email: Email{To: to, TextBody: "Hi", Attachments: []Attachment{{Filename: "a.png", ContentID: "a"}}}},
+		{name: "too large", email: Email{To: to, Attachments: []Attachment{{Filename: "big", Data: make([]byte, MaxAttachmentSize+1)}}}},
+		{name: "In-Reply-To with a header break", email: Email{To: to, InReplyTo: "<a@example.com>\r\nBcc: eve@example.com"}},
+		{name: "In-Reply-To without angle brackets", email: Email{To: to, InReplyTo: "a@example.com"}},
+		{name: "References with a header break", email: Email{To: to, References: []string{"<a@example.com>\nBcc: eve@example.com"}}},
+		{name: "References with two IDs in one value", email: Email{To: to, References: []string{"<a@example.com> <b@example.com>"}}},
+		{name: "empty message ID", email: Email{To: to, InReplyTo: "<>"}},
+		{
+			name:  "content ID with a header break",
+			email: Email{To: to, HTMLBody: "<p>Hi</p>", Attachments: []Attachment{{Filename: "a.png", ContentID: "a>\r\nBcc: eve@example.com"}}},
+		},
+		{
+			name:  "content ID with angle brackets",
+			email: Email{To: to, HTMLBody: "<p>Hi</p>", Attachments: []Attachment{{Filename: "a.png", ContentID: "<a>"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := buildMIME(tt.email); err == nil {
+				t.Fatalf("buildMIME succeeded, want an error")
+			}
+		})
+	}
+}
+
+// rawMessage encodes lines as the raw field of a message, joined with CRLF line breaks.
+func rawMessage(lines ...string) string {
+	return base64.URLEncoding.EncodeToString([]byte(strings.Join(lines, "\r\n")))
+}
+
+// payloadData encodes content as the data of a message part body.
+func payloadData(content string) *gmail.MessagePartBody {
+	return &gmail.MessagePartBody{Data: base64.URLEncoding.EncodeToString([]byte(content)), Size: int64(len(content))}
+}
+
+func TestParseMessage(t *testing.T) {
+	internalDate := time.Date(2024, time.May, 2, 8, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		message  *gmail.Message
+		want     ParsedMessage
+		wantDate time.Time
+	}{
+		{
+			name: "raw nested multipart with encoded headers",
+			message: &gmail.Message{Id: "m1", ThreadId: "t1", LabelIds: []string{"INBOX"}, Raw: rawMessage(
+				"From: =?UTF-8?Q?Jos=C3=A9?= <jose@example.com>",
+				"To: Jane Doe <jane@example.com>, bob@example.com",
+				"Cc: =?UTF-8?B?w4lsaWU=?= <elie@example.com>",
+				"Subject: =?UTF-8?Q?Caf=C3=A9_menu?=",
+				"Date: Wed, 01 May 2024 10:00:00 +0200",
+				"Message-ID: <m1@example.com>",
+				`Content-Type: multipart/mixed; boundary="outer"`,
+				"",
+				"--outer",
+				`Content-Type: multipart/alternative; boundary="inner"`,
+				"",
+				"--inner",
+				"Content-Type: text/plain; charset=UTF-8",
+				"Content-Transfer-Encoding: quoted-printable",
+				"",
+				"Caf=C3=A9 at noon=",
+				", see you",
+				"--inner",
+				"Content-Type: text/html; charset=UTF-8",
+				"Content-Transfer-Encoding: base64",
+				"",
+				"PHA+Q2Fmw6k8L3A+",
+				"--inner--",
+				"--outer",
+				"Content-Type: application/pdf",
+				`Content-Disposition: attachment; filename="menu.pdf"`,
+				"Content-Transfer-Encoding: base64",
+				"",
+				"JVBERi0x",
+				"--outer--",
+			)},
+			want: ParsedMessage{
+				ID:          "m1",
+				ThreadID:    "t1",
+				LabelIDs:    []string{"INBOX"},
+				MessageID:   "<m1@example.com>",
+				From:        "José <jose@example.com>",
+				To:          []string{"Jane Doe <jane@example.com>", "bob@example.com"},
+				Cc:          []string{"Élie <elie@example.com>"},
+				Subject:     "Café menu",
+				TextBody:    "Café at noon, see you",
+				HTMLBody:    "<p>Café</p>",
+				Attachments: []MessageAttachment{{Filename: "menu.pdf", MimeType: "application/pdf", Size: 6}},
+			},
+			wantDate: time.Date(2024, time.May, 1, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "raw attachment named by its content type and a bad date",
+			message: &gmail.Message{Id: "m2", InternalDate: internalDate.UnixMilli(), Raw: rawMessage(
+				"Subject: Photo",
+				"Date: yesterday",
+				"Content-Type: multipart/mixed; boundary=b",
+				"",
+				"--b",
+				"Content-Type: text/plain",
+				"",
+				"hello",
+				"--b",
+				`Content-Type: image/png; name="=?UTF-8?Q?caf=C3=A9.png?="`,
+				"Content-Transfer-Encoding: base64",
+				"",
+				"iVBORw==",
+				"--b--",
+			)},
+			want: ParsedMessage{
+				ID:          "m2",
+				Subject:     "Photo",
+				TextBody:    "hello",
+				Attachments: []MessageAttachment{{Filename: "café.png", MimeType: "image/png", Size: 4}},
+			},
+			wantDate: internalDate,
+		},
+		{
+			name: "raw single part without a content type",
+			message: &gmail.Message{Id: "m3", Raw: rawMessage(
+				"Subject: Plain",
+				"",
+				"Just text",
+			)},
+			want: ParsedMessage{ID: "m3", Subject: "Plain", TextBody: "Just text"},
+		},
+		{
+			name: "payload with nested parts",
+			message: &gmail.Message{Id: "m4", InternalDate: internalDate.UnixMilli(), Payload: &gmail.MessagePart{
+				MimeType: "multipart/mixed",
+				Headers: []*gmail.MessagePartHeader{
+					{Name: "Subject", Value: "=?UTF-8?B?UsOpc3Vtw6k=?="},
+					{Name: "From", Value: "jane@example.com"},
+					{Name: "To", Value: "bob@example.com"},
+					{Name: "Date", Value: "not a date"},
+				},
+				Parts: []*gmail.MessagePart{
+					{MimeType: "multipart/alternative", Parts: []*gmail.MessagePart{
+						{MimeType: "text/plain", Body: payloadData("plain")},
+						{MimeType: "TEXT/HTML", Body: payloadData("<b>html</b>")},
+					}},
+					{PartId: "1", Filename: "cv.pdf", MimeType: "application/pdf", Body: &gmail.MessagePartBody{AttachmentId: "att1", Size: 1234}},
+				},
+			}},
+			want: ParsedMessage{
+				ID:          "m4",
+				From:        "jane@example.com",
+				To:          []string{"bob@example.com"},
+				Subject:     "Résumé",
+				TextBody:    "plain",
+				HTMLBody:    "<b>html</b>",
+				Attachments: []MessageAttachment{{PartID: "1", AttachmentID: "att1", Filename: "cv.pdf", MimeType: "application/pdf", Size: 1234}},
+			},
+			wantDate: internalDate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseMessage(tt.message)
+			if err != nil {
+				t.Fatalf("ParseMessage: %v", err)
+			}
+			if !parsed.Date.Equal(tt.wantDate) {
+				t.Errorf("Date = %s, want %s", parsed.Date, tt.wantDate)
+			}
+			parsed.Date = time.Time{}
+			if !reflect.DeepEqual(*parsed, tt.want) {
+				t.Errorf("ParseMessage = %+v, want %+v", *parsed, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMessageWithoutContent(t *testing.T) {
+	if _, err := ParseMessage(&gmail.Message{Id: "m1"}); err == nil {
+		t.Fatalf("ParseMessage of a metadata-only message succeeded, want an error")
+	}
+}
+
+func TestQueryString(t *testing.T) {
+	after := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2024, time.February, 1, 12, 30, 0, 0, time.FixedZone("JST", 9*60*60))
+
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{
+		{name: "empty", query: Query{}, want: ""},
+		{name: "plain values", query: Query{From: "jane@example.com", To: "bob@example.com", Subject: "invoice"}, want: "from:jane@example.com to:bob@example.com subject:invoice"},
+		{name: "value with spaces is quoted", query: Query{Subject: "quarterly report"}, want: `subject:"quarterly report"`},
+		{name: "embedded quotes are stripped", query: Query{Subject: `say "hi" now`}, want: `subject:"say hi now"`},
+		{name: "parentheses are quoted", query: Query{From: "(team)"}, want: `from:"(team)"`},
+		{name: "label spaces become dashes", query: Query{Label: "my receipts"}, want: "label:my-receipts"},
+		{name: "epoch bounds", query: Query{After: after, Before: before}, want: "after:1704067200 before:1706758200"},
+		{name: "attachment and free text", query: Query{HasAttachment: true, Text: "larger:5M -in:chats"}, want: "has:attachment larger:5M -in:chats"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.String(); got != tt.want {
+				t.Fatalf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachmentFilterMatches(t *testing.T) {
+	pdf := MessageAttachment{Filename: "Report.PDF", MimeType: "application/pdf"}
+	png := MessageAttachment{Filename: "photo.png", MimeType: "Image/PNG"}
+
+	tests := []struct {
+		name       string
+		filter     AttachmentFilter
+		attachment MessageAttachment
+		want       bool
+	}{
+		{name: "empty filter", filter: AttachmentFilter{}, attachment: pdf, want: true},
+		{name: "pattern ignores case", filter: AttachmentFilter{Filename: "*.pdf"}, attachment: pdf, want: true},
+		{name: "upper-case pattern", filter: AttachmentFilter{Filename: "*.PDF"}, attachment: pdf, want: true},
+		{name: "pattern mismatch", filter: AttachmentFilter{Filename: "*.PDF"}, attachment: png, want: false},
+		{name: "malformed pattern", filter: AttachmentFilter{Filename: "[.pdf"}, attachment: pdf, want: false},
+		{name: "wildcard subtype", filter: AttachmentFilter{MimeType: "image/*"}, attachment: png, want: true},
+		{name: "wildcard subtype mismatch", filter: AttachmentFilter{MimeType: "image/*"}, attachment: pdf, want: false},
+		{name: "exact type", filter: AttachmentFilter{MimeType: "application/pdf"}, attachment: pdf, want: true},
+		{name: "both fields", filter: AttachmentFilter{Filename: "*.png", MimeType: "image/*"}, attachment: png, want: true},
+		{name: "both fields, type mismatch", filter: AttachmentFilter{Filename: "*.pdf", MimeType: "image/*"}, attachment: pdf, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(tt.attachment); got != tt.want {
+				t.Fatalf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachmentFileName(t *testing.T) {
+	tests := []struct {
+		name      string
+		filenames []string
+		want      []string
+	}{
+		{name: "plain", filenames: []string{"report.pdf"}, want: []string{"report.pdf"}},
+		{name: "parent traversal", filenames: []string{"../../x"}, want: []string{"x"}},
+		{name: "absolute path", filenames: []string{"/etc/passwd"}, want: []string{"passwd"}},
+		{name: "root", filenames: []string{"/"}, want: []string{"attachment"}},
+		{name: "empty", filenames: []string{""}, want: []string{"attachment"}},
+		{name: "dot", filenames: []string{"."}, want: []string{"attachment"}},
+		{
+			name:      "duplicates get a suffix",
+			filenames: []string{"a.txt", "a.txt", "dir/a.txt", "", ""},
+			want:      []string{"a.txt", "a (1).txt", "a (2).txt", "attachment", "attachment (1)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used := map[string]bool{}
+			var got []string
+			for _, filename := range tt.filenames {
+				got = append(got, attachmentFileName(filename, used))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("attachmentFileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
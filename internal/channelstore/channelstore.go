@@ -0,0 +1,128 @@
+// Package channelstore persists push notification channels in a JSON file and renews them
+// before they expire. It backs the channel stores of gDriveHelper and gMeetHelper.
+package channelstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// File stores channels of type T in a JSON object keyed by channel ID. The path is passed on
+// every call, so the zero value is ready to use. Calls are serialized, and every write replaces
+// the file atomically so a crash never leaves a truncated store behind.
+type File[T any] struct {
+	mu sync.Mutex
+}
+
+// Save adds or replaces the channel stored under id.
+func (f *File[T]) Save(path, id string, channel T) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	channels, err := read[T](path)
+	if err != nil {
+		return err
+	}
+	channels[id] = channel
+	return write(path, channels)
+}
+
+// Delete removes the channel stored under id.
+func (f *File[T]) Delete(path, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	channels, err := read[T](path)
+	if err != nil {
+		return err
+	}
+	delete(channels, id)
+	return write(path, channels)
+}
+
+// List returns all stored channels, soonest to expire first.
+func (f *File[T]) List(path string, expiration func(T) time.Time) ([]T, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	channels, err := read[T](path)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]T, 0, len(channels))
+	for _, channel := range channels {
+		list = append(list, channel)
+	}
+	sort.Slice(list, func(i, j int) bool { return expiration(list[i]).Before(expiration(list[j])) })
+	return list, nil
+}
+
+func read[T any](path string) (map[string]T, error) {
+	channels := map[string]T{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return channels, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read channel store: %w", err)
+	}
+	if err := json.Unmarshal(data, &channels); err != nil {
+		return nil, fmt.Errorf("unable to decode channel store: %w", err)
+	}
+	return channels, nil
+}
+
+func write[T any](path string, channels map[string]T) error {
+	data, err := json.MarshalIndent(channels, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode channel store: %w", err)
+	}
+
+	// Write next to the store and rename over it, which is atomic on the same file system.
+	// CreateTemp already restricts the file to its owner
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("unable to write channel store: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write channel store: %w", err)
+	}
+	return nil
+}
+
+// Renew replaces every channel that expires before deadline. The replacement is registered
+// before the old channel is stopped so no notifications are missed. It returns the
+// replacements made before the first error.
+func Renew[T any](channels []T, deadline time.Time, expiration func(T) time.Time, register func(T) (*T, error), stop func(T) error) ([]T, error) {
+	var renewed []T
+	for _, channel := range channels {
+		if expiration(channel).After(deadline) {
+			continue
+		}
+
+		replacement, err := register(channel)
+		if err != nil {
+			return renewed, err
+		}
+		if err := stop(channel); err != nil {
+			return renewed, err
+		}
+		renewed = append(renewed, *replacement)
+	}
+	return renewed, nil
+}
@@ -0,0 +1,104 @@
+package channelstore
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type channel struct {
+	ID         string    `json:"id"`
+	Expiration time.Time `json:"expiration"`
+}
+
+func expiration(c channel) time.Time { return c.Expiration }
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "channels.json")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var file File[channel]
+	for _, c := range []channel{
+		{ID: "late", Expiration: now.Add(2 * time.Hour)},
+		{ID: "soon", Expiration: now.Add(time.Hour)},
+		{ID: "gone", Expiration: now},
+	} {
+		if err := file.Save(path, c.ID, c); err != nil {
+			t.Fatalf("Save(%q): %v", c.ID, err)
+		}
+	}
+	if err := file.Delete(path, "gone"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	// A fresh File reads what the first one wrote
+	var reopened File[channel]
+	list, err := reopened.List(path, expiration)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != "soon" || list[1].ID != "late" {
+		t.Fatalf("List = %+v, want soon then late", list)
+	}
+}
+
+func TestRenew(t *testing.T) {
+	now := time.Now()
+	channels := []channel{
+		{ID: "a", Expiration: now.Add(time.Minute)},
+		{ID: "b", Expiration: now.Add(time.Hour)},
+		{ID: "c", Expiration: now.Add(2 * time.Minute)},
+	}
+	failing := errors.New("failing")
+
+	tests := []struct {
+		name        string
+		failOn      string
+		wantRenewed []string
+		wantStopped []string
+		wantErr     bool
+	}{
+		{name: "renews channels due before the deadline", wantRenewed: []string{"a2", "c2"}, wantStopped: []string{"a", "c"}},
+		{name: "stops at the first failure", failOn: "c", wantRenewed: []string{"a2"}, wantStopped: []string{"a"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stopped []string
+			register := func(c channel) (*channel, error) {
+				if c.ID == tt.failOn {
+					return nil, failing
+				}
+				return &channel{ID: c.ID + "2", Expiration: now.Add(time.Hour)}, nil
+			}
+			stop := func(c channel) error {
+				stopped = append(stopped, c.ID)
+				return nil
+			}
+
+			renewed, err := Renew(channels, now.Add(10*time.Minute), expiration, register, stop)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Renew error = %v, want error %v", err, tt.wantErr)
+			}
+			var ids []string
+			for _, c := range renewed {
+				ids = append(ids, c.ID)
+			}
+			if !equal(ids, tt.wantRenewed) || !equal(stopped, tt.wantStopped) {
+				t.Fatalf("renewed %v and stopped %v, want %v and %v", ids, stopped, tt.wantRenewed, tt.wantStopped)
+			}
+		})
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+// Package concurrency runs batches of API calls with a bounded number of workers and a rate
+// limit. It is shared by the batch helpers of gDriveHelper and gMeetHelper.
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ForEach calls fn for every index in [0, n) using a bounded number of workers and an optional
+// rate limit. A non-positive concurrency defaults to 4 workers and a non-positive
+// requestsPerSecond to 5 calls per second. It stops scheduling new work once ctx is cancelled.
+func ForEach(ctx context.Context, n, concurrency int, requestsPerSecond float64, fn func(i int)) {
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	if requestsPerSecond <= 0 {
+		requestsPerSecond = 5
+	}
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / requestsPerSecond))
+	defer ticker.Stop()
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+schedule:
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			break schedule
+		case <-ticker.C:
+		}
+		select {
+		case <-ctx.Done():
+			break schedule
+		case indexes <- i:
+		}
+	}
+	close(indexes)
+	wg.Wait()
+}
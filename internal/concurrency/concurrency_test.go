@@ -0,0 +1,93 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachRunsEveryIndexOnce(t *testing.T) {
+	const n = 50
+	var mu sync.Mutex
+	calls := make([]int, n)
+
+	ForEach(context.Background(), n, 3, 1000, func(i int) {
+		mu.Lock()
+		calls[i]++
+		mu.Unlock()
+	})
+
+	for i, count := range calls {
+		if count != 1 {
+			t.Errorf("index %d ran %d times, want 1", i, count)
+		}
+	}
+}
+
+func TestForEachBoundsWorkers(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		want        int32
+	}{
+		{name: "explicit", concurrency: 2, want: 2},
+		{name: "default", concurrency: 0, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var active, peak int32
+			ForEach(context.Background(), 20, tt.concurrency, 1000, func(int) {
+				current := atomic.AddInt32(&active, 1)
+				for {
+					previous := atomic.LoadInt32(&peak)
+					if current <= previous || atomic.CompareAndSwapInt32(&peak, previous, current) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				atomic.AddInt32(&active, -1)
+			})
+
+			if peak > tt.want {
+				t.Fatalf("%d calls ran at once, want at most %d", peak, tt.want)
+			}
+			if peak < 2 {
+				t.Fatalf("%d calls ran at once, want calls to overlap", peak)
+			}
+			if active != 0 {
+				t.Fatalf("%d calls still running after ForEach returned", active)
+			}
+		})
+	}
+}
+
+func TestForEachStopsOnCancel(t *testing.T) {
+	const n = 1000
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int32
+	ForEach(ctx, n, 2, 1000, func(i int) {
+		if atomic.AddInt32(&calls, 1) == 3 {
+			cancel()
+		}
+	})
+
+	if calls >= n {
+		t.Fatalf("ForEach ran all %d calls after ctx was cancelled", calls)
+	}
+}
+
+func TestForEachCancelledBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var calls int32
+	ForEach(ctx, 10, 2, 1000, func(int) { atomic.AddInt32(&calls, 1) })
+
+	if calls != 0 {
+		t.Fatalf("ForEach ran %d calls with a cancelled ctx, want 0", calls)
+	}
+}
@@ -60,22 +60,23 @@ func main() {
 	// ************** CREATE A GOOGLE CALENDAR EVENT
 	startTime := time.Now()
 	endTime := startTime.Add(1 * time.Hour)
-	event, err := gMeetHelper.CreateCalendarEvent(ctx, authConfig, "Meeting", "Virtual", "Discuss project updates", startTime, endTime)
+	event, err := gMeetHelper.CreateCalendarEvent(ctx, authConfig, "Meeting", "Virtual", "Discuss project updates", startTime, endTime, gMeetHelper.EventOptions{})
 	if err != nil {
 		log.Fatalf("main: unable to create calendar event: %v", err)
 	}
 	fmt.Printf("Created event with ID: %s\n", event.Id)
+	fmt.Printf("Meet link: %s\n", gMeetHelper.GetConferenceInfo(event).MeetLink)
 
 	// ************** ADD ATTENDEES TO THE EVENT
 	attendees := []string{"user@gmail.com"}
-	err = gMeetHelper.AddAttendeesToEvent(ctx, authConfig, event.Id, attendees)
+	err = gMeetHelper.AddAttendeesToEvent(ctx, authConfig, "primary", event.Id, attendees, gMeetHelper.SendUpdatesAll)
 	if err != nil {
 		log.Fatalf("main: unable to add attendees to event: %v", err)
 	}
 	fmt.Println("Added attendees to the event.")
 
 	// Attach the copied document to the event
-	err = gMeetHelper.AttachFileToEvent(ctx, authConfig, event.Id, copiedFile.Id)
+	err = gMeetHelper.AttachFileToEvent(ctx, authConfig, "primary", event.Id, copiedFile.Id)
 	if err != nil {
 		log.Fatalf("main: unable to attach file to event: %v", err)
 	}